@@ -5,12 +5,15 @@
 package main
 
 import (
+	"encoding/json"
 	"flag"
 	"fmt"
 	"log"
 	"os"
+	"strings"
 
 	"github.com/fsgo/fsgo/cmds/rpcdump/internal"
+	"github.com/fsgo/fsgo/fsio"
 	"github.com/fsgo/fsgo/fsnet/fsconn/conndump"
 )
 
@@ -22,6 +25,39 @@ var connID = flag.Int64("cid", -1, `filter only which conn ID.
 var action = flag.String("a", "rwc", "filter action. r: Read, w:Write, c:Close; rc: Read and Close")
 var service = flag.String("s", "", "filter only which service")
 var detail = flag.Bool("d", true, "print details")
+var format = flag.String("f", "", `payload format, works with -cid >= 0:
+""        : raw payload
+hexdump   : same as 'hexdump -C'
+quote     : Go-quoted lines
+protocol  : detect HTTP/1.x, Redis RESP and fsrpc
+`)
+var color = flag.Bool("color", false, "colorize read/write direction markers")
+var decode = flag.String("decode", "", `reassemble the streams by ConnID and decode them, print as JSON.
+auto, http, resp or fsrpc`)
+var serverSide = flag.Bool("server", false, `with -decode, the dump files are from server side.
+only used for dump files without Direction`)
+var bodyMax = flag.Int("bodymax", 1024, "with -decode, max length of the body to print")
+var matches stringsFlag
+
+func init() {
+	flag.Var(&matches, "match", `with -decode, filter by the decoded fields, can be used multiple times.
+format: name=pattern, pattern uses the syntax of path.Match.
+e.g. Path=/api/*, Request.Method=user.*, Command.0=GET`)
+}
+
+type stringsFlag []string
+
+func (sf *stringsFlag) String() string {
+	return strings.Join(*sf, " ")
+}
+
+func (sf *stringsFlag) Set(v string) error {
+	if !strings.Contains(v, "=") {
+		return fmt.Errorf("invalid match %q, should be name=pattern", v)
+	}
+	*sf = append(*sf, v)
+	return nil
+}
 
 // Usage:
 // cat all messages:
@@ -29,14 +65,78 @@ var detail = flag.Bool("d", true, "print details")
 //
 // cat cid=1's messages:
 // dumpcat -cid=1 dump.pb.202205222200
+//
+// decode the HTTP requests whose path has prefix /api/:
+// dumpcat -decode http -match 'Path=/api/*' dump.pb.202205222200
 func main() {
 	flag.Parse()
 
+	pf, err := fsio.ParsePrintFormat(*format)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	printer.Format = pf
+
+	if len(*decode) > 0 {
+		decodeFiles(flag.Args())
+		return
+	}
+
 	for _, fp := range flag.Args() {
 		catFile(fp)
 	}
 }
 
+func decodeFiles(files []string) {
+	internal.BodyMax = *bodyMax
+	sd := &internal.StreamDecoder{
+		ServerSide: *serverSide,
+	}
+	if *decode != "auto" {
+		if sd.Decoder = internal.FindDecoder(*decode); sd.Decoder == nil {
+			log.Fatalln("unknown decoder:", *decode)
+		}
+	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetEscapeHTML(false)
+	output := func(rds []*internal.Record) {
+		for _, rd := range rds {
+			if matchRecord(rd) {
+				_ = enc.Encode(rd)
+			}
+		}
+	}
+	for _, fp := range files {
+		f, err := os.Open(fp)
+		if err != nil {
+			log.Println("open file ", fp, " failed, ", err)
+			continue
+		}
+		_ = conndump.Scan(f, func(msg *conndump.Message) bool {
+			if *connID > 0 && *connID != msg.GetConnID() {
+				return true
+			}
+			if len(*service) > 0 && *service != msg.GetService() {
+				return true
+			}
+			output(sd.Decode(msg))
+			return true
+		})
+		_ = f.Close()
+	}
+	// 没有 Close 消息的连接，如 dump 时连接还未关闭
+	output(sd.Flush())
+}
+
+func matchRecord(rd *internal.Record) bool {
+	for _, m := range matches {
+		if !rd.Match(m) {
+			return false
+		}
+	}
+	return true
+}
+
 func catFile(fp string) {
 	f, err := os.Open(fp)
 	if err != nil {
@@ -53,6 +153,10 @@ func catFile(fp string) {
 		if !filter(msg) {
 			return true
 		}
+		if len(*format) > 0 {
+			printPayload(msg)
+			return true
+		}
 		if *detail {
 			fmt.Println(internal.FormatMessage(msg, true))
 		}
@@ -78,3 +182,21 @@ func filter(msg *conndump.Message) bool {
 	}
 	return true
 }
+
+var printer = &fsio.PrintByteWriter{
+	Out: os.Stdout,
+}
+
+func printPayload(msg *conndump.Message) {
+	printer.Color = *color
+	printer.Name = msg.GetAction().String()
+	switch msg.GetAction() {
+	case conndump.MessageAction_Read:
+		printer.Direction = fsio.PrintDirectionRead
+	case conndump.MessageAction_Write:
+		printer.Direction = fsio.PrintDirectionWrite
+	default:
+		printer.Direction = fsio.PrintDirectionNone
+	}
+	_, _ = printer.WriteWithMeta(msg.GetPayload(), internal.FormatMessage(msg, false))
+}
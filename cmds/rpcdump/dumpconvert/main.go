@@ -0,0 +1,99 @@
+// Copyright(C) 2026 github.com/fsgo  All Rights Reserved.
+// Author: hidu <duv123@gmail.com>
+// Date: 2026/10/19
+
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/fsgo/fsgo/fsnet/fsconn/conndump"
+)
+
+var format = flag.String("format", "pcapng", "output format: pcapng or har")
+var out = flag.String("o", "", `output file, default is "dump.pcapng" or "dump.har"`)
+var local = flag.String("local", "", `local addr, e.g. "10.0.0.1:8080".
+default is the LocalAddr in messages if exists,
+otherwise 127.0.0.1 (or ::1) with port generated by ConnID`)
+var server = flag.Bool("server", false, "the dump files are from server side, the remote peers are clients.\nignored when messages have Direction")
+
+// Usage:
+// dumpconvert -o client.pcapng dump_data/client/dump.pb.*
+// dumpconvert -server -local 10.0.0.1:8080 -o server.pcapng dump_data/server/dump.pb.*
+// dumpconvert -format har -o client.har dump_data/client/dump.pb.*
+func main() {
+	flag.Parse()
+	if flag.NArg() == 0 {
+		log.Fatalln("no dump files")
+	}
+	if *format != "pcapng" && *format != "har" {
+		log.Fatalln("not support format:", *format)
+	}
+	if *out == "" {
+		*out = "dump." + *format
+	}
+
+	f, err := os.Create(*out)
+	if err != nil {
+		log.Fatalln("create output file failed:", err)
+	}
+	bw := bufio.NewWriter(f)
+	if *format == "har" {
+		err = convertHAR(bw)
+	} else {
+		err = convertPcapng(bw)
+	}
+	if err != nil {
+		log.Fatalln(err)
+	}
+	if err = bw.Flush(); err != nil {
+		log.Fatalln("write output file failed:", err)
+	}
+	if err = f.Close(); err != nil {
+		log.Fatalln("close output file failed:", err)
+	}
+	log.Println("saved to", *out)
+}
+
+func convertPcapng(w io.Writer) error {
+	pw := &conndump.PcapngWriter{
+		Writer:         w,
+		LocalAddr:      *local,
+		RemoteIsClient: *server,
+	}
+	return convertFiles(pw.Convert)
+}
+
+func convertHAR(w io.Writer) error {
+	hc := &conndump.HARConverter{
+		ServerSide: *server,
+	}
+	if err := convertFiles(hc.Convert); err != nil {
+		return err
+	}
+	_, err := hc.HAR().WriteTo(w)
+	return err
+}
+
+func convertFiles(convert func(rd io.Reader) error) error {
+	for _, fp := range flag.Args() {
+		if err := convertFile(convert, fp); err != nil {
+			return fmt.Errorf("convert %s failed: %w", fp, err)
+		}
+	}
+	return nil
+}
+
+func convertFile(convert func(rd io.Reader) error, fp string) error {
+	f, err := os.Open(fp)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return convert(bufio.NewReader(f))
+}
@@ -6,6 +6,7 @@ package main
 
 import (
 	"flag"
+	"fmt"
 	"io"
 	"log"
 	"net"
@@ -22,7 +23,22 @@ var connID = flag.Int64("cid", 0, "filter the connID field")
 var action = flag.String("a", "rwc", "filter the action filed. r: Read,w:Write,c:Close; rc: Read and Close")
 var service = flag.String("s", "", "filter the service field")
 var to = flag.String("to", "", "replay data to. can be a net addr, eg 127.0.0.1:8080, default to stdout")
-var conc = flag.Int("conc", 1, "number of multiple requests to make at a time")
+var conc = flag.Int("conc", 1, "number of multiple requests to make at a time, not supported with -speed or -verify")
+var speed = flag.Float64("speed", 0, `replay with the original intervals between messages, scaled by speed.
+e.g. 1: original speed, 2: twice as fast. 0: as fast as possible`)
+var verify = flag.Bool("verify", false, "read responses from the target and compare them with the recorded ones")
+var waitResp = flag.Duration("wait", 3*time.Second, "max time to wait for the responses after the last request")
+var serverSide = flag.Bool("server", false, `the dump files are from server side, the requests are the Read messages.
+only used for dump files without Direction`)
+var normalizers normalizerFlags
+
+func init() {
+	flag.Var(&normalizers, "norm", `normalize the responses before comparing, can be used multiple times.
+header:Name  : remove HTTP header Name
+http-date    : remove HTTP header Date
+regexp:expr  : remove the contents matched the regexp
+trim-space   : remove leading and trailing white space`)
+}
 
 // Usage:
 // cat all messages:
@@ -30,11 +46,18 @@ var conc = flag.Int("conc", 1, "number of multiple requests to make at a time")
 //
 // cat cid=1's messages:
 // dumpreplay -cid=1 dump.pb.202205222200
+//
+// replay with the original intervals and compare the responses:
+// dumpreplay -to 127.0.0.1:8080 -speed 1 -verify -norm http-date dump.pb.202205222200
 
 var w *writer
 
 func main() {
 	flag.Parse()
+	if *speed > 0 || *verify {
+		replaySessions()
+		return
+	}
 	w = newWriter()
 	defer w.Close()
 
@@ -68,6 +91,54 @@ func replayFile(fp string) {
 	wg.Wait()
 }
 
+// replaySessions 按照原始的时间间隔回放，并读取响应
+func replaySessions() {
+	if len(*to) == 0 {
+		log.Fatalln("-to is required with -speed or -verify")
+	}
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == "conc" {
+			log.Fatalln("-conc is not supported with -speed or -verify, all the connections are replayed concurrently")
+		}
+	})
+	sr := &sessionReplayer{}
+	for _, fp := range flag.Args() {
+		f, err := os.Open(fp)
+		if err != nil {
+			log.Println("open file ", fp, " failed, ", err)
+			continue
+		}
+		var wg sync.WaitGroup
+		// 每个文件以其第一条需要回放的消息的时间为基准
+		var clock *sessionClock
+		cs := &conndump.ChanScanner{
+			Filter: func(msg *conndump.Message) bool {
+				if !filter(msg) {
+					return false
+				}
+				if clock == nil {
+					clock = newSessionClock(msg)
+				}
+				return true
+			},
+			Receiver: func(msgs <-chan *conndump.Message) bool {
+				wg.Add(1)
+				c := clock
+				go func() {
+					defer wg.Done()
+					sr.replay(msgs, c)
+				}()
+				return true
+			},
+		}
+		_ = cs.Scan(f)
+		cs.Close()
+		wg.Wait()
+		_ = f.Close()
+	}
+	fmt.Println(sr.Summary())
+}
+
 func filter(msg *conndump.Message) bool {
 	if *connID > 0 && *connID != msg.GetConnID() {
 		return false
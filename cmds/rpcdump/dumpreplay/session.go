@@ -0,0 +1,248 @@
+// Copyright(C) 2026 github.com/fsgo  All Rights Reserved.
+// Author: hidu <duv123@gmail.com>
+// Date: 2026/10/19
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsgo/fsgo/cmds/rpcdump/internal"
+	"github.com/fsgo/fsgo/fsnet/fsconn/conndump"
+)
+
+// normalizerFlags -norm 参数，可以有多个
+type normalizerFlags struct {
+	list internal.Normalizers
+	expr []string
+}
+
+func (nf *normalizerFlags) String() string {
+	return strings.Join(nf.expr, " ")
+}
+
+func (nf *normalizerFlags) Set(expr string) error {
+	n, err := internal.ParseNormalizer(expr)
+	if err != nil {
+		return err
+	}
+	nf.list = append(nf.list, n)
+	nf.expr = append(nf.expr, expr)
+	return nil
+}
+
+// sessionReplayer 按照原始的时间间隔回放，并读取响应和 dump 的响应进行比较
+type sessionReplayer struct {
+	results []*sessionResult
+	mux     sync.Mutex
+}
+
+// sessionResult 一个连接回放的结果
+type sessionResult struct {
+	Err error
+
+	// Diff 响应不一致的描述，为空表示一致
+	Diff string
+
+	ConnID   int64
+	Requests int
+	Sent     int
+	Want     int
+	Got      int
+}
+
+func (sr *sessionResult) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "ConnID:%d Requests:%d Sent:%d Want:%d Got:%d", sr.ConnID, sr.Requests, sr.Sent, sr.Want, sr.Got)
+	switch {
+	case sr.Err != nil:
+		b.WriteString(" Result:ERROR ")
+		b.WriteString(sr.Err.Error())
+	case !*verify:
+	case len(sr.Diff) == 0:
+		b.WriteString(" Result:OK")
+	default:
+		b.WriteString(" Result:DIFF ")
+		b.WriteString(sr.Diff)
+	}
+	return b.String()
+}
+
+// sessionClock 一个 dump 文件回放的时钟，文件中的所有连接共用
+type sessionClock struct {
+	// start 开始回放的时间
+	start time.Time
+
+	// base 第一条消息的时间
+	base time.Time
+}
+
+func newSessionClock(first *conndump.Message) *sessionClock {
+	return &sessionClock{
+		start: time.Now(),
+		base:  first.GetTime().AsTime(),
+	}
+}
+
+// wait 按照 -speed 等待，直到回放此消息的时间
+func (c *sessionClock) wait(msg *conndump.Message) {
+	if *speed <= 0 {
+		return
+	}
+	offset := time.Duration(float64(msg.GetTime().AsTime().Sub(c.base)) / *speed)
+	if d := time.Until(c.start.Add(offset)); d > 0 {
+		time.Sleep(d)
+	}
+}
+
+func (r *sessionReplayer) replay(msgs <-chan *conndump.Message, clock *sessionClock) {
+	result := &sessionResult{}
+	var want bytes.Buffer
+	var conn net.Conn
+	var got *respBuffer
+	for msg := range msgs {
+		result.ConnID = msg.GetConnID()
+		if result.Err != nil || msg.GetAction() == conndump.MessageAction_Close {
+			continue
+		}
+		clock.wait(msg)
+		if !msg.ClientToServer(*serverSide) {
+			want.Write(msg.GetPayload())
+			continue
+		}
+		if conn == nil {
+			conn, result.Err = net.DialTimeout("tcp", *to, 3*time.Second)
+			if result.Err != nil {
+				continue
+			}
+			got = newRespBuffer(conn)
+		}
+		n, err := conn.Write(msg.GetPayload())
+		result.Requests++
+		result.Sent += n
+		result.Err = err
+	}
+	if conn != nil {
+		got.waitLen(want.Len(), *waitResp)
+		_ = conn.Close()
+		result.Got = got.Len()
+		if *verify {
+			result.Diff = diff(normalizers.list.Normalize(want.Bytes()), normalizers.list.Normalize(got.Bytes()))
+		}
+	}
+	result.Want = want.Len()
+	fmt.Println(result.String())
+
+	r.mux.Lock()
+	r.results = append(r.results, result)
+	r.mux.Unlock()
+}
+
+// Summary 所有连接的汇总结果
+func (r *sessionReplayer) Summary() string {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	var ok, diffs, errs int
+	for _, rs := range r.results {
+		switch {
+		case rs.Err != nil:
+			errs++
+		case len(rs.Diff) > 0:
+			diffs++
+		default:
+			ok++
+		}
+	}
+	if !*verify {
+		return fmt.Sprintf("Total:%d Error:%d", len(r.results), errs)
+	}
+	return fmt.Sprintf("Total:%d OK:%d DIFF:%d Error:%d", len(r.results), ok, diffs, errs)
+}
+
+const diffContext = 32
+
+// diff 比较两个响应，返回第一个不同的位置及其附近的内容
+func diff(want []byte, got []byte) string {
+	if bytes.Equal(want, got) {
+		return ""
+	}
+	idx := 0
+	for idx < len(want) && idx < len(got) && want[idx] == got[idx] {
+		idx++
+	}
+	snippet := func(b []byte) string {
+		start := max(0, idx-diffContext/2)
+		end := min(len(b), idx+diffContext)
+		if start > end {
+			start = end
+		}
+		return strconv.Quote(string(b[start:end]))
+	}
+	return fmt.Sprintf("at offset %d, want %s, got %s", idx, snippet(want), snippet(got))
+}
+
+// respBuffer 读取并保存目标服务的响应
+type respBuffer struct {
+	buf  bytes.Buffer
+	done chan struct{}
+	mux  sync.Mutex
+}
+
+func newRespBuffer(conn net.Conn) *respBuffer {
+	rb := &respBuffer{
+		done: make(chan struct{}),
+	}
+	go func() {
+		defer close(rb.done)
+		bf := make([]byte, 32*1024)
+		for {
+			n, err := conn.Read(bf)
+			if n > 0 {
+				rb.mux.Lock()
+				rb.buf.Write(bf[:n])
+				rb.mux.Unlock()
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+	return rb
+}
+
+func (rb *respBuffer) Len() int {
+	rb.mux.Lock()
+	defer rb.mux.Unlock()
+	return rb.buf.Len()
+}
+
+func (rb *respBuffer) Bytes() []byte {
+	rb.mux.Lock()
+	defer rb.mux.Unlock()
+	return bytes.Clone(rb.buf.Bytes())
+}
+
+// waitLen 等待，直到读取到的数据不少于 size 、连接被关闭或者超时
+func (rb *respBuffer) waitLen(size int, timeout time.Duration) {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+	for rb.Len() < size {
+		select {
+		case <-rb.done:
+			return
+		case <-timer.C:
+			log.Println("wait response timeout")
+			return
+		case <-ticker.C:
+		}
+	}
+}
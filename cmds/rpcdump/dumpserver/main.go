@@ -10,15 +10,20 @@ import (
 	"io"
 	"log"
 	"net"
+	"sync"
 	"time"
 
 	"github.com/fsgo/fsgo/fsfs"
+	"github.com/fsgo/fsgo/fsnet/fsconn"
 	"github.com/fsgo/fsgo/fsnet/fsconn/conndump"
 )
 
 var addr = flag.String("l", ":8090", "server listen addr")
 var out = flag.String("o", "./dump_data/", "dump data dir")
 var maxFiles = flag.Int("m", 24, "max dump files total")
+var upstream = flag.String("upstream", "", `proxy every connection to the upstream addr, eg 127.0.0.1:8080.
+both the client and server side traffic are dumped`)
+var dialTimeout = flag.Duration("dt", 3*time.Second, "dial upstream timeout")
 
 func main() {
 	flag.Parse()
@@ -27,6 +32,9 @@ func main() {
 		log.Fatalln("listen failed:", err)
 	}
 	log.Println("dump server listen at:", l.Addr().String(), "dump data dir:", *out)
+	if len(*upstream) > 0 {
+		log.Println("proxy to upstream:", *upstream)
+	}
 
 	log.Fatalln("server exit:", startDumpServer(l))
 }
@@ -48,10 +56,18 @@ func startDumpServer(l net.Listener) error {
 		n, err := io.Copy(io.Discard, conn)
 		log.Println("disconnect:", conn.RemoteAddr(), "read=", n, "err=", err)
 	}
+	if len(*upstream) > 0 {
+		handler = func(conn net.Conn) {
+			proxy(dm, conn)
+		}
+	}
 
 	for {
 		conn, err := l.Accept()
 		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return err
+			}
 			var ne net.Error
 			if errors.As(err, &ne) {
 				time.Sleep(5 * time.Millisecond)
@@ -62,3 +78,53 @@ func startDumpServer(l net.Listener) error {
 		go handler(conn)
 	}
 }
+
+// proxy 将连接代理到 upstream，client 端（和 upstream 的连接）使用 ClientConnInterceptor dump
+func proxy(dm *conndump.Dumper, conn net.Conn) {
+	defer conn.Close()
+	log.Println("connect:", conn.RemoteAddr())
+	rc, err := net.DialTimeout("tcp", *upstream, *dialTimeout)
+	if err != nil {
+		log.Println("dial upstream failed:", err)
+		return
+	}
+	up := fsconn.Wrap(fsconn.WithService("upstream", rc), dm.ClientConnInterceptor())
+	defer up.Close()
+
+	var wg sync.WaitGroup
+	var sent, received int64
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		sent = pipe(up, conn)
+	}()
+	go func() {
+		defer wg.Done()
+		received = pipe(conn, up)
+	}()
+	wg.Wait()
+	log.Println("disconnect:", conn.RemoteAddr(), "sent=", sent, "received=", received)
+}
+
+// pipe 将 src 的数据复制到 dst
+//
+// src 正常读取完后，只关闭 dst 的写；若出错，则关闭两个连接，以让另一个方向的复制也退出
+func pipe(dst net.Conn, src net.Conn) int64 {
+	n, err := io.Copy(dst, src)
+	if err != nil {
+		_ = src.Close()
+		_ = dst.Close()
+		return n
+	}
+	closeWrite(dst)
+	return n
+}
+
+// closeWrite 关闭连接的写，以通知对端数据已发送完
+func closeWrite(conn net.Conn) {
+	if cw, ok := fsconn.OriginConn(conn).(interface{ CloseWrite() error }); ok {
+		_ = cw.CloseWrite()
+		return
+	}
+	_ = conn.Close()
+}
@@ -0,0 +1,93 @@
+// Copyright(C) 2026 github.com/fsgo  All Rights Reserved.
+// Author: hidu <duv123@gmail.com>
+// Date: 2026/10/19
+
+package main
+
+import (
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/fsgo/fst"
+
+	"github.com/fsgo/fsgo/fsnet/fsconn/conndump"
+)
+
+func TestStartDumpServerProxy(t *testing.T) {
+	echo, err := net.Listen("tcp", "127.0.0.1:0")
+	fst.NoError(t, err)
+	defer echo.Close()
+	go func() {
+		for {
+			conn, err := echo.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				_, _ = io.Copy(conn, conn)
+			}()
+		}
+	}()
+
+	*out = t.TempDir()
+	*upstream = echo.Addr().String()
+	defer func() {
+		*upstream = ""
+	}()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	fst.NoError(t, err)
+	done := make(chan error, 1)
+	go func() {
+		done <- startDumpServer(l)
+	}()
+
+	conn, err := net.Dial("tcp", l.Addr().String())
+	fst.NoError(t, err)
+	_, err = conn.Write([]byte("hello"))
+	fst.NoError(t, err)
+	fst.NoError(t, conn.(*net.TCPConn).CloseWrite())
+	got, err := io.ReadAll(conn)
+	fst.NoError(t, err)
+	fst.Equal(t, "hello", string(got))
+	_ = conn.Close()
+
+	// server 端和 client 端的 dump 文件中，都有发送和接收的数据
+	readDump := func(side string) (read string, write string) {
+		files, _ := filepath.Glob(filepath.Join(*out, side, "dump.pb*"))
+		for _, fp := range files {
+			f, err := os.Open(fp)
+			fst.NoError(t, err)
+			_ = conndump.Scan(f, func(msg *conndump.Message) bool {
+				switch msg.GetAction() {
+				case conndump.MessageAction_Read:
+					read += string(msg.GetPayload())
+				case conndump.MessageAction_Write:
+					write += string(msg.GetPayload())
+				}
+				return true
+			})
+			_ = f.Close()
+		}
+		return read, write
+	}
+	for _, side := range []string{"server", "client"} {
+		var read, write string
+		for i := 0; i < 100; i++ {
+			if read, write = readDump(side); len(read) > 0 && len(write) > 0 {
+				break
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+		fst.Equal(t, "hello", read)
+		fst.Equal(t, "hello", write)
+	}
+
+	_ = l.Close()
+	fst.Error(t, <-done)
+}
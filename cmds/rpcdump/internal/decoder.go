@@ -0,0 +1,522 @@
+// Copyright(C) 2026 github.com/fsgo  All Rights Reserved.
+// Author: hidu <duv123@gmail.com>
+// Date: 2026/10/19
+
+package internal
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/fsgo/fsgo/fsio"
+	"github.com/fsgo/fsgo/fsnet/fsconn/conndump"
+	"github.com/fsgo/fsgo/fsrpc"
+)
+
+// Decoder 从数据流中解码出消息
+type Decoder interface {
+	// Name 协议名称
+	Name() string
+
+	// Detect 根据数据流开头的内容判断是否此协议
+	Detect(b []byte) bool
+
+	// NewConn 创建一个连接的解码器
+	NewConn() ConnDecoder
+}
+
+// ConnDecoder 一个连接的解码器，按照顺序输入连接双向的数据
+type ConnDecoder interface {
+	// Decode 添加一段数据，返回解码出的完整的消息，tm 为数据所在消息的时间
+	// 返回的 Record 的 ConnID 和 Protocol 字段由调用方设置，Time 字段为空时也由调用方设置
+	Decode(b []byte, fromClient bool, tm time.Time) []*Record
+
+	// Close 连接已关闭，返回剩余的消息
+	Close() []*Record
+}
+
+// Decoders 所有的 Decoder，按照顺序检测协议
+var Decoders = []Decoder{
+	&fsrpcDecoder{},
+	&httpDecoder{},
+	&respDecoder{},
+}
+
+// FindDecoder 按照名称查找 Decoder
+func FindDecoder(name string) Decoder {
+	for _, d := range Decoders {
+		if d.Name() == name {
+			return d
+		}
+	}
+	return nil
+}
+
+// BodyMax 输出的消息体的最大长度
+var BodyMax = 1024
+
+func bodyString(b []byte) string {
+	if len(b) > BodyMax {
+		return string(b[:BodyMax]) + "...(truncated)"
+	}
+	return string(b)
+}
+
+// Record 解码后的一条消息
+type Record struct {
+	ConnID    int64
+	Direction string
+	Time      string
+	Protocol  string
+	Type      string
+	Error     string         `json:",omitempty"`
+	Data      map[string]any `json:",omitempty"`
+}
+
+// Get 读取 Data 中的字段，name 可以是以 . 分割的路径，如 "Request.Method"、"Command.0"
+func (r *Record) Get(name string) (any, bool) {
+	var cur any = r.Data
+	for _, key := range strings.Split(name, ".") {
+		switch v := cur.(type) {
+		case map[string]any:
+			var ok bool
+			if cur, ok = v[key]; !ok {
+				return nil, false
+			}
+		case []any:
+			idx, err := strconv.Atoi(key)
+			if err != nil || idx < 0 || idx >= len(v) {
+				return nil, false
+			}
+			cur = v[idx]
+		default:
+			return nil, false
+		}
+	}
+	return cur, true
+}
+
+// Match 判断字段是否匹配，expr 格式为 "name=pattern"，pattern 使用 path.Match 的语法
+func (r *Record) Match(expr string) bool {
+	name, pattern, _ := strings.Cut(expr, "=")
+	v, ok := r.Get(name)
+	if !ok {
+		return false
+	}
+	var s string
+	switch vv := v.(type) {
+	case string:
+		s = vv
+	default:
+		s = fmt.Sprint(vv)
+	}
+	ok, _ = path.Match(pattern, s)
+	return ok
+}
+
+// StreamDecoder 将 Message 按照 ConnID 重组为数据流，并解码
+type StreamDecoder struct {
+	// Decoder 指定协议，可选，为空时自动检测
+	Decoder Decoder
+
+	conns map[int64]*streamConn
+
+	// ServerSide 对于没有 Direction 的旧数据，数据是否是 server 端 dump 的
+	ServerSide bool
+}
+
+type streamConn struct {
+	decoder Decoder
+	conn    ConnDecoder
+	broken  bool
+}
+
+const timeLayout = "2006-01-02 15:04:05.000"
+
+func directionOf(fromClient bool) string {
+	if fromClient {
+		return conndump.Direction_ClientToServer.String()
+	}
+	return conndump.Direction_ServerToClient.String()
+}
+
+// Decode 添加一条消息，返回解码出的完整的消息
+//
+// 连接关闭时，会返回以连接关闭为结束的消息，如没有 Content-Length 的 HTTP 响应
+func (sd *StreamDecoder) Decode(msg *conndump.Message) []*Record {
+	if sd.conns == nil {
+		sd.conns = make(map[int64]*streamConn)
+	}
+	connID := msg.GetConnID()
+	tm := msg.GetTime().AsTime()
+	sc := sd.conns[connID]
+	if msg.GetAction() == conndump.MessageAction_Close {
+		if sc == nil {
+			return nil
+		}
+		delete(sd.conns, connID)
+		return sc.close(connID, tm)
+	}
+	if len(msg.GetPayload()) == 0 {
+		return nil
+	}
+	if sc == nil {
+		sc = &streamConn{decoder: sd.Decoder}
+		sd.conns[connID] = sc
+	}
+	if sc.broken {
+		return nil
+	}
+	fromClient := msg.ClientToServer(sd.ServerSide)
+	if sc.decoder == nil {
+		for _, d := range Decoders {
+			if d.Detect(msg.GetPayload()) {
+				sc.decoder = d
+				break
+			}
+		}
+		if sc.decoder == nil {
+			sc.broken = true
+			rd := &Record{
+				Direction: directionOf(fromClient),
+				Error:     "unknown protocol",
+			}
+			return sc.fill([]*Record{rd}, connID, "unknown", tm)
+		}
+	}
+	if sc.conn == nil {
+		sc.conn = sc.decoder.NewConn()
+	}
+	return sc.fill(sc.conn.Decode(msg.GetPayload(), fromClient, tm), connID, sc.decoder.Name(), tm)
+}
+
+// Flush 所有的数据已读取完，当作所有的连接都已关闭，返回剩余的消息
+func (sd *StreamDecoder) Flush() []*Record {
+	ids := make([]int64, 0, len(sd.conns))
+	for id := range sd.conns {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	var result []*Record
+	for _, id := range ids {
+		result = append(result, sd.conns[id].close(id, time.Time{})...)
+	}
+	sd.conns = nil
+	return result
+}
+
+func (sc *streamConn) close(connID int64, tm time.Time) []*Record {
+	if sc.conn == nil {
+		return nil
+	}
+	return sc.fill(sc.conn.Close(), connID, sc.decoder.Name(), tm)
+}
+
+func (sc *streamConn) fill(rds []*Record, connID int64, proto string, tm time.Time) []*Record {
+	for _, rd := range rds {
+		rd.ConnID = connID
+		rd.Protocol = proto
+		if len(rd.Time) == 0 && !tm.IsZero() {
+			rd.Time = tm.Local().Format(timeLayout)
+		}
+	}
+	return rds
+}
+
+// frameDecoder 从 b 的开头解码出一条完整的消息，返回消息的类型、内容和使用的字节数，
+// 数据不完整时返回的字节数为 0
+type frameDecoder func(b []byte, fromClient bool) (typ string, data map[string]any, n int, err error)
+
+// frameConn 每个方向的数据由一条条独立的消息组成的协议的 ConnDecoder
+type frameConn struct {
+	decode frameDecoder
+
+	// streams 两个方向的数据，0 为 server 到 client，1 为 client 到 server
+	streams [2]frameStream
+}
+
+type frameStream struct {
+	buf    []byte
+	broken bool
+}
+
+func (c *frameConn) Decode(b []byte, fromClient bool, _ time.Time) []*Record {
+	st := &c.streams[0]
+	if fromClient {
+		st = &c.streams[1]
+	}
+	if st.broken {
+		return nil
+	}
+	st.buf = append(st.buf, b...)
+	var result []*Record
+	for len(st.buf) > 0 {
+		typ, data, n, err := c.decode(st.buf, fromClient)
+		if err != nil {
+			st.broken = true
+			result = append(result, &Record{
+				Direction: directionOf(fromClient),
+				Type:      typ,
+				Error:     err.Error(),
+			})
+			break
+		}
+		if n == 0 {
+			break
+		}
+		st.buf = st.buf[n:]
+		result = append(result, &Record{
+			Direction: directionOf(fromClient),
+			Type:      typ,
+			Data:      data,
+		})
+	}
+	if len(st.buf) == 0 {
+		st.buf = nil
+	}
+	return result
+}
+
+func (c *frameConn) Close() []*Record {
+	return nil
+}
+
+type httpDecoder struct{}
+
+func (d *httpDecoder) Name() string {
+	return "http"
+}
+
+func (d *httpDecoder) Detect(b []byte) bool {
+	return fsio.IsHTTPStart(b)
+}
+
+func (d *httpDecoder) NewConn() ConnDecoder {
+	return &httpConn{}
+}
+
+// httpConn 响应和请求按照顺序配对后再解析，以正确的处理 HEAD 请求的响应，
+// 以连接关闭为结束的响应，在连接关闭时输出
+type httpConn struct {
+	stream conndump.HTTPStream
+}
+
+func (c *httpConn) Decode(b []byte, fromClient bool, tm time.Time) []*Record {
+	reqs, resps, err := c.stream.Write(b, fromClient, tm)
+	result := make([]*Record, 0, len(reqs)+len(resps))
+	for _, ex := range reqs {
+		result = append(result, httpRequestRecord(ex))
+	}
+	for _, ex := range resps {
+		result = append(result, httpResponseRecord(ex))
+	}
+	if err != nil {
+		result = append(result, &Record{
+			Direction: directionOf(fromClient),
+			Error:     err.Error(),
+		})
+	}
+	return result
+}
+
+func (c *httpConn) Close() []*Record {
+	resps, _ := c.stream.Close()
+	result := make([]*Record, 0, len(resps))
+	for _, ex := range resps {
+		result = append(result, httpResponseRecord(ex))
+	}
+	return result
+}
+
+func headerMap(h http.Header) map[string]any {
+	m := make(map[string]any, len(h))
+	for k, v := range h {
+		m[k] = strings.Join(v, ", ")
+	}
+	return m
+}
+
+func httpRequestRecord(ex *conndump.HTTPExchange) *Record {
+	req := ex.Request
+	return &Record{
+		Direction: directionOf(true),
+		Time:      ex.RequestEnd.Local().Format(timeLayout),
+		Type:      "Request",
+		Data: map[string]any{
+			"Method":  req.Method,
+			"URL":     req.RequestURI,
+			"Path":    req.URL.Path,
+			"Host":    req.Host,
+			"Proto":   req.Proto,
+			"Header":  headerMap(req.Header),
+			"BodyLen": len(ex.RequestBody),
+			"Body":    bodyString(ex.RequestBody),
+		},
+	}
+}
+
+func httpResponseRecord(ex *conndump.HTTPExchange) *Record {
+	resp := ex.Response
+	return &Record{
+		Direction: directionOf(false),
+		Time:      ex.ResponseEnd.Local().Format(timeLayout),
+		Type:      "Response",
+		Data: map[string]any{
+			"Status":     resp.Status,
+			"StatusCode": resp.StatusCode,
+			"Proto":      resp.Proto,
+			"Header":     headerMap(resp.Header),
+			"BodyLen":    len(ex.ResponseBody),
+			"Body":       bodyString(ex.ResponseBody),
+		},
+	}
+}
+
+type respDecoder struct{}
+
+func (d *respDecoder) Name() string {
+	return "resp"
+}
+
+func (d *respDecoder) Detect(b []byte) bool {
+	return fsio.IsRESPStart(b)
+}
+
+func (d *respDecoder) NewConn() ConnDecoder {
+	return &frameConn{decode: decodeRESP}
+}
+
+func decodeRESP(b []byte, fromClient bool) (string, map[string]any, int, error) {
+	v, n, err := fsio.ParseRESP(b)
+	if err != nil || n == 0 {
+		return "", nil, 0, err
+	}
+	if fromClient {
+		return "Command", map[string]any{"Command": respValue(v)}, n, nil
+	}
+	return "Reply", map[string]any{"Reply": respValue(v)}, n, nil
+}
+
+// respValue 将 RESP 的值转换为便于输出为 JSON 和过滤的值
+func respValue(v *fsio.RESPValue) any {
+	if v.Null {
+		return nil
+	}
+	switch v.Type {
+	case '-':
+		return map[string]any{"Error": v.Str}
+	case ':':
+		num, _ := strconv.ParseInt(v.Str, 10, 64)
+		return num
+	case '$':
+		return bodyString([]byte(v.Str))
+	case '*':
+		list := make([]any, 0, len(v.Array))
+		for _, item := range v.Array {
+			list = append(list, respValue(item))
+		}
+		return list
+	case '#':
+		return v.Str == "t"
+	default:
+		return v.Str
+	}
+}
+
+type fsrpcDecoder struct{}
+
+func (d *fsrpcDecoder) Name() string {
+	return "fsrpc"
+}
+
+func (d *fsrpcDecoder) Detect(b []byte) bool {
+	if bytes.HasPrefix(b, fsrpc.Protocol) {
+		return true
+	}
+	if len(b) < fsrpc.HeaderLen {
+		return false
+	}
+	_, err := fsrpc.ReadHeader(bytes.NewReader(b))
+	return err == nil
+}
+
+// protoMap 将 proto 消息转换为 map，以便输出和过滤
+func protoMap(m proto.Message) (map[string]any, error) {
+	bf, err := protojson.MarshalOptions{UseProtoNames: true}.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	var data map[string]any
+	err = json.Unmarshal(bf, &data)
+	return data, err
+}
+
+func (d *fsrpcDecoder) NewConn() ConnDecoder {
+	return &frameConn{decode: decodeFSRPC}
+}
+
+func decodeFSRPC(b []byte, _ bool) (string, map[string]any, int, error) {
+	if bytes.HasPrefix(b, fsrpc.Protocol) {
+		return "Protocol", nil, len(fsrpc.Protocol), nil
+	}
+	if len(b) < fsrpc.HeaderLen {
+		return "", nil, 0, nil
+	}
+	header, err := fsrpc.ReadHeader(bytes.NewReader(b))
+	if err != nil {
+		return "Header", nil, 0, err
+	}
+	end := fsrpc.HeaderLen + int(header.Length)
+	if len(b) < end {
+		return "", nil, 0, nil
+	}
+	body := b[fsrpc.HeaderLen:end]
+	data := map[string]any{
+		"Header": map[string]any{
+			"Type":   header.Type.String(),
+			"Length": header.Length,
+		},
+	}
+	var typ string
+	var msg proto.Message
+	switch header.Type {
+	case fsrpc.HeaderTypeRequest:
+		typ, msg = "Request", &fsrpc.Request{}
+	case fsrpc.HeaderTypeResponse:
+		typ, msg = "Response", &fsrpc.Response{}
+	case fsrpc.HeaderTypePayload:
+		typ, msg = "PayloadMeta", &fsrpc.PayloadMeta{}
+	default:
+		return "Header", nil, 0, fmt.Errorf("invalid header type %s", header.Type)
+	}
+	if err = proto.Unmarshal(body, msg); err != nil {
+		return typ, nil, 0, err
+	}
+	if data[typ], err = protoMap(msg); err != nil {
+		return typ, nil, 0, err
+	}
+	if meta, ok := msg.(*fsrpc.PayloadMeta); ok {
+		size := int(meta.GetLength())
+		if len(b) < end+size {
+			return "", nil, 0, nil
+		}
+		payload := b[end : end+size]
+		data["PayloadLen"] = size
+		switch meta.GetEncodingType() {
+		case fsrpc.EncodingType_Bytes, fsrpc.EncodingType_JSON:
+			data["Payload"] = bodyString(payload)
+		}
+		end += size
+	}
+	return typ, data, end, nil
+}
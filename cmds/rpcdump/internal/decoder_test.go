@@ -0,0 +1,133 @@
+// Copyright(C) 2026 github.com/fsgo  All Rights Reserved.
+// Author: hidu <duv123@gmail.com>
+// Date: 2026/10/19
+
+package internal
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/fsgo/fst"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/fsgo/fsgo/fsnet/fsconn/conndump"
+	"github.com/fsgo/fsgo/fsrpc"
+)
+
+func newTestMessage(connID int64, dir conndump.Direction, payload string) *conndump.Message {
+	return &conndump.Message{
+		ConnID:    connID,
+		Action:    conndump.MessageAction_Read,
+		Direction: dir,
+		Payload:   []byte(payload),
+	}
+}
+
+func TestStreamDecoder(t *testing.T) {
+	t.Run("http", func(t *testing.T) {
+		sd := &StreamDecoder{}
+		rs := sd.Decode(newTestMessage(1, conndump.Direction_ClientToServer, "GET /a/b?c=1 HTTP/1.1\r\nHost: a.com\r\n"))
+		fst.Empty(t, rs)
+		rs = sd.Decode(newTestMessage(1, conndump.Direction_ClientToServer, "\r\nPOST /x HTTP/1.1\r\nHost: a.com\r\nContent-Length: 2\r\n\r\nok"))
+		fst.Len(t, rs, 2)
+		fst.Equal(t, "http", rs[0].Protocol)
+		fst.Equal(t, "Request", rs[0].Type)
+		fst.True(t, rs[0].Match("Path=/a/*"))
+		fst.False(t, rs[1].Match("Path=/a/*"))
+		fst.True(t, rs[1].Match("Header.Content-Length=2"))
+		fst.Equal(t, "ok", rs[1].Data["Body"])
+
+		rs = sd.Decode(newTestMessage(1, conndump.Direction_ServerToClient, "HTTP/1.1 200 OK\r\nContent-Length: 5\r\n\r\nhel"))
+		fst.Empty(t, rs)
+		rs = sd.Decode(newTestMessage(1, conndump.Direction_ServerToClient, "lo"))
+		fst.Len(t, rs, 1)
+		fst.Equal(t, "Response", rs[0].Type)
+		fst.Equal(t, 200, rs[0].Data["StatusCode"])
+	})
+
+	t.Run("http head", func(t *testing.T) {
+		sd := &StreamDecoder{}
+		rs := sd.Decode(newTestMessage(1, conndump.Direction_ClientToServer, "HEAD /a HTTP/1.1\r\nHost: a.com\r\n\r\n"))
+		fst.Len(t, rs, 1)
+		// HEAD 请求的响应没有 body
+		rs = sd.Decode(newTestMessage(1, conndump.Direction_ServerToClient, "HTTP/1.1 200 OK\r\nContent-Length: 5\r\n\r\n"))
+		fst.Len(t, rs, 1)
+		fst.Equal(t, "Response", rs[0].Type)
+		fst.Equal[any](t, 0, rs[0].Data["BodyLen"])
+	})
+
+	t.Run("http close", func(t *testing.T) {
+		sd := &StreamDecoder{}
+		fst.Len(t, sd.Decode(newTestMessage(1, conndump.Direction_ClientToServer, "GET /a HTTP/1.0\r\n\r\n")), 1)
+		// 没有 Content-Length 的响应，以连接关闭为结束
+		fst.Empty(t, sd.Decode(newTestMessage(1, conndump.Direction_ServerToClient, "HTTP/1.0 200 OK\r\n\r\nhello ")))
+		fst.Empty(t, sd.Decode(newTestMessage(1, conndump.Direction_ServerToClient, "world")))
+		rs := sd.Decode(&conndump.Message{ConnID: 1, Action: conndump.MessageAction_Close})
+		fst.Len(t, rs, 1)
+		fst.Equal(t, "Response", rs[0].Type)
+		fst.Equal(t, int64(1), rs[0].ConnID)
+		fst.Equal(t, "http", rs[0].Protocol)
+		fst.Equal[any](t, "hello world", rs[0].Data["Body"])
+
+		// 没有 Close 消息的连接，在 Flush 时输出
+		fst.Len(t, sd.Decode(newTestMessage(2, conndump.Direction_ClientToServer, "GET /b HTTP/1.0\r\n\r\n")), 1)
+		fst.Empty(t, sd.Decode(newTestMessage(2, conndump.Direction_ServerToClient, "HTTP/1.0 200 OK\r\n\r\nhi")))
+		rs = sd.Flush()
+		fst.Len(t, rs, 1)
+		fst.Equal(t, int64(2), rs[0].ConnID)
+		fst.Equal[any](t, "hi", rs[0].Data["Body"])
+		fst.Empty(t, sd.Flush())
+	})
+
+	t.Run("resp", func(t *testing.T) {
+		sd := &StreamDecoder{}
+		rs := sd.Decode(newTestMessage(1, conndump.Direction_ClientToServer, "*2\r\n$3\r\nGET\r\n$1\r"))
+		fst.Empty(t, rs)
+		rs = sd.Decode(newTestMessage(1, conndump.Direction_ClientToServer, "\nk\r\n"))
+		fst.Len(t, rs, 1)
+		fst.Equal[any](t, []any{"GET", "k"}, rs[0].Data["Command"])
+		fst.True(t, rs[0].Match("Command.0=GET"))
+		fst.False(t, rs[0].Match("Command.2=GET"))
+
+		rs = sd.Decode(newTestMessage(1, conndump.Direction_ServerToClient, "$-1\r\n:12\r\n"))
+		fst.Len(t, rs, 2)
+		fst.Nil(t, rs[0].Data["Reply"])
+		fst.Equal[any](t, int64(12), rs[1].Data["Reply"])
+	})
+
+	t.Run("fsrpc", func(t *testing.T) {
+		bf := &bytes.Buffer{}
+		bf.Write(fsrpc.Protocol)
+		req, err := proto.Marshal(&fsrpc.Request{Method: "user.Get", ID: 1, HasPayload: true})
+		fst.NoError(t, err)
+		fst.NoError(t, fsrpc.Header{Type: fsrpc.HeaderTypeRequest, Length: uint32(len(req))}.Write(bf))
+		bf.Write(req)
+		meta, err := proto.Marshal(&fsrpc.PayloadMeta{RID: 1, Length: 5, EncodingType: fsrpc.EncodingType_Bytes})
+		fst.NoError(t, err)
+		fst.NoError(t, fsrpc.Header{Type: fsrpc.HeaderTypePayload, Length: uint32(len(meta))}.Write(bf))
+		bf.Write(meta)
+		bf.WriteString("hello")
+
+		sd := &StreamDecoder{}
+		data := bf.String()
+		rs := sd.Decode(newTestMessage(1, conndump.Direction_ClientToServer, data[:len(data)-2]))
+		fst.Len(t, rs, 2)
+		fst.Equal(t, "Protocol", rs[0].Type)
+		fst.Equal(t, "Request", rs[1].Type)
+		fst.True(t, rs[1].Match("Request.Method=user.*"))
+
+		rs = sd.Decode(newTestMessage(1, conndump.Direction_ClientToServer, data[len(data)-2:]))
+		fst.Len(t, rs, 1)
+		fst.Equal(t, "PayloadMeta", rs[0].Type)
+		fst.Equal(t, "hello", rs[0].Data["Payload"])
+	})
+
+	t.Run("unknown", func(t *testing.T) {
+		sd := &StreamDecoder{}
+		rs := sd.Decode(newTestMessage(1, conndump.Direction_ClientToServer, "hello"))
+		fst.Len(t, rs, 1)
+		fst.NotEmpty(t, rs[0].Error)
+		fst.Empty(t, sd.Decode(newTestMessage(1, conndump.Direction_ClientToServer, "hello")))
+	})
+}
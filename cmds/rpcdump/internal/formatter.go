@@ -24,7 +24,21 @@ func FormatMessage(msg *conndump.Message, long bool) string {
 	b.WriteString("_")
 	b.WriteString(strconv.FormatInt(msg.GetSubID(), 10))
 	b.WriteString(" Addr:")
-	b.WriteString(msg.GetAddr())
+	b.WriteString(msg.Remote())
+	if local := msg.GetLocalAddr(); len(local) > 0 {
+		b.WriteString(" Local:")
+		b.WriteString(local)
+	}
+	if dir := msg.GetDirection(); dir != conndump.Direction_DirectionUnknown {
+		b.WriteString(" Direction:")
+		b.WriteString(dir.String())
+		b.WriteString(" Offset:")
+		b.WriteString(strconv.FormatInt(msg.GetOffset(), 10))
+	}
+	if e := msg.GetError(); len(e) > 0 {
+		b.WriteString(" Error:")
+		b.WriteString(strconv.Quote(e))
+	}
 
 	b.WriteString(" Time:")
 	b.WriteString(msg.GetTime().AsTime().Local().Format("20060102 15:04:05.000"))
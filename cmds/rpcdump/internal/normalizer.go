@@ -0,0 +1,82 @@
+// Copyright(C) 2026 github.com/fsgo  All Rights Reserved.
+// Author: hidu <duv123@gmail.com>
+// Date: 2026/10/19
+
+package internal
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Normalizer 在比较数据前对数据进行处理，如去掉每次都会变化的时间、请求 ID 等
+type Normalizer func(b []byte) []byte
+
+// NormalizerFactory 使用参数创建 Normalizer
+type NormalizerFactory func(arg string) (Normalizer, error)
+
+var normalizers = map[string]NormalizerFactory{
+	"header":     newHeaderNormalizer,
+	"http-date":  func(_ string) (Normalizer, error) { return newHeaderNormalizer("Date") },
+	"regexp":     newRegexpNormalizer,
+	"trim-space": func(_ string) (Normalizer, error) { return bytes.TrimSpace, nil },
+}
+
+var normalizersMux sync.RWMutex
+
+// RegisterNormalizer 注册 Normalizer，已存在的会被替换
+func RegisterNormalizer(name string, fn NormalizerFactory) {
+	normalizersMux.Lock()
+	defer normalizersMux.Unlock()
+	normalizers[name] = fn
+}
+
+// ParseNormalizer 解析 Normalizer 表达式，格式为 "name" 或者 "name:arg"
+//
+// 内置的有：
+//
+//	header:Name  去掉名称为 Name 的 HTTP 头（不区分大小写）
+//	http-date    去掉 HTTP 的 Date 头
+//	regexp:expr  将匹配正则 expr 的内容替换为空
+//	trim-space   去掉首尾的空白字符
+func ParseNormalizer(expr string) (Normalizer, error) {
+	name, arg, _ := strings.Cut(expr, ":")
+	normalizersMux.RLock()
+	fn := normalizers[name]
+	normalizersMux.RUnlock()
+	if fn == nil {
+		return nil, fmt.Errorf("normalizer %q not found", name)
+	}
+	return fn(arg)
+}
+
+// Normalizers 一组 Normalizer
+type Normalizers []Normalizer
+
+// Normalize 依次调用所有的 Normalizer
+func (ns Normalizers) Normalize(b []byte) []byte {
+	for _, n := range ns {
+		b = n(b)
+	}
+	return b
+}
+
+func newHeaderNormalizer(name string) (Normalizer, error) {
+	if len(name) == 0 {
+		return nil, fmt.Errorf("empty header name")
+	}
+	return newRegexpNormalizer(`(?im)^` + regexp.QuoteMeta(name) + `:[^\n]*\n`)
+}
+
+func newRegexpNormalizer(expr string) (Normalizer, error) {
+	reg, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+	return func(b []byte) []byte {
+		return reg.ReplaceAll(b, nil)
+	}, nil
+}
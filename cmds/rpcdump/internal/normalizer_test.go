@@ -0,0 +1,30 @@
+// Copyright(C) 2026 github.com/fsgo  All Rights Reserved.
+// Author: hidu <duv123@gmail.com>
+// Date: 2026/10/19
+
+package internal
+
+import (
+	"testing"
+
+	"github.com/fsgo/fst"
+)
+
+func TestParseNormalizer(t *testing.T) {
+	resp := "HTTP/1.1 200 OK\r\ndate: Mon, 19 Oct 2026 10:00:00 GMT\r\nX-Id: 123\r\n\r\nok "
+
+	var ns Normalizers
+	for _, expr := range []string{"http-date", "regexp:X-Id: \\d+", "trim-space"} {
+		n, err := ParseNormalizer(expr)
+		fst.NoError(t, err)
+		ns = append(ns, n)
+	}
+	fst.Equal(t, "HTTP/1.1 200 OK\r\n\r\n\r\nok", string(ns.Normalize([]byte(resp))))
+
+	_, err := ParseNormalizer("not-found")
+	fst.Error(t, err)
+	_, err = ParseNormalizer("regexp:(")
+	fst.Error(t, err)
+	_, err = ParseNormalizer("header")
+	fst.Error(t, err)
+}
@@ -0,0 +1,79 @@
+// Copyright(C) 2026 github.com/fsgo  All Rights Reserved.
+// Author: hidu <duv123@gmail.com>
+// Date: 2026/10/19
+
+package internal
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"io"
+
+	"github.com/fsgo/fsgo/fsio"
+	"github.com/fsgo/fsgo/fsrpc"
+)
+
+func init() {
+	// fsio 不依赖 fsrpc，由此注册 fsio.PrintFormatProtocol 格式下对 fsrpc 协议的识别
+	_ = fsio.RegisterProtocolPrinter(&fsio.ProtocolPrinter{
+		Name:  "fsrpc",
+		Print: printFSRPC,
+	})
+}
+
+// parseFSRPCHeader 解析 fsrpc 的消息头，校验不通过时返回 false
+func parseFSRPCHeader(p []byte) (fsrpc.Header, bool) {
+	if len(p) < fsrpc.HeaderLen {
+		return fsrpc.Header{}, false
+	}
+	h, err := fsrpc.ReadHeader(bytes.NewReader(p))
+	if err != nil {
+		return h, false
+	}
+	switch h.Type {
+	case fsrpc.HeaderTypeRequest, fsrpc.HeaderTypeResponse, fsrpc.HeaderTypePayload:
+		return h, true
+	default:
+		return h, false
+	}
+}
+
+func writeHexdump(w io.Writer, p []byte) {
+	if len(p) == 0 {
+		return
+	}
+	_, _ = fmt.Fprintf(w, "%s%08x\n", hex.Dump(p), len(p))
+}
+
+// printFSRPC 输出 fsrpc 协议的内容
+func printFSRPC(w io.Writer, p []byte) bool {
+	rest := p
+	out := &bytes.Buffer{}
+	if bytes.HasPrefix(rest, fsrpc.Protocol) {
+		fmt.Fprintf(out, "  Protocol %s\n", fsrpc.Protocol)
+		rest = rest[len(fsrpc.Protocol):]
+	} else if _, ok := parseFSRPCHeader(rest); !ok {
+		return false
+	}
+	for len(rest) > 0 {
+		h, ok := parseFSRPCHeader(rest)
+		if !ok {
+			fmt.Fprintf(out, "  Unknown (%d)\n", len(rest))
+			writeHexdump(out, rest)
+			break
+		}
+		rest = rest[fsrpc.HeaderLen:]
+		body := rest[:min(int(h.Length), len(rest))]
+		fmt.Fprintf(out, "  Frame Type=%s Length=%d", h.Type, h.Length)
+		if len(body) < int(h.Length) {
+			fmt.Fprintf(out, " (incomplete, got %d)", len(body))
+		}
+		out.WriteByte('\n')
+		writeHexdump(out, body)
+		rest = rest[len(body):]
+	}
+	_, _ = io.WriteString(w, "FSRPC:\n")
+	_, _ = w.Write(out.Bytes())
+	return true
+}
@@ -0,0 +1,45 @@
+// Copyright(C) 2026 github.com/fsgo  All Rights Reserved.
+// Author: hidu <duv123@gmail.com>
+// Date: 2026/10/19
+
+package internal
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"testing"
+
+	"github.com/fsgo/fst"
+
+	"github.com/fsgo/fsgo/fsio"
+)
+
+func TestPrintFSRPC(t *testing.T) {
+	out := &bytes.Buffer{}
+	pb := &fsio.PrintByteWriter{
+		Name:   "Read",
+		Out:    out,
+		Format: fsio.PrintFormatProtocol,
+	}
+	check := func(t *testing.T, data string, want string) {
+		t.Helper()
+		out.Reset()
+		_, err := pb.Write([]byte(data))
+		fst.NoError(t, err)
+		fst.Contains(t, out.String(), want)
+	}
+
+	t.Run("fsrpc", func(t *testing.T) {
+		h := []byte{1, 0, 0, 0, 0, 0, 0, 0, 0}
+		binary.LittleEndian.PutUint32(h[1:], 3)
+		binary.LittleEndian.PutUint32(h[5:], crc32.ChecksumIEEE(h[:5]))
+		check(t, "FSRPC"+string(h)+"abc", "FSRPC:\n  Protocol FSRPC\n  Frame Type=1-request Length=3\n")
+	})
+
+	t.Run("invalid type", func(t *testing.T) {
+		h := []byte{4, 0, 0, 0, 0, 0, 0, 0, 0}
+		binary.LittleEndian.PutUint32(h[5:], crc32.ChecksumIEEE(h[:5]))
+		check(t, string(h), "00000000  04 00")
+	})
+}
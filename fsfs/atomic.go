@@ -0,0 +1,198 @@
+// Copyright(C) 2026 github.com/fsgo  All Rights Reserved.
+// Author: hidu <duv123@gmail.com>
+// Date: 2026/10/19
+
+package fsfs
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+var _ io.WriteCloser = (*AtomicWriter)(nil)
+
+// AtomicWriter 原子的写文件，内容先写入同目录下的临时文件，Close 时再 rename 为目标文件
+//
+// 在 Close 之前，目标文件保持不变；若中途放弃，调用 Abort 即可删除临时文件。
+// 若目标文件已存在，新文件会沿用其权限和属主
+type AtomicWriter struct {
+	file *os.File
+	err  error
+
+	// Path 目标文件路径，必填
+	Path string
+
+	// Perm 目标文件不存在时，新文件的权限，可选，默认为 0644
+	Perm os.FileMode
+
+	// Backup 是否将之前的文件保留为 Path + ".bak"，可选
+	Backup bool
+
+	mux  sync.Mutex
+	done bool
+}
+
+func (aw *AtomicWriter) getPerm() os.FileMode {
+	if aw.Perm == 0 {
+		return 0644
+	}
+	return aw.Perm
+}
+
+func (aw *AtomicWriter) init() error {
+	if aw.file != nil || aw.err != nil {
+		return aw.err
+	}
+	if aw.done {
+		return os.ErrClosed
+	}
+	if len(aw.Path) == 0 {
+		aw.err = errors.New("empty Path")
+		return aw.err
+	}
+	dir, name := filepath.Split(aw.Path)
+	if len(dir) == 0 {
+		dir = "."
+	}
+	f, err := os.CreateTemp(dir, "."+name+".tmp*")
+	if err != nil {
+		aw.err = err
+		return err
+	}
+	aw.file = f
+
+	perm := aw.getPerm()
+	info, err := os.Stat(aw.Path)
+	if err == nil {
+		perm = info.Mode().Perm()
+		err = chownAs(f, info)
+	} else if os.IsNotExist(err) {
+		err = nil
+	}
+	if err == nil {
+		err = f.Chmod(perm)
+	}
+	if err != nil {
+		aw.abort()
+		aw.err = err
+	}
+	return err
+}
+
+// Write 写入临时文件
+func (aw *AtomicWriter) Write(p []byte) (int, error) {
+	aw.mux.Lock()
+	defer aw.mux.Unlock()
+	if err := aw.init(); err != nil {
+		return 0, err
+	}
+	n, err := aw.file.Write(p)
+	if err != nil {
+		aw.err = err
+	}
+	return n, err
+}
+
+// Close 提交：将临时文件 fsync 后 rename 为目标文件，并 fsync 目标文件所在的目录
+//
+// 若之前的 Write 失败了，会放弃写入并返回该错误
+func (aw *AtomicWriter) Close() error {
+	aw.mux.Lock()
+	defer aw.mux.Unlock()
+	if aw.done {
+		return nil
+	}
+	if err := aw.init(); err != nil {
+		aw.abort()
+		return err
+	}
+	defer aw.abort()
+
+	if err := aw.file.Sync(); err != nil {
+		return err
+	}
+	if err := aw.file.Close(); err != nil {
+		return err
+	}
+	if aw.Backup {
+		if err := backupFile(aw.Path); err != nil {
+			return err
+		}
+	}
+	if err := os.Rename(aw.file.Name(), aw.Path); err != nil {
+		return err
+	}
+	aw.file = nil
+	return syncDir(filepath.Dir(aw.Path))
+}
+
+// Abort 放弃写入，删除临时文件，目标文件保持不变
+func (aw *AtomicWriter) Abort() error {
+	aw.mux.Lock()
+	defer aw.mux.Unlock()
+	aw.abort()
+	return nil
+}
+
+func (aw *AtomicWriter) abort() {
+	aw.done = true
+	if aw.file == nil {
+		return
+	}
+	_ = aw.file.Close()
+	_ = os.Remove(aw.file.Name())
+	aw.file = nil
+}
+
+// backupFile 将文件保留为 name + ".bak"，若文件不存在，则忽略
+func backupFile(name string) error {
+	bak := name + ".bak"
+	if err := os.Remove(bak); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	err := os.Link(name, bak)
+	if err == nil || os.IsNotExist(err) {
+		return nil
+	}
+	// 不支持硬链接时，复制文件
+	return copyFile(name, bak)
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(out, in); err != nil {
+		_ = out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// AtomicWriteFile 原子的写文件，用法同 os.WriteFile
+//
+// 若文件已存在，将沿用其权限和属主，否则使用 perm
+func AtomicWriteFile(name string, data []byte, perm os.FileMode) error {
+	aw := &AtomicWriter{
+		Path: name,
+		Perm: perm,
+	}
+	if _, err := aw.Write(data); err != nil {
+		_ = aw.Abort()
+		return err
+	}
+	return aw.Close()
+}
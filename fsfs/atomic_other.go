@@ -0,0 +1,19 @@
+// Copyright(C) 2026 github.com/fsgo  All Rights Reserved.
+// Author: hidu <duv123@gmail.com>
+// Date: 2026/10/19
+
+//go:build windows || wasm
+
+package fsfs
+
+import (
+	"os"
+)
+
+func chownAs(_ *os.File, _ os.FileInfo) error {
+	return nil
+}
+
+func syncDir(_ string) error {
+	return nil
+}
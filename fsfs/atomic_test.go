@@ -0,0 +1,59 @@
+// Copyright(C) 2026 github.com/fsgo  All Rights Reserved.
+// Author: hidu <duv123@gmail.com>
+// Date: 2026/10/19
+
+package fsfs
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/fsgo/fst"
+)
+
+func TestAtomicWriter(t *testing.T) {
+	dir := t.TempDir()
+	fp := filepath.Join(dir, "app.conf")
+
+	readFile := func(name string) string {
+		bf, err := os.ReadFile(name)
+		fst.NoError(t, err)
+		return string(bf)
+	}
+
+	fst.NoError(t, AtomicWriteFile(fp, []byte("v1"), 0600))
+	fst.Equal(t, "v1", readFile(fp))
+	info, err := os.Stat(fp)
+	fst.NoError(t, err)
+	fst.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	t.Run("abort", func(t *testing.T) {
+		aw := &AtomicWriter{Path: fp}
+		_, err := aw.Write([]byte("v2"))
+		fst.NoError(t, err)
+		fst.NoError(t, aw.Abort())
+		fst.Equal(t, "v1", readFile(fp))
+		fst.NoError(t, aw.Close())
+		fst.Equal(t, "v1", readFile(fp))
+	})
+
+	t.Run("backup", func(t *testing.T) {
+		aw := &AtomicWriter{Path: fp, Backup: true}
+		_, err := aw.Write([]byte("v3"))
+		fst.NoError(t, err)
+		fst.NoError(t, aw.Close())
+		fst.Equal(t, "v3", readFile(fp))
+		fst.Equal(t, "v1", readFile(fp+".bak"))
+
+		// 沿用已有文件的权限
+		info, err := os.Stat(fp)
+		fst.NoError(t, err)
+		fst.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	})
+
+	// 没有遗留的临时文件
+	files, err := filepath.Glob(filepath.Join(dir, ".*"))
+	fst.NoError(t, err)
+	fst.Empty(t, files)
+}
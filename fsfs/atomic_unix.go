@@ -0,0 +1,35 @@
+// Copyright(C) 2026 github.com/fsgo  All Rights Reserved.
+// Author: hidu <duv123@gmail.com>
+// Date: 2026/10/19
+
+//go:build !windows && !wasm
+
+package fsfs
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// chownAs 将文件的属主设置为和 info 的一致，若没有权限，则忽略
+func chownAs(f *os.File, info os.FileInfo) error {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return nil
+	}
+	err := f.Chown(int(st.Uid), int(st.Gid))
+	if errors.Is(err, os.ErrPermission) {
+		return nil
+	}
+	return err
+}
+
+func syncDir(dir string) error {
+	f, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return f.Sync()
+}
@@ -0,0 +1,194 @@
+// Copyright(C) 2026 github.com/fsgo  All Rights Reserved.
+// Author: hidu <duv123@gmail.com>
+// Date: 2026/10/19
+
+package fsfs
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Compressor 文件压缩方式
+type Compressor struct {
+	// NewWriter 创建压缩的 writer，必填
+	NewWriter func(w io.Writer) (io.WriteCloser, error)
+
+	// Name 名称，必填，如 gzip
+	Name string
+
+	// Ext 压缩后文件名的后缀，必填，如 .gz
+	Ext string
+}
+
+var compressors = map[string]*Compressor{
+	"gzip": {
+		Name: "gzip",
+		Ext:  ".gz",
+		NewWriter: func(w io.Writer) (io.WriteCloser, error) {
+			return gzip.NewWriter(w), nil
+		},
+	},
+}
+
+var compressorsMux sync.RWMutex
+
+// RegisterCompressor 注册新的压缩方式（如 zstd），若和已有的重名，会覆盖掉
+func RegisterCompressor(c *Compressor) error {
+	if len(c.Name) == 0 || len(c.Ext) == 0 || c.NewWriter == nil {
+		return fmt.Errorf("invalid Compressor: %v", c)
+	}
+	compressorsMux.Lock()
+	defer compressorsMux.Unlock()
+	compressors[c.Name] = c
+	return nil
+}
+
+// 压缩过程中的临时文件后缀，压缩完成后才会 rename 为最终的文件名
+const compressTmpExt = ".tmp"
+
+// trimCompressExt 去除文件名中压缩方式的后缀
+func trimCompressExt(name string) (string, bool) {
+	compressorsMux.RLock()
+	defer compressorsMux.RUnlock()
+	for _, c := range compressors {
+		if strings.HasSuffix(name, c.Ext) {
+			return strings.TrimSuffix(name, c.Ext), true
+		}
+	}
+	return name, false
+}
+
+// CompressFile 将文件 src 压缩为 src + Compressor.Ext，完成后删除 src
+//
+// 压缩时先写入临时文件，完成后再 rename，所以不会出现不完整的压缩文件。
+// 若压缩文件已存在，认为是之前已完成压缩、但未删除 src，会直接删除 src
+func CompressFile(src string, c *Compressor) error {
+	dst := src + c.Ext
+	if has, _ := Exists(dst); has {
+		return os.Remove(src)
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+
+	tmp := dst + compressTmpExt
+	out, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	err = writeCompressed(out, in, c)
+	if errClose := out.Close(); err == nil {
+		err = errClose
+	}
+	if err == nil {
+		// 保持修改时间不变，以便按照修改时间清理文件时，顺序依然正确
+		err = os.Chtimes(tmp, info.ModTime(), info.ModTime())
+	}
+	if err == nil {
+		err = os.Rename(tmp, dst)
+	}
+	if err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return os.Remove(src)
+}
+
+func writeCompressed(out *os.File, in io.Reader, c *Compressor) error {
+	zw, err := c.NewWriter(out)
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(zw, in); err != nil {
+		_ = zw.Close()
+		return err
+	}
+	if err = zw.Close(); err != nil {
+		return err
+	}
+	return out.Sync()
+}
+
+// rotateCompressor 在后台压缩 Rotator 已切割的文件
+type rotateCompressor struct {
+	c       *Compressor
+	sem     chan struct{}
+	running map[string]bool
+	wg      sync.WaitGroup
+	mux     sync.Mutex
+}
+
+func newRotateCompressor(name string, concurrency int) (*rotateCompressor, error) {
+	compressorsMux.RLock()
+	c, has := compressors[name]
+	compressorsMux.RUnlock()
+	if !has {
+		return nil, fmt.Errorf("compress=%q not support", name)
+	}
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	rc := &rotateCompressor{
+		c:       c,
+		sem:     make(chan struct{}, concurrency),
+		running: map[string]bool{},
+	}
+	return rc, nil
+}
+
+// compress 压缩 files 中除 current 之外的，尚未压缩的文件
+func (rc *rotateCompressor) compress(files []string, current string) {
+	rc.mux.Lock()
+	defer rc.mux.Unlock()
+	for _, name := range files {
+		if name == current || rc.running[name] {
+			continue
+		}
+		if strings.HasSuffix(name, compressTmpExt) {
+			// 进程在压缩过程中退出留下的临时文件
+			if !rc.running[strings.TrimSuffix(strings.TrimSuffix(name, compressTmpExt), rc.c.Ext)] {
+				_ = os.Remove(name)
+			}
+			continue
+		}
+		if _, ok := trimCompressExt(name); ok {
+			continue
+		}
+		rc.running[name] = true
+		rc.wg.Add(1)
+		go rc.compressFile(name)
+	}
+}
+
+func (rc *rotateCompressor) compressFile(name string) {
+	defer rc.wg.Done()
+	rc.sem <- struct{}{}
+	err := CompressFile(name, rc.c)
+	<-rc.sem
+
+	rc.mux.Lock()
+	delete(rc.running, name)
+	rc.mux.Unlock()
+
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		log.Printf("[Rotator][Compress][error] CompressFile(%q): %v\n", name, err)
+	}
+}
+
+// Wait 等待正在进行的压缩完成
+func (rc *rotateCompressor) Wait() {
+	rc.wg.Wait()
+}
@@ -64,44 +64,66 @@ func CleanFiles(pattern string, remaining int) error {
 	if err != nil {
 		return err
 	}
+	cleanFiles(files, remaining, modTimeLess)
+	return nil
+}
 
-	if len(files) <= remaining {
-		return nil
-	}
+type fileInfo struct {
+	info os.FileInfo
+	path string
+}
+
+// modTimeLess 按照修改时间排序，新的文件在前
+func modTimeLess(a, b *fileInfo) bool {
+	return b.info.ModTime().Before(a.info.ModTime())
+}
 
-	type finfo struct {
-		info os.FileInfo
-		path string
+// cleanFiles 清理文件，文件按照 less 排序后，保留前 remaining 个
+func cleanFiles(files []string, remaining int, less func(a, b *fileInfo) bool) {
+	if len(files) <= remaining {
+		return
 	}
+	removeFiles(files, less, false, func(index int, _ *fileInfo, _ int64) bool {
+		return index >= remaining
+	})
+}
 
-	var infos []*finfo
+// removeFiles 将文件按照 less 排序后，依次判断是否需要删除，返回需要删除的文件
+//
+// expired 的参数：index 为排序后的序号，total 为包含当前文件在内的，之前所有文件的总大小
+func removeFiles(files []string, less func(a, b *fileInfo) bool, dryRun bool, expired func(index int, info *fileInfo, total int64) bool) []string {
+	infos := make([]*fileInfo, 0, len(files))
 	for _, p := range files {
 		info, err := os.Stat(p)
 		if os.IsNotExist(err) {
 			continue
 		}
 		if err != nil {
-			log.Fatalf("[fsgo][cleanFiles] os.Stat(%q) has error:%v\n", p, err)
+			log.Printf("[fsgo][cleanFiles] os.Stat(%q) has error:%v\n", p, err)
 			continue
 		}
-		infos = append(infos, &finfo{path: p, info: info})
-	}
-
-	if len(infos) <= remaining {
-		return nil
+		infos = append(infos, &fileInfo{path: p, info: info})
 	}
 
 	sort.Slice(infos, func(i, j int) bool {
-		a := infos[i].info.ModTime()
-		b := infos[j].info.ModTime()
-		return b.Before(a)
+		return less(infos[i], infos[j])
 	})
 
-	for i := remaining; i < len(infos); i++ {
-		p := infos[i].path
-		if err = os.Remove(p); err != nil {
-			log.Printf("[fsgo][cleanFiles] os.Remove(%q), err=%v\n", p, err)
+	var removed []string
+	var total int64
+	for i, info := range infos {
+		total += info.info.Size()
+		if !expired(i, info, total) {
+			continue
 		}
+		p := info.path
+		if !dryRun {
+			if err := os.Remove(p); err != nil {
+				log.Printf("[fsgo][cleanFiles] os.Remove(%q), err=%v\n", p, err)
+				continue
+			}
+		}
+		removed = append(removed, p)
 	}
-	return nil
+	return removed
 }
@@ -0,0 +1,183 @@
+// Copyright(C) 2026 github.com/fsgo  All Rights Reserved.
+// Author: hidu <duv123@gmail.com>
+// Date: 2026/10/19
+
+package fsfs
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ErrLocked 文件已被其他进程锁定
+var ErrLocked = errors.New("file is locked")
+
+// FileLock 基于 flock 的跨进程建议锁（advisory lock）
+//
+// 锁和打开的文件关联，进程退出后，锁会自动释放。
+// 同一个 FileLock 同时只能持有一个锁，不可重入
+type FileLock struct {
+	file *os.File
+
+	// Path 锁文件的路径，必填，若不存在会自动创建
+	Path string
+
+	mux sync.Mutex
+}
+
+// Lock 获取排它锁，阻塞直到获取成功或者 ctx 结束
+func (l *FileLock) Lock(ctx context.Context) error {
+	return l.lock(ctx, true)
+}
+
+// RLock 获取共享锁，阻塞直到获取成功或者 ctx 结束
+func (l *FileLock) RLock(ctx context.Context) error {
+	return l.lock(ctx, false)
+}
+
+// TryLock 尝试获取排它锁，若已被其他进程锁定，返回 ErrLocked
+func (l *FileLock) TryLock() error {
+	return l.tryLock(true)
+}
+
+// TryRLock 尝试获取共享锁，若已被其他进程以排它锁锁定，返回 ErrLocked
+func (l *FileLock) TryRLock() error {
+	return l.tryLock(false)
+}
+
+func (l *FileLock) lock(ctx context.Context, exclusive bool) error {
+	// flock 阻塞时不能被取消，所以使用非阻塞的方式重试
+	wait := time.Millisecond
+	for {
+		err := l.tryLock(exclusive)
+		if !errors.Is(err, ErrLocked) {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%w: %w", err, ctx.Err())
+		case <-time.After(wait):
+		}
+		wait = min(wait*2, 100*time.Millisecond)
+	}
+}
+
+func (l *FileLock) tryLock(exclusive bool) error {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+	if l.file != nil {
+		return fmt.Errorf("%q already locked by this FileLock", l.Path)
+	}
+	if len(l.Path) == 0 {
+		return errors.New("empty Path")
+	}
+	if err := KeepDirExists(filepath.Dir(l.Path)); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(l.Path, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return err
+	}
+	if err = flock(f, exclusive); err != nil {
+		_ = f.Close()
+		return err
+	}
+	l.file = f
+	return nil
+}
+
+// Unlock 释放锁
+func (l *FileLock) Unlock() error {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+	if l.file == nil {
+		return nil
+	}
+	err := funlock(l.file)
+	if errClose := l.file.Close(); err == nil {
+		err = errClose
+	}
+	l.file = nil
+	return err
+}
+
+// File 持有锁时，返回锁文件，否则返回 nil
+func (l *FileLock) File() *os.File {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+	return l.file
+}
+
+// InstanceLock 单实例的锁，由 SingleInstance 创建
+type InstanceLock struct {
+	lock *FileLock
+
+	// PrevPID 获取锁之前，文件中记录的上一个持有者的 pid，若没有则为 0
+	PrevPID int
+
+	// Stale 上一个持有者是否已异常退出（文件中有其 pid，但进程已不存在）
+	Stale bool
+}
+
+// Release 释放锁，锁文件会保留，以避免删除文件时和其他进程获取锁产生竞争
+func (il *InstanceLock) Release() error {
+	f := il.lock.File()
+	if f != nil {
+		_ = f.Truncate(0)
+	}
+	return il.lock.Unlock()
+}
+
+// SingleInstance 保证同时只有一个进程持有 path 对应的锁，如用于保证同时只有一个 master 进程
+//
+// 获取成功后，会将当前进程的 pid 写入文件；
+// 若已被其他进程持有，返回的错误包含 ErrLocked 以及持有者的 pid
+func SingleInstance(path string) (*InstanceLock, error) {
+	fl := &FileLock{Path: path}
+	if err := fl.TryLock(); err != nil {
+		if !errors.Is(err, ErrLocked) {
+			return nil, err
+		}
+		pid := readPIDFile(path)
+		if pid > 0 && !pidAlive(pid) {
+			return nil, fmt.Errorf("%w by pid=%d, which is not running, the lock may be held by its sub process", err, pid)
+		}
+		return nil, fmt.Errorf("%w by pid=%d", err, pid)
+	}
+
+	il := &InstanceLock{
+		lock:    fl,
+		PrevPID: readPIDFile(path),
+	}
+	il.Stale = il.PrevPID > 0 && il.PrevPID != os.Getpid() && !pidAlive(il.PrevPID)
+
+	f := fl.File()
+	err := f.Truncate(0)
+	if err == nil {
+		_, err = f.WriteAt([]byte(strconv.Itoa(os.Getpid())), 0)
+	}
+	if err == nil {
+		err = f.Sync()
+	}
+	if err != nil {
+		_ = fl.Unlock()
+		return nil, err
+	}
+	return il, nil
+}
+
+func readPIDFile(path string) int {
+	bf, err := os.ReadFile(path)
+	if err != nil {
+		return 0
+	}
+	pid, _ := strconv.Atoi(string(bytes.TrimSpace(bf)))
+	return pid
+}
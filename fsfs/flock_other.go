@@ -0,0 +1,26 @@
+// Copyright(C) 2026 github.com/fsgo  All Rights Reserved.
+// Author: hidu <duv123@gmail.com>
+// Date: 2026/10/19
+
+//go:build !(linux || darwin || freebsd || netbsd || openbsd || dragonfly)
+
+package fsfs
+
+import (
+	"errors"
+	"os"
+)
+
+var errFlockNotSupported = errors.New("flock not supported")
+
+func flock(_ *os.File, _ bool) error {
+	return errFlockNotSupported
+}
+
+func funlock(_ *os.File) error {
+	return errFlockNotSupported
+}
+
+func pidAlive(_ int) bool {
+	return true
+}
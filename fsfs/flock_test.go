@@ -0,0 +1,66 @@
+// Copyright(C) 2026 github.com/fsgo  All Rights Reserved.
+// Author: hidu <duv123@gmail.com>
+// Date: 2026/10/19
+
+//go:build linux || darwin
+
+package fsfs
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/fsgo/fst"
+)
+
+func TestFileLock(t *testing.T) {
+	fp := filepath.Join(t.TempDir(), "a.lock")
+	l1 := &FileLock{Path: fp}
+	l2 := &FileLock{Path: fp}
+
+	fst.NoError(t, l1.RLock(context.Background()))
+	fst.NoError(t, l2.TryRLock())
+	fst.NoError(t, l2.Unlock())
+
+	fst.True(t, errors.Is(l2.TryLock(), ErrLocked))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err := l2.Lock(ctx)
+	fst.True(t, errors.Is(err, ErrLocked))
+	fst.True(t, errors.Is(err, context.DeadlineExceeded))
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		_ = l1.Unlock()
+	}()
+	fst.NoError(t, l2.Lock(context.Background()))
+	fst.NoError(t, l2.Unlock())
+}
+
+func TestSingleInstance(t *testing.T) {
+	fp := filepath.Join(t.TempDir(), "main.lock")
+	// 一个已经不存在的进程
+	fst.NoError(t, os.WriteFile(fp, []byte("99999999"), 0644))
+
+	il, err := SingleInstance(fp)
+	fst.NoError(t, err)
+	fst.Equal(t, 99999999, il.PrevPID)
+	fst.True(t, il.Stale)
+	fst.Equal(t, os.Getpid(), readPIDFile(fp))
+
+	_, err = SingleInstance(fp)
+	fst.True(t, errors.Is(err, ErrLocked))
+	fst.Contains(t, err.Error(), "pid="+strconv.Itoa(os.Getpid()))
+
+	fst.NoError(t, il.Release())
+	il, err = SingleInstance(fp)
+	fst.NoError(t, err)
+	fst.False(t, il.Stale)
+	fst.NoError(t, il.Release())
+}
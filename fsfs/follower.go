@@ -0,0 +1,377 @@
+// Copyright(C) 2026 github.com/fsgo  All Rights Reserved.
+// Author: hidu <duv123@gmail.com>
+// Date: 2026/10/19
+
+package fsfs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var _ io.ReadCloser = (*Follower)(nil)
+
+// errFollowSwitched 已切换到新的文件
+var errFollowSwitched = errors.New("switched to new file")
+
+// Follower 类似 tail -F，持续读取文件新写入的内容
+//
+// 可以识别文件被切割（文件的 inode 变化）和被清空的情况。
+// Path 也可以是通配符，用于读取 Rotator 生成的文件，如 "log/app.log.*"，
+// 此时会按照修改时间从旧到新依次读取各个文件，会跳过已压缩的文件。
+type Follower struct {
+	file *os.File
+	info os.FileInfo
+	done chan struct{}
+
+	// Path 文件路径或通配符，必填
+	Path string
+
+	// Checkpoint 保存读取位置的文件路径，可选
+	// 若文件存在，启动时从其记录的位置继续读取，通过 SaveCheckpoint 保存
+	Checkpoint string
+
+	// name 当前正在读取的文件
+	name string
+
+	// buf ReadLine 已读取、未返回的内容
+	buf []byte
+
+	// Interval 读取到文件末尾后，检查文件变化的间隔，可选，默认为 100ms
+	Interval time.Duration
+
+	// offset 当前文件已读取的位置
+	offset int64
+
+	// FromStart 没有 Checkpoint 记录时，是否从文件开头读取，默认从文件末尾开始
+	FromStart bool
+
+	mux  sync.Mutex
+	once sync.Once
+}
+
+// followCheckpoint Checkpoint 文件的内容
+type followCheckpoint struct {
+	File   string
+	Inode  uint64
+	Offset int64
+}
+
+func (f *Follower) getInterval() time.Duration {
+	if f.Interval > 0 {
+		return f.Interval
+	}
+	return 100 * time.Millisecond
+}
+
+func (f *Follower) init() {
+	f.once.Do(func() {
+		f.done = make(chan struct{})
+	})
+}
+
+// files 所有匹配的文件，按照修改时间从旧到新排序
+func (f *Follower) files() []*fileInfo {
+	var names []string
+	if strings.ContainsAny(f.Path, `*?[\`) {
+		names, _ = filepath.Glob(f.Path)
+	} else {
+		names = []string{f.Path}
+	}
+	infos := make([]*fileInfo, 0, len(names))
+	for _, name := range names {
+		if _, compressed := trimCompressExt(name); compressed || strings.HasSuffix(name, compressTmpExt) {
+			continue
+		}
+		info, err := os.Stat(name)
+		if err != nil || info.IsDir() {
+			continue
+		}
+		infos = append(infos, &fileInfo{path: name, info: info})
+	}
+	sort.SliceStable(infos, func(i, j int) bool {
+		return modTimeLess(infos[j], infos[i])
+	})
+	return infos
+}
+
+// open 打开文件，并 seek 到 offset；offset < 0 时，从文件末尾开始
+func (f *Follower) open(name string, offset int64) error {
+	file, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return err
+	}
+	if offset < 0 || offset > info.Size() {
+		// 若 offset 超过了文件大小，说明文件被清空过
+		if offset >= 0 {
+			offset = 0
+		} else {
+			offset = info.Size()
+		}
+	}
+	if _, err = file.Seek(offset, io.SeekStart); err != nil {
+		_ = file.Close()
+		return err
+	}
+	if f.file != nil {
+		_ = f.file.Close()
+	}
+	f.file = file
+	f.info = info
+	f.name = name
+	f.offset = offset
+	return nil
+}
+
+// openFirst 首次打开文件，若有 Checkpoint，则从记录的位置继续
+func (f *Follower) openFirst() error {
+	files := f.files()
+	if len(files) == 0 {
+		return os.ErrNotExist
+	}
+	if cp := f.loadCheckpoint(); cp != nil {
+		for _, fi := range files {
+			if cp.Inode != 0 && cp.Inode == inodeOf(fi.info) || cp.Inode == 0 && cp.File == fi.path {
+				return f.open(fi.path, cp.Offset)
+			}
+		}
+		// 记录的文件已不存在，从最旧的文件开始读取，以避免遗漏
+		return f.open(files[0].path, 0)
+	}
+	offset := int64(-1)
+	if f.FromStart {
+		offset = 0
+	}
+	return f.open(files[len(files)-1].path, offset)
+}
+
+func (f *Follower) loadCheckpoint() *followCheckpoint {
+	if len(f.Checkpoint) == 0 {
+		return nil
+	}
+	bf, err := os.ReadFile(f.Checkpoint)
+	if err != nil {
+		return nil
+	}
+	var cp *followCheckpoint
+	if err = json.Unmarshal(bf, &cp); err != nil {
+		return nil
+	}
+	return cp
+}
+
+// SaveCheckpoint 将已读取（对于 ReadLine，为已返回）的位置保存到 Checkpoint 文件
+func (f *Follower) SaveCheckpoint() error {
+	if len(f.Checkpoint) == 0 {
+		return errors.New("empty Checkpoint")
+	}
+	f.mux.Lock()
+	cp := &followCheckpoint{
+		File:   f.name,
+		Offset: f.offset - int64(len(f.buf)),
+	}
+	if f.info != nil {
+		cp.Inode = inodeOf(f.info)
+	}
+	f.mux.Unlock()
+	if len(cp.File) == 0 {
+		return nil
+	}
+	bf, err := json.Marshal(cp)
+	if err != nil {
+		return err
+	}
+	return AtomicWriteFile(f.Checkpoint, bf, 0644)
+}
+
+// Offset 返回当前读取的文件和位置
+func (f *Follower) Offset() (name string, offset int64) {
+	f.mux.Lock()
+	defer f.mux.Unlock()
+	return f.name, f.offset - int64(len(f.buf))
+}
+
+// checkSwitch 读取到文件末尾后，检查文件是否被切割或者清空
+func (f *Follower) checkSwitch() error {
+	files := f.files()
+	if len(files) == 0 {
+		return nil
+	}
+	// 当前文件的信息需要每次都更新，如被重命名后，需要和其最新的修改时间比较
+	if info, err := f.file.Stat(); err == nil {
+		f.info = info
+	}
+	var next *fileInfo
+	for i, fi := range files {
+		if !os.SameFile(fi.info, f.info) {
+			continue
+		}
+		if fi.info.Size() < f.offset {
+			// 被清空了
+			if _, err := f.file.Seek(0, io.SeekStart); err != nil {
+				return err
+			}
+			f.offset = 0
+			return errFollowSwitched
+		}
+		if i+1 < len(files) {
+			next = files[i+1]
+		}
+		break
+	}
+	if next == nil {
+		// 当前文件已不在列表中（如被删除或者被重命名了），切换到比当前文件新的文件
+		if slicesIndex(files, f.info) >= 0 {
+			return nil
+		}
+		for _, fi := range files {
+			if !fi.info.ModTime().Before(f.info.ModTime()) {
+				next = fi
+				break
+			}
+		}
+		if next == nil {
+			return nil
+		}
+	}
+	// 旧文件还有未读取的内容，先读取完
+	if f.info.Size() > f.offset {
+		return nil
+	}
+	if err := f.open(next.path, 0); err != nil {
+		return err
+	}
+	return errFollowSwitched
+}
+
+func slicesIndex(files []*fileInfo, info os.FileInfo) int {
+	for i, fi := range files {
+		if os.SameFile(fi.info, info) {
+			return i
+		}
+	}
+	return -1
+}
+
+// read 读取数据，若没有新数据则等待，直到 ctx 结束或者 Close
+//
+// 切换到新文件时返回 errFollowSwitched
+func (f *Follower) read(ctx context.Context, p []byte) (int, error) {
+	f.init()
+	for {
+		n, err := f.tryRead(p)
+		if n > 0 || (err != nil && err != io.EOF) {
+			return n, err
+		}
+		select {
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		case <-f.done:
+			return 0, io.EOF
+		case <-time.After(f.getInterval()):
+		}
+	}
+}
+
+func (f *Follower) tryRead(p []byte) (int, error) {
+	f.mux.Lock()
+	defer f.mux.Unlock()
+	select {
+	case <-f.done:
+		// 已经 Close 了
+		return 0, io.EOF
+	default:
+	}
+	if f.file == nil {
+		if err := f.openFirst(); err != nil {
+			if os.IsNotExist(err) {
+				return 0, io.EOF
+			}
+			return 0, err
+		}
+	}
+	n, err := f.file.Read(p)
+	f.offset += int64(n)
+	if n > 0 || err != io.EOF {
+		return n, err
+	}
+	// 读取到文件末尾了，检查文件是否被切割
+	if err = f.checkSwitch(); err != nil {
+		return 0, err
+	}
+	return 0, io.EOF
+}
+
+// Read 读取新写入的数据，若没有新数据则阻塞，直到有新数据或者 Close
+func (f *Follower) Read(p []byte) (int, error) {
+	for {
+		n, err := f.read(context.Background(), p)
+		if err != errFollowSwitched {
+			return n, err
+		}
+	}
+}
+
+// ReadLine 读取一行，返回的内容不包含换行符，若没有完整的行则等待，直到 ctx 结束或者 Close
+//
+// 文件切换时，旧文件末尾未换行的内容会作为一行返回
+func (f *Follower) ReadLine(ctx context.Context) ([]byte, error) {
+	chunk := make([]byte, 32*1024)
+	for {
+		f.mux.Lock()
+		if idx := bytes.IndexByte(f.buf, '\n'); idx >= 0 {
+			line := bytes.Clone(f.buf[:idx])
+			f.buf = f.buf[idx+1:]
+			f.mux.Unlock()
+			return line, nil
+		}
+		f.mux.Unlock()
+
+		n, err := f.read(ctx, chunk)
+		f.mux.Lock()
+		if n > 0 {
+			f.buf = append(f.buf, chunk[:n]...)
+		}
+		if err == errFollowSwitched && len(f.buf) > 0 {
+			line := f.buf
+			f.buf = nil
+			f.mux.Unlock()
+			return line, nil
+		}
+		f.mux.Unlock()
+		if err != nil && err != errFollowSwitched {
+			return nil, err
+		}
+	}
+}
+
+// Close 关闭，正在阻塞的和之后调用的 Read、ReadLine 会返回 io.EOF
+func (f *Follower) Close() error {
+	f.init()
+	f.mux.Lock()
+	defer f.mux.Unlock()
+	select {
+	case <-f.done:
+		return nil
+	default:
+		close(f.done)
+	}
+	if f.file != nil {
+		return f.file.Close()
+	}
+	return nil
+}
@@ -0,0 +1,15 @@
+// Copyright(C) 2026 github.com/fsgo  All Rights Reserved.
+// Author: hidu <duv123@gmail.com>
+// Date: 2026/10/19
+
+//go:build windows || wasm
+
+package fsfs
+
+import (
+	"os"
+)
+
+func inodeOf(_ os.FileInfo) uint64 {
+	return 0
+}
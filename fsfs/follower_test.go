@@ -0,0 +1,138 @@
+// Copyright(C) 2026 github.com/fsgo  All Rights Reserved.
+// Author: hidu <duv123@gmail.com>
+// Date: 2026/10/19
+
+package fsfs
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/fsgo/fst"
+)
+
+func appendFile(t *testing.T, name string, content string) {
+	t.Helper()
+	f, err := os.OpenFile(name, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	fst.NoError(t, err)
+	_, err = f.WriteString(content)
+	fst.NoError(t, err)
+	fst.NoError(t, f.Close())
+}
+
+func readLine(t *testing.T, f *Follower) string {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	line, err := f.ReadLine(ctx)
+	fst.NoError(t, err)
+	return string(line)
+}
+
+func TestFollower(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "app.log")
+	appendFile(t, name, "old\n")
+
+	f := &Follower{
+		Path:       name,
+		Interval:   10 * time.Millisecond,
+		Checkpoint: filepath.Join(dir, "app.log.cp"),
+	}
+	defer f.Close()
+
+	t.Run("from end", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		_, err := f.ReadLine(ctx)
+		fst.Error(t, err)
+		appendFile(t, name, "hello\nwor")
+		fst.Equal(t, "hello", readLine(t, f))
+		appendFile(t, name, "ld\n")
+		fst.Equal(t, "world", readLine(t, f))
+	})
+
+	t.Run("truncate", func(t *testing.T) {
+		fst.NoError(t, os.Truncate(name, 0))
+		appendFile(t, name, "a\n")
+		fst.Equal(t, "a", readLine(t, f))
+	})
+
+	t.Run("rotate", func(t *testing.T) {
+		appendFile(t, name, "b\nc")
+		fst.NoError(t, os.Rename(name, name+".1"))
+		appendFile(t, name, "d\n")
+		fst.Equal(t, "b", readLine(t, f))
+		fst.Equal(t, "c", readLine(t, f))
+		fst.Equal(t, "d", readLine(t, f))
+	})
+
+	t.Run("checkpoint", func(t *testing.T) {
+		fst.NoError(t, f.SaveCheckpoint())
+		fst.NoError(t, f.Close())
+		_, err := f.Read(make([]byte, 10))
+		fst.Equal(t, io.EOF, err)
+		appendFile(t, name, "e\n")
+
+		f2 := &Follower{
+			Path:       name,
+			Interval:   10 * time.Millisecond,
+			Checkpoint: f.Checkpoint,
+		}
+		defer f2.Close()
+		fst.Equal(t, "e", readLine(t, f2))
+		_, offset := f2.Offset()
+		fst.Equal(t, int64(4), offset)
+	})
+}
+
+func TestFollowerPattern(t *testing.T) {
+	dir := t.TempDir()
+	name1 := filepath.Join(dir, "app.log.1")
+	appendFile(t, name1, "1\n")
+	f := &Follower{
+		Path:      filepath.Join(dir, "app.log.*"),
+		Interval:  10 * time.Millisecond,
+		FromStart: true,
+	}
+	defer f.Close()
+	fst.Equal(t, "1", readLine(t, f))
+
+	name2 := filepath.Join(dir, "app.log.2")
+	appendFile(t, name2, "2\n")
+	future := time.Now().Add(time.Second)
+	fst.NoError(t, os.Chtimes(name2, future, future))
+	appendFile(t, filepath.Join(dir, "app.log.0.gz"), "gz\n")
+	fst.Equal(t, "2", readLine(t, f))
+}
+
+func TestFollowerPattern_renamed(t *testing.T) {
+	dir := t.TempDir()
+	name1 := filepath.Join(dir, "app.log.1")
+	appendFile(t, name1, "1\n")
+	f := &Follower{
+		Path:      filepath.Join(dir, "app.log.*"),
+		Interval:  10 * time.Millisecond,
+		FromStart: true,
+	}
+	defer f.Close()
+	fst.Equal(t, "1", readLine(t, f))
+	appendFile(t, name1, "x\n")
+	fst.Equal(t, "x", readLine(t, f))
+
+	// 当前文件在打开后有写入，被重命名后，需要和其最新的修改时间比较，不能切换到更旧的文件
+	now := time.Now()
+	fst.NoError(t, os.Chtimes(name1, now.Add(2*time.Second), now.Add(2*time.Second)))
+	fst.NoError(t, os.Rename(name1, filepath.Join(dir, "other.log")))
+	name2 := filepath.Join(dir, "app.log.2")
+	appendFile(t, name2, "2\n")
+	fst.NoError(t, os.Chtimes(name2, now.Add(time.Second), now.Add(time.Second)))
+	name3 := filepath.Join(dir, "app.log.3")
+	appendFile(t, name3, "3\n")
+	fst.NoError(t, os.Chtimes(name3, now.Add(3*time.Second), now.Add(3*time.Second)))
+	fst.Equal(t, "3", readLine(t, f))
+}
@@ -0,0 +1,20 @@
+// Copyright(C) 2026 github.com/fsgo  All Rights Reserved.
+// Author: hidu <duv123@gmail.com>
+// Date: 2026/10/19
+
+//go:build !windows && !wasm
+
+package fsfs
+
+import (
+	"os"
+	"syscall"
+)
+
+// inodeOf 返回文件的 inode
+func inodeOf(info os.FileInfo) uint64 {
+	if st, ok := info.Sys().(*syscall.Stat_t); ok {
+		return uint64(st.Ino)
+	}
+	return 0
+}
@@ -0,0 +1,58 @@
+// Copyright(C) 2026 github.com/fsgo  All Rights Reserved.
+// Author: hidu <duv123@gmail.com>
+// Date: 2026/10/19
+
+package fsfs
+
+import (
+	"path/filepath"
+	"time"
+)
+
+// Retention 文件保留策略，各个条件可以单独使用，也可以组合使用，满足任意一个条件的文件都会被删除
+//
+// 文件按照修改时间从新到旧排序，优先删除老的文件。
+// 可以单独使用，也可以通过 Rotator.Retention 使用
+type Retention struct {
+	// MaxFiles 最多保留的文件数，可选，<=0 时不限制
+	MaxFiles int
+
+	// MaxAge 文件的最长保留时间（按照修改时间），可选，<=0 时不限制
+	MaxAge time.Duration
+
+	// MaxBytes 所有文件的总大小上限，可选，<=0 时不限制
+	// 超过时，从最老的文件开始删除，直到总大小不超过此值
+	MaxBytes int64
+
+	// DryRun 是否只返回需要删除的文件，而不真正删除
+	DryRun bool
+}
+
+// Clean 按照策略清理 pattern 匹配的文件，返回已删除的文件（DryRun 时为需要删除的文件）
+//
+//	pattern: eg /home/work/logs/access_log.log.*
+func (r *Retention) Clean(pattern string) ([]string, error) {
+	files, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, err
+	}
+	return r.CleanFiles(files), nil
+}
+
+// CleanFiles 按照策略清理指定的文件，返回已删除的文件（DryRun 时为需要删除的文件）
+func (r *Retention) CleanFiles(files []string) []string {
+	return r.cleanFiles(files, modTimeLess)
+}
+
+func (r *Retention) cleanFiles(files []string, less func(a, b *fileInfo) bool) []string {
+	now := time.Now()
+	return removeFiles(files, less, r.DryRun, func(index int, info *fileInfo, total int64) bool {
+		if r.MaxFiles > 0 && index >= r.MaxFiles {
+			return true
+		}
+		if r.MaxAge > 0 && now.Sub(info.info.ModTime()) > r.MaxAge {
+			return true
+		}
+		return r.MaxBytes > 0 && total > r.MaxBytes
+	})
+}
@@ -0,0 +1,63 @@
+// Copyright(C) 2026 github.com/fsgo  All Rights Reserved.
+// Author: hidu <duv123@gmail.com>
+// Date: 2026/10/19
+
+package fsfs
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/fsgo/fst"
+)
+
+func TestRetention_Clean(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	// a.log.0 最新，a.log.4 最老，每个 10 字节
+	var files []string
+	for i := 0; i < 5; i++ {
+		fp := filepath.Join(dir, "a.log."+strconv.Itoa(i))
+		fst.NoError(t, os.WriteFile(fp, []byte("0123456789"), 0644))
+		mt := now.Add(-time.Duration(i) * time.Hour)
+		fst.NoError(t, os.Chtimes(fp, mt, mt))
+		files = append(files, fp)
+	}
+	pattern := filepath.Join(dir, "a.log.*")
+
+	t.Run("dry run", func(t *testing.T) {
+		r := &Retention{MaxFiles: 4, DryRun: true}
+		got, err := r.Clean(pattern)
+		fst.NoError(t, err)
+		fst.Equal(t, files[4:], got)
+
+		r = &Retention{MaxAge: 150 * time.Minute, DryRun: true}
+		got, err = r.Clean(pattern)
+		fst.NoError(t, err)
+		fst.Equal(t, files[3:], got)
+
+		r = &Retention{MaxBytes: 25, DryRun: true}
+		got, err = r.Clean(pattern)
+		fst.NoError(t, err)
+		fst.Equal(t, files[2:], got)
+
+		r = &Retention{MaxFiles: 4, MaxAge: 90 * time.Minute, DryRun: true}
+		got, err = r.Clean(pattern)
+		fst.NoError(t, err)
+		fst.Equal(t, files[2:], got)
+	})
+
+	t.Run("remove", func(t *testing.T) {
+		r := &Retention{MaxBytes: 30}
+		got, err := r.Clean(pattern)
+		fst.NoError(t, err)
+		fst.Equal(t, files[3:], got)
+
+		left, err := filepath.Glob(pattern)
+		fst.NoError(t, err)
+		fst.Equal(t, files[:3], left)
+	})
+}
@@ -10,7 +10,11 @@ import (
 	"io"
 	"log"
 	"os"
+	"path/filepath"
+	"slices"
 	"sort"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -45,11 +49,36 @@ type Rotator struct {
 	// 若值为 -1，则保留所有文件
 	MaxFiles int
 
+	// Retention 文件保留策略，可选，如按照文件的保留时长、总大小清理
+	// 若设置了此值，MaxFiles 不再生效。当前正在写入的文件不会被清理
+	Retention *Retention
+
 	// MaxDelay 最大延迟时间,可选，默认为 100ms.
 	// 影响文件状态、buffer.
 	// 如文件被删除了，则最大间隔 MaxDelay 时长会检查到
 	MaxDelay time.Duration
 
+	// MaxSize 单个文件的最大字节数，可选，默认为 0，不限制
+	// 在同一个切割周期内，文件超过此大小后，会切换到添加了序号后缀的新文件，
+	// 如 app.log.2026101712 -> app.log.2026101712.1 -> app.log.2026101712.2
+	// 文件大小是每间隔 MaxDelay 检查一次，所以实际文件可能会略大于此值
+	MaxSize int64
+
+	// Compress 已切割的文件的压缩方式，可选，默认不压缩
+	// 目前支持 gzip，也可以通过 RegisterCompressor 注册其他的压缩方式（如 zstd）
+	// 压缩是在后台异步进行的，压缩后的文件和未压缩的文件一起按照 MaxFiles 保留
+	Compress string
+
+	// CompressConcurrency 同时压缩的最大文件数，可选，默认为 1
+	CompressConcurrency int
+
+	compressor *rotateCompressor
+
+	// sizeBase、sizeIndex 当前切割周期的文件名和序号，用于 MaxSize
+	sizeBase  string
+	sizeIndex int
+	sizeMux   sync.Mutex
+
 	onceSetup sync.Once
 	onceInit  sync.Once
 }
@@ -103,6 +132,9 @@ func (f *Rotator) setup() error {
 	}
 
 	f.setupKeepFile()
+	if err := f.setupCompress(); err != nil {
+		return err
+	}
 	f.setupClean()
 
 	return nil
@@ -116,8 +148,12 @@ func (f *Rotator) getMaxDelay() time.Duration {
 }
 
 func (f *Rotator) setupKeepFile() {
+	filePath := f.filePathFn
+	if f.MaxSize > 0 {
+		filePath = f.sizedFilePath
+	}
 	f.kp = &Keeper{
-		FilePath:      f.filePathFn,
+		FilePath:      filePath,
 		CheckInterval: f.getMaxDelay(),
 	}
 
@@ -130,6 +166,25 @@ func (f *Rotator) onFileChange(file *os.File) {
 }
 
 func (f *Rotator) setupClean() {
+	if f.Retention != nil {
+		f.kp.AfterChange(func(file *os.File) {
+			files, err := f.rotatedFiles()
+			if err != nil {
+				log.Println("[Rotator][CleanFiles][error]", err)
+				return
+			}
+			// 当前正在写入的文件，不参与清理，也不计入 MaxFiles、MaxBytes
+			files = slices.DeleteFunc(files, func(name string) bool {
+				return name == file.Name()
+			})
+			removed := f.Retention.cleanFiles(files, f.rotatedFileLess)
+			if f.Retention.DryRun && len(removed) > 0 {
+				log.Println("[Rotator][CleanFiles][DryRun] files to remove:", removed)
+			}
+		})
+		return
+	}
+
 	if f.MaxFiles < 0 {
 		return
 	}
@@ -139,13 +194,176 @@ func (f *Rotator) setupClean() {
 		num = 24
 	}
 	f.kp.AfterChange(func(_ *os.File) {
-		err := CleanFiles(f.Path+"*", num)
+		files, err := f.rotatedFiles()
 		if err != nil {
 			log.Println("[Rotator][CleanFiles][error]", err)
+			return
 		}
+		cleanFiles(files, num, f.rotatedFileLess)
 	})
 }
 
+func (f *Rotator) setupCompress() error {
+	if len(f.Compress) == 0 {
+		return nil
+	}
+	rc, err := newRotateCompressor(f.Compress, f.CompressConcurrency)
+	if err != nil {
+		return err
+	}
+	f.compressor = rc
+	f.kp.AfterChange(func(cur *os.File) {
+		files, err := filepath.Glob(f.Path + "*")
+		if err != nil {
+			log.Println("[Rotator][Compress][error]", err)
+			return
+		}
+		// 只压缩此 Rotator 自己生成的文件，同目录下相同前缀的其他文件（如 app.log.wf.2026101712）不处理
+		files = slices.DeleteFunc(files, func(name string) bool {
+			return !f.isRotatedFile(strings.TrimSuffix(name, compressTmpExt))
+		})
+		rc.compress(files, cur.Name())
+	})
+	return nil
+}
+
+// isRotatedFile 判断文件名是否符合此 Rotator 的命名规则，即 Path + 周期后缀 [+ .序号]，可能已被压缩
+//
+// 周期后缀以当前的文件名为准：长度需要相同，且数字的位置相同、其他字符相同
+func (f *Rotator) isRotatedFile(fp string) bool {
+	name, _ := trimCompressExt(fp)
+	if !strings.HasPrefix(name, f.Path) {
+		return false
+	}
+	ext, _ := f.splitRotateIndex(name)
+	cur := strings.TrimPrefix(f.filePathFn(), f.Path)
+	if len(ext) != len(cur) {
+		return false
+	}
+	for i := 0; i < len(ext); i++ {
+		if isDigit(ext[i]) != isDigit(cur[i]) || (!isDigit(cur[i]) && ext[i] != cur[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func isDigit(b byte) bool {
+	return b >= '0' && b <= '9'
+}
+
+// rotatedFiles 所有的已切割的文件，包括已压缩的，不包括正在压缩中的临时文件
+func (f *Rotator) rotatedFiles() ([]string, error) {
+	files, err := filepath.Glob(f.Path + "*")
+	if err != nil {
+		return nil, err
+	}
+	result := files[:0]
+	for _, name := range files {
+		if !strings.HasSuffix(name, compressTmpExt) {
+			result = append(result, name)
+		}
+	}
+	return result, nil
+}
+
+// sizedFilePath 在 filePathFn 的基础上，当文件超过 MaxSize 后，添加序号后缀
+func (f *Rotator) sizedFilePath() string {
+	base := f.filePathFn()
+
+	f.sizeMux.Lock()
+	defer f.sizeMux.Unlock()
+
+	if base != f.sizeBase {
+		f.sizeBase = base
+		// 程序重启后，继续使用当前周期内序号最大的文件
+		f.sizeIndex = lastRotateIndex(base)
+	}
+	fp := rotateIndexPath(base, f.sizeIndex)
+	if info, err := os.Stat(fp); err == nil && info.Size() >= f.MaxSize {
+		f.sizeIndex++
+		fp = rotateIndexPath(base, f.sizeIndex)
+	}
+	return fp
+}
+
+func rotateIndexPath(base string, index int) string {
+	if index == 0 {
+		return base
+	}
+	return base + "." + strconv.Itoa(index)
+}
+
+// lastRotateIndex 查找 base 可继续写入的序号
+//
+// 若序号最大的文件已经被压缩了，则使用下一个序号
+func lastRotateIndex(base string) int {
+	files, _ := filepath.Glob(base + "*")
+	last := -1
+	var lastPlain bool
+	for _, fp := range files {
+		name, compressed := trimCompressExt(fp)
+		var index int
+		if name != base {
+			var ok bool
+			if index, ok = parseRotateIndex(strings.TrimPrefix(name, base+".")); !ok {
+				continue
+			}
+		}
+		if index > last {
+			last = index
+			lastPlain = false
+		}
+		if index == last && !compressed {
+			lastPlain = true
+		}
+	}
+	if last < 0 {
+		return 0
+	}
+	if !lastPlain {
+		return last + 1
+	}
+	return last
+}
+
+func parseRotateIndex(str string) (int, bool) {
+	index, err := strconv.Atoi(str)
+	return index, err == nil && index > 0
+}
+
+// rotatedFileLess 文件排序规则，新的文件在前
+// 优先使用修改时间，修改时间相同时（如在同一秒内因 MaxSize 切换了文件），按照文件名里的周期和序号排序
+func (f *Rotator) rotatedFileLess(a, b *fileInfo) bool {
+	ta, tb := a.info.ModTime(), b.info.ModTime()
+	if !ta.Equal(tb) {
+		return tb.Before(ta)
+	}
+	extA, indexA := f.splitRotateIndex(a.path)
+	extB, indexB := f.splitRotateIndex(b.path)
+	if extA != extB {
+		return extA > extB
+	}
+	return indexA > indexB
+}
+
+// splitRotateIndex 将 app.log.2026101712.1 拆分为 .2026101712 和 1
+//
+// 同一个规则生成的文件后缀长度是固定的，所以以当前的文件名为准来拆分
+func (f *Rotator) splitRotateIndex(fp string) (string, int) {
+	fp, _ = trimCompressExt(fp)
+	rest := strings.TrimPrefix(fp, f.Path)
+	extLen := len(f.filePathFn()) - len(f.Path)
+	if extLen < 0 || extLen > len(rest) {
+		return rest, 0
+	}
+	ext, suffix := rest[:extLen], rest[extLen:]
+	if index, ok := parseRotateIndex(strings.TrimPrefix(suffix, ".")); ok && strings.HasPrefix(suffix, ".") {
+		return ext, index
+	}
+	return rest, 0
+}
+
 func (f *Rotator) setFilePathFn() error {
 	if len(f.Path) == 0 {
 		return errors.New("f.Path is empty")
@@ -202,6 +420,9 @@ func (f *Rotator) Close() error {
 	}
 	_ = fsio.TryFlush(f.writer)
 	f.kp.Stop()
+	if f.compressor != nil {
+		f.compressor.Wait()
+	}
 	return nil
 }
 
@@ -0,0 +1,150 @@
+// Copyright(C) 2026 github.com/fsgo  All Rights Reserved.
+// Author: hidu <duv123@gmail.com>
+// Date: 2026/10/19
+
+package fsfs
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/fsgo/fst"
+)
+
+func TestRotator_MaxSize(t *testing.T) {
+	dir := t.TempDir()
+	r := &Rotator{
+		Path:     filepath.Join(dir, "app.log"),
+		ExtRule:  "1hour",
+		MaxSize:  10,
+		MaxDelay: 10 * time.Millisecond,
+	}
+	defer r.Close()
+	fst.NoError(t, r.Init())
+	base := r.filePathFn()
+
+	for i := 0; i < 3; i++ {
+		_, err := r.Write([]byte("hello world\n"))
+		fst.NoError(t, err)
+		time.Sleep(50 * time.Millisecond)
+	}
+	for _, name := range []string{base, base + ".1", base + ".2"} {
+		info, err := os.Stat(name)
+		fst.NoError(t, err)
+		fst.Equal(t, int64(12), info.Size())
+	}
+	// .2 也已经超过 MaxSize，已切换到 .3
+	fst.Equal(t, 3, lastRotateIndex(base))
+
+	ext, index := r.splitRotateIndex(base + ".2")
+	fst.Equal(t, base[len(r.Path):], ext)
+	fst.Equal(t, 2, index)
+
+	ext, index = r.splitRotateIndex(base)
+	fst.Equal(t, base[len(r.Path):], ext)
+	fst.Equal(t, 0, index)
+}
+
+func TestRotator_Compress(t *testing.T) {
+	dir := t.TempDir()
+	r := &Rotator{
+		Path:     filepath.Join(dir, "app.log"),
+		ExtRule:  "1hour",
+		MaxSize:  10,
+		MaxDelay: 10 * time.Millisecond,
+		Compress: "gzip",
+		MaxFiles: 3,
+	}
+	fst.NoError(t, r.Init())
+	base := r.filePathFn()
+
+	// 模拟进程在压缩过程中退出后留下的临时文件
+	fst.NoError(t, os.WriteFile(base+".gz"+compressTmpExt, []byte("bad"), 0644))
+
+	for i := 0; i < 4; i++ {
+		_, err := r.Write([]byte("hello world\n"))
+		fst.NoError(t, err)
+		time.Sleep(50 * time.Millisecond)
+	}
+	fst.NoError(t, r.Close())
+
+	files, err := r.rotatedFiles()
+	fst.NoError(t, err)
+	fst.Equal(t, []string{base + ".2.gz", base + ".3.gz", base + ".4"}, files)
+
+	_, err = os.Stat(base + ".gz" + compressTmpExt)
+	fst.True(t, os.IsNotExist(err))
+
+	// 已压缩的文件，序号需要继续向后
+	fst.Equal(t, 4, lastRotateIndex(base))
+	fst.NoError(t, os.Remove(base+".4"))
+	fst.Equal(t, 4, lastRotateIndex(base))
+}
+
+func TestRotator_CompressOwnFiles(t *testing.T) {
+	dir := t.TempDir()
+	r := &Rotator{
+		Path:     filepath.Join(dir, "app.log"),
+		ExtRule:  "1hour",
+		MaxSize:  10,
+		MaxDelay: 10 * time.Millisecond,
+		Compress: "gzip",
+		MaxFiles: -1,
+	}
+	fst.NoError(t, r.Init())
+	base := r.filePathFn()
+	ext := base[len(r.Path):]
+
+	// 同目录下其他 Rotator 的文件和无关的文件，都不能被压缩
+	others := []string{r.Path + ".wf" + ext, r.Path + ".bak", r.Path + ext + ".bak"}
+	for _, name := range others {
+		fst.NoError(t, os.WriteFile(name, []byte("other"), 0644))
+		fst.False(t, r.isRotatedFile(name))
+	}
+	fst.True(t, r.isRotatedFile(base))
+	fst.True(t, r.isRotatedFile(base+".2.gz"))
+
+	for i := 0; i < 2; i++ {
+		_, err := r.Write([]byte("hello world\n"))
+		fst.NoError(t, err)
+		time.Sleep(50 * time.Millisecond)
+	}
+	fst.NoError(t, r.Close())
+
+	for _, name := range others {
+		_, err := os.Stat(name)
+		fst.NoError(t, err)
+	}
+	_, err := os.Stat(base + ".1.gz")
+	fst.NoError(t, err)
+}
+
+func TestRotator_Retention(t *testing.T) {
+	dir := t.TempDir()
+	r := &Rotator{
+		Path:      filepath.Join(dir, "app.log"),
+		ExtRule:   "1hour",
+		MaxDelay:  10 * time.Millisecond,
+		Retention: &Retention{MaxBytes: 10},
+	}
+	base := r.Path + time.Now().Format(".2006010215")
+	old := r.Path + time.Now().Add(-time.Hour).Format(".2006010215")
+	fst.NoError(t, os.WriteFile(old, []byte("hello world\n"), 0644))
+	fst.NoError(t, os.Chtimes(old, time.Now().Add(-time.Hour), time.Now().Add(-time.Hour)))
+	// 重启后继续写入的文件已经超过 MaxBytes，依然不能被删除
+	fst.NoError(t, os.WriteFile(base, []byte("hello world\n"), 0644))
+
+	fst.NoError(t, r.Init())
+	defer r.Close()
+	fst.Equal(t, base, r.filePathFn())
+
+	_, err := r.Write([]byte("hello\n"))
+	fst.NoError(t, err)
+	fst.NoError(t, r.Close())
+
+	files, err := r.rotatedFiles()
+	fst.NoError(t, err)
+	fst.Equal(t, []string{base}, files)
+}
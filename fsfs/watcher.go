@@ -6,9 +6,11 @@ package fsfs
 
 import (
 	"errors"
+	"io/fs"
 	"log"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"time"
 
@@ -23,32 +25,83 @@ const (
 
 	// WatcherEventDelete  delete event
 	WatcherEventDelete WatcherEventType = "delete"
+
+	// WatcherEventRename rename event, FileName is the new name, OldName is the old name
+	// only the inotify backend has this event, the polling backend reports delete and update
+	WatcherEventRename WatcherEventType = "rename"
 )
 
 // WatcherEvent event for watcher
 type WatcherEvent struct {
 	FileName string
 	Type     WatcherEventType
+
+	// OldName the old file name for WatcherEventRename
+	OldName string
 }
 
 // String event desc
 func (we *WatcherEvent) String() string {
+	if we.Type == WatcherEventRename {
+		return we.OldName + " " + string(we.Type) + " " + we.FileName
+	}
 	return we.FileName + " " + string(we.Type)
 }
 
+// WatcherBackend the implementation of Watcher
+type WatcherBackend string
+
+const (
+	// WatcherBackendPoll polling with filepath.Glob on every Interval, it is the default
+	WatcherBackendPoll WatcherBackend = "poll"
+
+	// WatcherBackendAuto use inotify if available, otherwise use polling
+	WatcherBackendAuto WatcherBackend = "auto"
+
+	// WatcherBackendInotify use inotify, only available on linux
+	WatcherBackendInotify WatcherBackend = "inotify"
+)
+
 // Watcher 文件监听
 type Watcher struct {
-	timer *fstime.Interval
-	rules []*watchRule
+	timer  *fstime.Interval
+	notify *notifyBackend
 
+	rules    []*watchRule
+	rulesMux sync.RWMutex
+
+	// rulesAdded 添加了规则的通知，容量为 1，用于唤醒 inotify 的 goroutine
+	rulesAdded chan struct{}
+
+	// Interval 轮询的间隔，可选，默认为 1s
+	// 使用 inotify 时，用于轮询还不能使用 inotify 监听的规则（如目录还不存在）
 	Interval time.Duration
-	Delay    time.Duration
-	mux      sync.RWMutex
-	started  bool
+
+	// Delay 文件变化后，需要保持不变的时长，之后才会回调，可选，默认为 1s
+	// 使用 inotify 时，在此时长内同一个文件的多个事件会合并为一个
+	Delay time.Duration
+
+	// Backend 实现方式，可选，默认为 WatcherBackendPoll
+	// 使用 inotify 时（WatcherBackendAuto、WatcherBackendInotify），文件重命名会产生 WatcherEventRename 事件
+	Backend WatcherBackend
+
+	mux     sync.RWMutex
+	started bool
 }
 
-// Watch add  file watch with callback
+// Watch add  file watch with callback, can be called after Start
+//
+//	name: file name or glob pattern, eg: conf/*.toml
 func (w *Watcher) Watch(name string, callback func(event WatcherEvent)) {
+	w.addRule(name, false, callback)
+}
+
+// WatchDir add recursive watch for all files in dir with callback, can be called after Start
+func (w *Watcher) WatchDir(dir string, callback func(event WatcherEvent)) {
+	w.addRule(dir, true, callback)
+}
+
+func (w *Watcher) addRule(name string, recursive bool, callback func(event WatcherEvent)) {
 	if len(name) == 0 {
 		panic("name is empty")
 	}
@@ -56,14 +109,45 @@ func (w *Watcher) Watch(name string, callback func(event WatcherEvent)) {
 		panic("callback is nil")
 	}
 	wd := &watchRule{
-		Name:     name,
-		CallBack: callback,
-		delay:    time.Second,
+		Name:      filepath.Clean(name),
+		CallBack:  callback,
+		delay:     time.Second,
+		recursive: recursive,
+		pending:   map[string]*pendingEvent{},
 	}
 	if w.Delay > 0 {
 		wd.delay = w.Delay
 	}
+
+	// 不能等待 inotify 的 goroutine，因为回调中也可能会调用 Watch
+	w.rulesMux.Lock()
 	w.rules = append(w.rules, wd)
+	ch := w.getRulesAddedLocked()
+	w.rulesMux.Unlock()
+
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
+}
+
+func (w *Watcher) getRulesAdded() chan struct{} {
+	w.rulesMux.Lock()
+	defer w.rulesMux.Unlock()
+	return w.getRulesAddedLocked()
+}
+
+func (w *Watcher) getRulesAddedLocked() chan struct{} {
+	if w.rulesAdded == nil {
+		w.rulesAdded = make(chan struct{}, 1)
+	}
+	return w.rulesAdded
+}
+
+func (w *Watcher) getRules() []*watchRule {
+	w.rulesMux.RLock()
+	defer w.rulesMux.RUnlock()
+	return w.rules
 }
 
 func (w *Watcher) getInterval() time.Duration {
@@ -80,6 +164,17 @@ func (w *Watcher) Start() error {
 	if w.started {
 		return errors.New("already started")
 	}
+	if w.Backend == WatcherBackendAuto || w.Backend == WatcherBackendInotify {
+		nb, err := newNotifyBackend(w)
+		if err == nil {
+			w.notify = nb
+			w.started = true
+			return nil
+		}
+		if w.Backend == WatcherBackendInotify {
+			return err
+		}
+	}
 	w.timer = &fstime.Interval{}
 	w.timer.Add(w.scan)
 	w.timer.Start(w.getInterval())
@@ -93,8 +188,8 @@ func (w *Watcher) scan() {
 			log.Println("[fsgo] fsfs.Watcher.scan panic:", re)
 		}
 	}()
-	for _, rule := range w.rules {
-		rule.scan()
+	for _, rule := range w.getRules() {
+		_ = rule.scan()
 	}
 }
 
@@ -105,7 +200,12 @@ func (w *Watcher) Stop() {
 	if !w.started {
 		return
 	}
-	w.timer.Stop()
+	if w.notify != nil {
+		w.notify.Stop()
+		w.notify = nil
+	} else {
+		w.timer.Stop()
+	}
 	w.started = false
 }
 
@@ -114,17 +214,60 @@ type watchRule struct {
 	last     map[string]time.Time
 	Name     string
 	delay    time.Duration
+
+	// recursive 是否是递归监听目录
+	recursive bool
+
+	// 以下字段仅 inotify 方式使用
+	// pending 有事件、待回调的文件
+	pending map[string]*pendingEvent
+
+	// poll 是否需要轮询，如还不能使用 inotify 监听
+	poll bool
 }
 
 func (wr *watchRule) checkDelay(modTime time.Time) bool {
 	return modTime.Before(time.Now().Add(-1 * wr.delay))
 }
 
-func (wr *watchRule) scan() {
-	matches, err := filepath.Glob(wr.Name)
+// match 判断文件是否属于此规则
+func (wr *watchRule) match(name string) bool {
+	if wr.recursive {
+		return strings.HasPrefix(name, wr.Name+string(filepath.Separator))
+	}
+	ok, _ := filepath.Match(wr.Name, name)
+	return ok
+}
+
+// files 规则匹配的所有文件
+func (wr *watchRule) files() ([]string, error) {
+	if !wr.recursive {
+		return filepath.Glob(wr.Name)
+	}
+	var files []string
+	err := filepath.WalkDir(wr.Name, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if !d.IsDir() {
+			files = append(files, path)
+		}
+		return nil
+	})
+	return files, err
+}
+
+// scan 扫描所有文件，和上次扫描的结果对比，并回调
+//
+// 返回已有变化，但是还没有超过 delay 时长的文件
+func (wr *watchRule) scan() (unsettled []string) {
+	matches, err := wr.files()
 	if err != nil {
 		log.Printf("[fsgo] fsfs.Watch(%q) err: %v\n", wr.Name, err)
-		return
+		return nil
 	}
 	if wr.last == nil {
 		wr.last = map[string]time.Time{}
@@ -147,6 +290,7 @@ func (wr *watchRule) scan() {
 				wr.CallBack(event)
 			} else {
 				nowData[name] = info.ModTime().Add(-1)
+				unsettled = append(unsettled, name)
 			}
 		} else {
 			// 没有变化的情况
@@ -164,4 +308,5 @@ func (wr *watchRule) scan() {
 		}
 	}
 	wr.last = nowData
+	return unsettled
 }
@@ -0,0 +1,308 @@
+// Copyright(C) 2026 github.com/fsgo  All Rights Reserved.
+// Author: hidu <duv123@gmail.com>
+// Date: 2026/10/19
+
+package fsfs
+
+import (
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+
+	"github.com/fsgo/fsgo/internal/xinotify"
+)
+
+// pendingEvent 有事件，等待 delay 后回调
+type pendingEvent struct {
+	at time.Time
+
+	// oldName rename 前的文件名
+	oldName string
+}
+
+// pendingMove 还没有配对的 rename
+type pendingMove struct {
+	at time.Time
+
+	// name 原文件名
+	name string
+}
+
+// notifyBackend 使用 inotify 实现的 Watcher
+//
+// 所有的事件处理和回调都在同一个 goroutine 中
+type notifyBackend struct {
+	w     *Watcher
+	nw    *xinotify.Watcher
+	dirs  map[string]int          // 已监听的目录 -> wd
+	moves map[uint32]*pendingMove // rename 的 cookie -> 原文件
+	done  chan struct{}
+	exit  chan struct{}
+
+	// known 已添加监听的规则数，之后的是 Start 之后添加的
+	known int
+
+	// calling 是否正在执行回调
+	calling atomic.Bool
+}
+
+const notifyOps = xinotify.OpCreate | xinotify.OpCloseWrite | xinotify.OpWrite | xinotify.OpRemove |
+	xinotify.OpMoveFrom | xinotify.OpMoveTo | xinotify.OpChmod | xinotify.OpSelfRemove
+
+func newNotifyBackend(w *Watcher) (*notifyBackend, error) {
+	nw, err := xinotify.New()
+	if err != nil {
+		return nil, err
+	}
+	nb := &notifyBackend{
+		w:     w,
+		nw:    nw,
+		dirs:  map[string]int{},
+		moves: map[uint32]*pendingMove{},
+		done:  make(chan struct{}),
+		exit:  make(chan struct{}),
+	}
+	nb.addRules()
+	go nb.loop()
+	return nb, nil
+}
+
+// Stop 停止并等待事件处理的 goroutine 退出
+//
+// 在回调中调用时（如回调中调用了 Watcher.Stop），不会等待，当前的回调结束后 goroutine 就会退出
+func (nb *notifyBackend) Stop() {
+	close(nb.done)
+	_ = nb.nw.Close()
+	if !nb.calling.Load() {
+		<-nb.exit
+	}
+}
+
+func (nb *notifyBackend) stopped() bool {
+	select {
+	case <-nb.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// addRules 添加对新规则的监听，规则只会追加，所以 known 之后的都是新添加的
+func (nb *notifyBackend) addRules() {
+	rules := nb.w.getRules()
+	for _, rule := range rules[nb.known:] {
+		nb.rescan(rule)
+	}
+	nb.known = len(rules)
+}
+
+// ruleDirs 规则需要监听的目录
+func (nb *notifyBackend) ruleDirs(rule *watchRule) []string {
+	if !rule.recursive {
+		dirs, _ := filepath.Glob(filepath.Dir(rule.Name))
+		return dirs
+	}
+	var dirs []string
+	_ = filepath.WalkDir(rule.Name, func(path string, d fs.DirEntry, err error) error {
+		if err == nil && d.IsDir() {
+			dirs = append(dirs, path)
+		}
+		return nil
+	})
+	return dirs
+}
+
+// rescan 添加对规则目录的监听并重新扫描所有文件
+func (nb *notifyBackend) rescan(rule *watchRule) {
+	var watched int
+	for _, dir := range nb.ruleDirs(rule) {
+		if _, has := nb.dirs[dir]; has {
+			watched++
+			continue
+		}
+		wd, err := nb.nw.Add(dir, notifyOps)
+		if err != nil {
+			log.Printf("[fsgo] fsfs.Watcher inotify add %q failed: %v\n", dir, err)
+			continue
+		}
+		nb.dirs[dir] = wd
+		watched++
+	}
+	// 目录还不存在等情况，使用轮询
+	rule.poll = watched == 0
+
+	// 先添加监听再扫描，以避免遗漏扫描过程中的变化
+	now := time.Now()
+	for _, name := range rule.scan() {
+		rule.pending[name] = &pendingEvent{at: now}
+	}
+}
+
+func (nb *notifyBackend) loop() {
+	defer close(nb.exit)
+	defer func() {
+		if re := recover(); re != nil {
+			log.Println("[fsgo] fsfs.Watcher.notify panic:", re)
+		}
+	}()
+
+	tick := time.Second
+	for _, rule := range nb.w.getRules() {
+		tick = min(tick, rule.delay/4)
+	}
+	tk := time.NewTicker(max(tick, 10*time.Millisecond))
+	defer tk.Stop()
+
+	rulesAdded := nb.w.getRulesAdded()
+	lastPoll := time.Now()
+	for {
+		select {
+		case <-nb.done:
+			return
+		case err := <-nb.nw.Errors():
+			log.Println("[fsgo] fsfs.Watcher inotify read failed:", err)
+			return
+		case event, ok := <-nb.nw.Events():
+			if !ok {
+				return
+			}
+			nb.onEvent(event)
+		case <-rulesAdded:
+			nb.addRules()
+		case <-tk.C:
+			if time.Since(lastPoll) >= nb.w.getInterval() {
+				lastPoll = time.Now()
+				for _, rule := range nb.w.getRules() {
+					if rule.poll {
+						nb.rescan(rule)
+					}
+				}
+			}
+			nb.flush()
+		}
+	}
+}
+
+func (nb *notifyBackend) onEvent(event xinotify.Event) {
+	if event.Op.Has(xinotify.OpOverflow) {
+		// 有事件丢失，需要全量扫描
+		for _, rule := range nb.w.getRules() {
+			nb.rescan(rule)
+		}
+		return
+	}
+	if event.Op.Has(xinotify.OpIgnored) {
+		for dir, wd := range nb.dirs {
+			if wd == event.WD {
+				delete(nb.dirs, dir)
+			}
+		}
+		// 被监听的目录已不存在，重新扫描，目录不存在的规则会改为轮询
+		for _, rule := range nb.w.getRules() {
+			nb.rescan(rule)
+		}
+		return
+	}
+	if event.Op.Has(xinotify.OpSelfRemove) {
+		return
+	}
+
+	if event.IsDir {
+		// 子目录有变化，对于递归监听的规则，需要监听新的目录，并扫描其中的文件
+		for _, rule := range nb.w.getRules() {
+			if rule.recursive && (rule.match(event.Path) || rule.Name == event.Path) {
+				nb.rescan(rule)
+			}
+		}
+		return
+	}
+
+	now := time.Now()
+	var from string
+	if event.Op.Has(xinotify.OpMoveFrom) {
+		nb.moves[event.Cookie] = &pendingMove{name: event.Path, at: now}
+	} else if event.Op.Has(xinotify.OpMoveTo) {
+		if mv := nb.moves[event.Cookie]; mv != nil {
+			from = mv.name
+			delete(nb.moves, event.Cookie)
+		}
+	}
+
+	for _, rule := range nb.w.getRules() {
+		if !rule.match(event.Path) {
+			continue
+		}
+		pe := rule.pending[event.Path]
+		if pe == nil {
+			pe = &pendingEvent{}
+			rule.pending[event.Path] = pe
+		}
+		pe.at = now
+		if len(from) > 0 && rule.match(from) {
+			delete(rule.pending, from)
+			pe.oldName = from
+		}
+	}
+}
+
+// flush 回调已经超过 delay 时长没有新事件的文件
+func (nb *notifyBackend) flush() {
+	now := time.Now()
+	var maxDelay time.Duration
+	for _, rule := range nb.w.getRules() {
+		maxDelay = max(maxDelay, rule.delay)
+		for name, pe := range rule.pending {
+			if now.Sub(pe.at) < rule.delay {
+				continue
+			}
+			delete(rule.pending, name)
+			nb.flushFile(rule, name, pe)
+			if nb.stopped() {
+				return
+			}
+		}
+	}
+	// MOVE_FROM 和 MOVE_TO 可能不在同一次读取中，所以至少保留 delay 时长
+	// 超时还没有配对的 rename（如移出了监听的目录），原文件已作为删除处理
+	for cookie, mv := range nb.moves {
+		if now.Sub(mv.at) >= maxDelay {
+			delete(nb.moves, cookie)
+		}
+	}
+}
+
+func (nb *notifyBackend) callback(rule *watchRule, event WatcherEvent) {
+	nb.calling.Store(true)
+	defer nb.calling.Store(false)
+	rule.CallBack(event)
+}
+
+func (nb *notifyBackend) flushFile(rule *watchRule, name string, pe *pendingEvent) {
+	if rule.last == nil {
+		rule.last = map[string]time.Time{}
+	}
+	info, err := os.Stat(name)
+	if err != nil || info.IsDir() {
+		for _, fn := range []string{pe.oldName, name} {
+			if _, has := rule.last[fn]; has && len(fn) > 0 {
+				delete(rule.last, fn)
+				nb.callback(rule, WatcherEvent{FileName: fn, Type: WatcherEventDelete})
+			}
+		}
+		return
+	}
+
+	rule.last[name] = info.ModTime()
+	if len(pe.oldName) > 0 {
+		if _, hasOld := rule.last[pe.oldName]; hasOld {
+			delete(rule.last, pe.oldName)
+			nb.callback(rule, WatcherEvent{FileName: name, OldName: pe.oldName, Type: WatcherEventRename})
+			return
+		}
+	}
+	// inotify 的写入等事件，即使 mtime 没有变化（如被还原），也需要回调
+	nb.callback(rule, WatcherEvent{FileName: name, Type: WatcherEventUpdate})
+}
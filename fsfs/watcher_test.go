@@ -0,0 +1,192 @@
+// Copyright(C) 2026 github.com/fsgo  All Rights Reserved.
+// Author: hidu <duv123@gmail.com>
+// Date: 2026/10/19
+
+package fsfs
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fsgo/fst"
+
+	"github.com/fsgo/fsgo/internal/xinotify"
+)
+
+type watcherEvents struct {
+	list []WatcherEvent
+	mux  sync.Mutex
+}
+
+func (we *watcherEvents) add(e WatcherEvent) {
+	we.mux.Lock()
+	defer we.mux.Unlock()
+	we.list = append(we.list, e)
+}
+
+func (we *watcherEvents) take() []WatcherEvent {
+	we.mux.Lock()
+	defer we.mux.Unlock()
+	list := we.list
+	we.list = nil
+	return list
+}
+
+func testWatcher(t *testing.T, backend WatcherBackend) {
+	dir := t.TempDir()
+	w := &Watcher{
+		Interval: 10 * time.Millisecond,
+		Delay:    50 * time.Millisecond,
+		Backend:  backend,
+	}
+	var files, all watcherEvents
+	w.Watch(filepath.Join(dir, "*.txt"), files.add)
+	w.WatchDir(dir, all.add)
+	fst.NoError(t, w.Start())
+	defer w.Stop()
+
+	wait := func() {
+		time.Sleep(300 * time.Millisecond)
+	}
+
+	a := filepath.Join(dir, "a.txt")
+	fst.NoError(t, os.WriteFile(a, []byte("hello"), 0644))
+	fst.NoError(t, os.WriteFile(a, []byte("hello world"), 0644))
+	wait()
+	fst.Equal(t, []WatcherEvent{{FileName: a, Type: WatcherEventUpdate}}, files.take())
+
+	sub := filepath.Join(dir, "sub", "b.log")
+	fst.NoError(t, os.MkdirAll(filepath.Dir(sub), 0755))
+	fst.NoError(t, os.WriteFile(sub, []byte("hello"), 0644))
+	wait()
+	fst.Empty(t, files.take())
+	fst.Equal(t, []WatcherEvent{
+		{FileName: a, Type: WatcherEventUpdate},
+		{FileName: sub, Type: WatcherEventUpdate},
+	}, all.take())
+
+	b := filepath.Join(dir, "b.txt")
+	fst.NoError(t, os.Rename(a, b))
+	wait()
+	if backend == WatcherBackendInotify {
+		fst.Equal(t, []WatcherEvent{{FileName: b, OldName: a, Type: WatcherEventRename}}, files.take())
+	} else {
+		fst.Len(t, files.take(), 2)
+	}
+	all.take()
+
+	fst.NoError(t, os.Remove(b))
+	wait()
+	fst.Equal(t, []WatcherEvent{{FileName: b, Type: WatcherEventDelete}}, files.take())
+	all.take()
+
+	// Start 之后添加的规则
+	var mds watcherEvents
+	c := filepath.Join(dir, "c.md")
+	w.Watch(filepath.Join(dir, "*.md"), mds.add)
+	fst.NoError(t, os.WriteFile(c, []byte("hello"), 0644))
+	wait()
+	fst.Equal(t, []WatcherEvent{{FileName: c, Type: WatcherEventUpdate}}, mds.take())
+
+	if backend != WatcherBackendInotify {
+		return
+	}
+	// 文件内容有变化，但 mtime 被还原
+	info, err := os.Stat(c)
+	fst.NoError(t, err)
+	all.take()
+	fst.NoError(t, os.WriteFile(c, []byte("hello world"), 0644))
+	fst.NoError(t, os.Chtimes(c, info.ModTime(), info.ModTime()))
+	wait()
+	fst.Equal(t, []WatcherEvent{{FileName: c, Type: WatcherEventUpdate}}, mds.take())
+	fst.Equal(t, []WatcherEvent{{FileName: c, Type: WatcherEventUpdate}}, all.take())
+}
+
+func TestWatcher(t *testing.T) {
+	t.Run("default", func(t *testing.T) {
+		w := &Watcher{}
+		fst.NoError(t, w.Start())
+		defer w.Stop()
+		fst.Nil(t, w.notify)
+		fst.NotNil(t, w.timer)
+	})
+	t.Run("poll", func(t *testing.T) {
+		testWatcher(t, WatcherBackendPoll)
+	})
+	if runtime.GOOS == "linux" {
+		t.Run("inotify", func(t *testing.T) {
+			testWatcher(t, WatcherBackendInotify)
+		})
+		t.Run("callback", func(t *testing.T) {
+			testWatcherCallback(t)
+		})
+	}
+}
+
+// testWatcherCallback 在回调中调用 Watch 和 Stop 不会死锁
+func testWatcherCallback(t *testing.T) {
+	dir := t.TempDir()
+	w := &Watcher{
+		Delay:   20 * time.Millisecond,
+		Backend: WatcherBackendInotify,
+	}
+	var mds watcherEvents
+	stopped := make(chan struct{})
+	w.Watch(filepath.Join(dir, "*.txt"), func(event WatcherEvent) {
+		w.Watch(filepath.Join(dir, "*.md"), mds.add)
+		if filepath.Base(event.FileName) == "stop.txt" {
+			w.Stop()
+			close(stopped)
+		}
+	})
+	fst.NoError(t, w.Start())
+	defer w.Stop()
+
+	fst.NoError(t, os.WriteFile(filepath.Join(dir, "a.txt"), []byte("a"), 0644))
+	time.Sleep(100 * time.Millisecond)
+	c := filepath.Join(dir, "c.md")
+	fst.NoError(t, os.WriteFile(c, []byte("c"), 0644))
+	time.Sleep(100 * time.Millisecond)
+	fst.Equal(t, []WatcherEvent{{FileName: c, Type: WatcherEventUpdate}}, mds.take())
+
+	fst.NoError(t, os.WriteFile(filepath.Join(dir, "stop.txt"), []byte("s"), 0644))
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("Stop in callback blocked")
+	}
+}
+
+func TestNotifyBackend_splitRename(t *testing.T) {
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a.txt")
+	b := filepath.Join(dir, "b.txt")
+	w := &Watcher{Delay: 50 * time.Millisecond}
+	var events watcherEvents
+	w.Watch(filepath.Join(dir, "*.txt"), events.add)
+	nb := &notifyBackend{
+		w:     w,
+		moves: map[uint32]*pendingMove{},
+		done:  make(chan struct{}),
+	}
+	rule := w.getRules()[0]
+	rule.last = map[string]time.Time{a: {}}
+
+	fst.NoError(t, os.WriteFile(b, []byte("b"), 0644))
+	// MOVE_FROM 和 MOVE_TO 之间有一次 flush
+	nb.onEvent(xinotify.Event{Path: a, Op: xinotify.OpMoveFrom, Cookie: 1})
+	nb.flush()
+	time.Sleep(10 * time.Millisecond)
+	nb.onEvent(xinotify.Event{Path: b, Op: xinotify.OpMoveTo, Cookie: 1})
+	nb.flush()
+	fst.Empty(t, events.take())
+
+	time.Sleep(60 * time.Millisecond)
+	nb.flush()
+	fst.Equal(t, []WatcherEvent{{FileName: b, OldName: a, Type: WatcherEventRename}}, events.take())
+	fst.Empty(t, nb.moves)
+}
@@ -5,17 +5,36 @@
 package fsio
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/fsgo/fsgo/fssync/fsatomic"
 )
 
 var _ io.WriteCloser = (*AsyncWriter)(nil)
 
+// OverflowPolicy 异步队列满时的处理策略
+type OverflowPolicy uint8
+
+const (
+	// OverflowBlock 阻塞等待，直到队列有空闲，默认策略
+	OverflowBlock OverflowPolicy = iota
+
+	// OverflowDropNewest 丢弃当前写入的数据，Write 返回 ErrDropped
+	OverflowDropNewest
+
+	// OverflowDropOldest 丢弃队列中最早的数据，以写入当前的数据
+	OverflowDropOldest
+)
+
+// ErrDropped 队列已满，数据被丢弃
+var ErrDropped = errors.New("async queue is full, dropped")
+
 // AsyncWriter 异步化的 writer
 type AsyncWriter struct {
 	// Writer 实际 writer，必填
@@ -23,20 +42,64 @@ type AsyncWriter struct {
 
 	writeStats fsatomic.Value[WriteStatus] // 最新一条写入状态
 	buffers    chan []byte                 // 异步数据
+	flushReq   chan chan error             // Flush 请求
 	loopExit   chan bool                   // 异步写完成后的事件
+	batch      []byte                      // 合并写入时的缓存
+
+	stats asyncStats
 
 	// ChanSize 异步队列大小，可选
 	// 默认为 1024。当值为 -1 时，chanSize=0，即变为同步
 	ChanSize int
 
+	// BatchSize 合并写入的最大字节数，可选
+	// 当 > 0 时，会将队列中的多个小的数据合并为一次写入
+	BatchSize int
+
+	// FlushInterval 定期调用 Writer 的 Flush 方法的间隔，可选，默认不定期 Flush
+	FlushInterval time.Duration
+
 	once    sync.Once   // 用于初始化
 	initMux sync.Mutex  // 初始化时的锁
 	closed  atomic.Bool // 是否已经调用过 Close
+	inited  atomic.Bool // 是否已经初始化
+
+	// Overflow 队列满时的处理策略，可选，默认为 OverflowBlock
+	Overflow OverflowPolicy
 
 	// NeedStatus 是否需要 write 的状态
 	NeedStatus bool
 }
 
+// AsyncWriterStats AsyncWriter 的统计信息
+type AsyncWriterStats struct {
+	// Queued 当前在队列中等待写入的数据条数
+	Queued int
+
+	// Written 已写入 Writer 的数据条数和字节数
+	Written      int64
+	WrittenBytes int64
+
+	// Dropped 因队列满被丢弃的数据条数和字节数
+	Dropped      int64
+	DroppedBytes int64
+
+	// Batches 调用 Writer.Write 的次数
+	Batches int64
+
+	// Flushes 调用 Writer.Flush 的次数
+	Flushes int64
+}
+
+type asyncStats struct {
+	written      atomic.Int64
+	writtenBytes atomic.Int64
+	dropped      atomic.Int64
+	droppedBytes atomic.Int64
+	batches      atomic.Int64
+	flushes      atomic.Int64
+}
+
 var errClosed = errors.New("already closed")
 
 func (aw *AsyncWriter) getChanSize() int {
@@ -60,20 +123,73 @@ func (aw *AsyncWriter) Write(p []byte) (n int, err error) {
 	bf := make([]byte, 0, len(p))
 	bf = append(bf, p...)
 
-	select {
-	case aw.buffers <- bf:
-		return len(p), nil
-	case <-aw.loopExit:
-		return 0, errClosed
+	switch aw.Overflow {
+	case OverflowDropNewest:
+		select {
+		case aw.buffers <- bf:
+			return len(p), nil
+		case <-aw.loopExit:
+			return 0, errClosed
+		default:
+			aw.onDrop(bf)
+			return 0, ErrDropped
+		}
+	case OverflowDropOldest:
+		return aw.writeDropOldest(bf)
+	default:
+		select {
+		case aw.buffers <- bf:
+			return len(p), nil
+		case <-aw.loopExit:
+			return 0, errClosed
+		}
+	}
+}
+
+func (aw *AsyncWriter) writeDropOldest(bf []byte) (int, error) {
+	if cap(aw.buffers) == 0 {
+		// 同步模式下，没有可丢弃的数据
+		select {
+		case aw.buffers <- bf:
+			return len(bf), nil
+		case <-aw.loopExit:
+			return 0, errClosed
+		}
+	}
+	for {
+		select {
+		case aw.buffers <- bf:
+			return len(bf), nil
+		case <-aw.loopExit:
+			return 0, errClosed
+		default:
+		}
+		select {
+		case old := <-aw.buffers:
+			if old == nil {
+				// 是 Close 发送的结束标记，放回去
+				aw.buffers <- old
+				return 0, errClosed
+			}
+			aw.onDrop(old)
+		default:
+		}
 	}
 }
 
+func (aw *AsyncWriter) onDrop(b []byte) {
+	aw.stats.dropped.Add(1)
+	aw.stats.droppedBytes.Add(int64(len(b)))
+}
+
 func (aw *AsyncWriter) init() {
 	aw.initMux.Lock()
 	defer aw.initMux.Unlock()
 
 	aw.loopExit = make(chan bool)
+	aw.flushReq = make(chan chan error)
 	aw.buffers = make(chan []byte, aw.getChanSize())
+	aw.inited.Store(true)
 	go func() {
 		defer func() {
 			if re := recover(); re != nil {
@@ -107,18 +223,116 @@ func (aw *AsyncWriter) doLoop() {
 		}
 	}()
 
-	for b := range aw.buffers {
-		if b == nil {
-			break
+	var tick <-chan time.Time
+	if aw.FlushInterval > 0 {
+		ticker := time.NewTicker(aw.FlushInterval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case b := <-aw.buffers:
+			if b == nil || aw.writeBatch(b) {
+				aw.flush()
+				return
+			}
+		case ch := <-aw.flushReq:
+			exit, err := aw.drainAndFlush()
+			ch <- err
+			if exit {
+				return
+			}
+		case <-tick:
+			aw.flush()
 		}
-		n, err := aw.Writer.Write(b)
-		if aw.NeedStatus {
-			s := WriteStatus{
-				Wrote: n,
-				Err:   err,
+	}
+}
+
+// writeBatch 写入数据，若有设置 BatchSize，会合并队列中已有的数据一起写入
+// 若读取到了结束标记，返回 true
+func (aw *AsyncWriter) writeBatch(b []byte) (exit bool) {
+	if aw.BatchSize <= len(b) {
+		aw.write(b, 1)
+		return false
+	}
+	aw.batch = append(aw.batch[:0], b...)
+	count := 1
+	for len(aw.batch) < aw.BatchSize && !exit {
+		select {
+		case nb := <-aw.buffers:
+			if nb == nil {
+				exit = true
+			} else {
+				aw.batch = append(aw.batch, nb...)
+				count++
 			}
-			aw.writeStats.Store(s)
+		default:
+			aw.write(aw.batch, count)
+			return false
+		}
+	}
+	aw.write(aw.batch, count)
+	return exit
+}
+
+func (aw *AsyncWriter) write(b []byte, count int) {
+	n, err := aw.Writer.Write(b)
+	aw.stats.batches.Add(1)
+	aw.stats.written.Add(int64(count))
+	aw.stats.writtenBytes.Add(int64(n))
+	if aw.NeedStatus {
+		s := WriteStatus{
+			Wrote: n,
+			Err:   err,
 		}
+		aw.writeStats.Store(s)
+	}
+}
+
+// drainAndFlush 将队列中已有的数据全部写入后，再 Flush
+// 若读取到了 Close 发送的结束标记，exit 为 true
+//
+// 结束标记不能放回队列：并发的 Write 可能已经占满了队列，放回时会一直阻塞
+func (aw *AsyncWriter) drainAndFlush() (exit bool, err error) {
+	for n := len(aw.buffers); n > 0; n-- {
+		b := <-aw.buffers
+		if b == nil {
+			exit = true
+			break
+		}
+		aw.write(b, 1)
+	}
+	return exit, aw.flush()
+}
+
+func (aw *AsyncWriter) flush() error {
+	if _, ok := aw.Writer.(Flusher); !ok {
+		return nil
+	}
+	aw.stats.flushes.Add(1)
+	return TryFlush(aw.Writer)
+}
+
+// Flush 等待已调用 Write 的数据都写入 Writer，并调用 Writer 的 Flush 方法（若有）
+func (aw *AsyncWriter) Flush(ctx context.Context) error {
+	if aw.closed.Load() {
+		return errClosed
+	}
+	aw.once.Do(aw.init)
+	ch := make(chan error, 1)
+	select {
+	case aw.flushReq <- ch:
+	case <-aw.loopExit:
+		return errClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	select {
+	case err := <-ch:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
 	}
 }
 
@@ -127,6 +341,27 @@ func (aw *AsyncWriter) LastWriteStatus() WriteStatus {
 	return aw.writeStats.Load()
 }
 
+// Stats 返回统计信息
+func (aw *AsyncWriter) Stats() AsyncWriterStats {
+	return AsyncWriterStats{
+		Queued:       len(aw.getBuffers()),
+		Written:      aw.stats.written.Load(),
+		WrittenBytes: aw.stats.writtenBytes.Load(),
+		Dropped:      aw.stats.dropped.Load(),
+		DroppedBytes: aw.stats.droppedBytes.Load(),
+		Batches:      aw.stats.batches.Load(),
+		Flushes:      aw.stats.flushes.Load(),
+	}
+}
+
+// getBuffers 不能使用 initMux，Close 会持有 initMux 等待异步写完成
+func (aw *AsyncWriter) getBuffers() chan []byte {
+	if !aw.inited.Load() {
+		return nil
+	}
+	return aw.buffers
+}
+
 // Close 关闭
 func (aw *AsyncWriter) Close() error {
 	if aw.closed.CompareAndSwap(false, true) {
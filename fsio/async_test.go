@@ -6,9 +6,12 @@ package fsio
 
 import (
 	"bytes"
+	"context"
 	"io"
 	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/fsgo/fst"
 )
@@ -72,4 +75,146 @@ func TestAsyncWriter(t *testing.T) {
 		}()
 		wg.Wait()
 	})
+	t.Run("drop newest", func(t *testing.T) {
+		w := &blockWriter{unblock: make(chan struct{})}
+		aw := &AsyncWriter{
+			Writer:   w,
+			ChanSize: 2,
+			Overflow: OverflowDropNewest,
+		}
+		var dropped int
+		for i := 0; i < 10; i++ {
+			if _, err := aw.Write([]byte("abc")); err != nil {
+				fst.Equal(t, ErrDropped, err)
+				dropped++
+			}
+		}
+		fst.True(t, dropped >= 7)
+		st := aw.Stats()
+		fst.Equal(t, int64(dropped), st.Dropped)
+		fst.Equal(t, int64(dropped*3), st.DroppedBytes)
+		close(w.unblock)
+		fst.NoError(t, aw.Close())
+		fst.Equal(t, 10-dropped, w.count())
+	})
+
+	t.Run("drop oldest", func(t *testing.T) {
+		w := &blockWriter{unblock: make(chan struct{})}
+		aw := &AsyncWriter{
+			Writer:   w,
+			ChanSize: 2,
+			Overflow: OverflowDropOldest,
+		}
+		for i := 0; i < 10; i++ {
+			_, err := aw.Write([]byte{byte('0' + i)})
+			fst.NoError(t, err)
+		}
+		fst.True(t, aw.Stats().Dropped >= 7)
+		close(w.unblock)
+		fst.NoError(t, aw.Close())
+		fst.Contains(t, w.String(), "89")
+	})
+
+	t.Run("batch and flush", func(t *testing.T) {
+		w := &blockWriter{unblock: make(chan struct{})}
+		close(w.unblock)
+		aw := &AsyncWriter{
+			Writer:    w,
+			BatchSize: 1024,
+		}
+		for i := 0; i < 100; i++ {
+			_, err := aw.Write([]byte("H"))
+			fst.NoError(t, err)
+		}
+		fst.NoError(t, aw.Flush(context.Background()))
+		fst.Equal(t, 100, len(w.String()))
+		st := aw.Stats()
+		fst.Equal(t, int64(100), st.Written)
+		fst.Equal(t, int64(100), st.WrittenBytes)
+		fst.True(t, st.Batches <= 100)
+		fst.True(t, w.flushes.Load() >= 1)
+		fst.NoError(t, aw.Close())
+		fst.Error(t, aw.Flush(context.Background()))
+	})
+
+	t.Run("flush while closing", func(t *testing.T) {
+		for i := 0; i < 50; i++ {
+			w := &blockWriter{unblock: make(chan struct{})}
+			close(w.unblock)
+			aw := &AsyncWriter{
+				Writer:   w,
+				ChanSize: 2,
+			}
+			var wg sync.WaitGroup
+			for j := 0; j < 4; j++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for k := 0; k < 100; k++ {
+						_, _ = aw.Write([]byte("H"))
+						_ = aw.Flush(context.Background())
+						_ = aw.Stats()
+					}
+				}()
+			}
+			done := make(chan struct{})
+			go func() {
+				_ = aw.Close()
+				wg.Wait()
+				close(done)
+			}()
+			select {
+			case <-done:
+			case <-time.After(5 * time.Second):
+				t.Fatal("Close timeout")
+			}
+		}
+	})
+
+	t.Run("flush interval", func(t *testing.T) {
+		w := &blockWriter{unblock: make(chan struct{})}
+		close(w.unblock)
+		aw := &AsyncWriter{
+			Writer:        w,
+			FlushInterval: 10 * time.Millisecond,
+		}
+		_, err := aw.Write([]byte("H"))
+		fst.NoError(t, err)
+		time.Sleep(50 * time.Millisecond)
+		fst.True(t, w.flushes.Load() >= 2)
+		fst.NoError(t, aw.Close())
+	})
+}
+
+type blockWriter struct {
+	unblock chan struct{}
+	buf     bytes.Buffer
+	writes  int
+	flushes atomic.Int64
+	mux     sync.Mutex
+}
+
+func (w *blockWriter) Write(p []byte) (int, error) {
+	<-w.unblock
+	w.mux.Lock()
+	defer w.mux.Unlock()
+	w.writes++
+	return w.buf.Write(p)
+}
+
+func (w *blockWriter) Flush() error {
+	w.flushes.Add(1)
+	return nil
+}
+
+func (w *blockWriter) count() int {
+	w.mux.Lock()
+	defer w.mux.Unlock()
+	return w.writes
+}
+
+func (w *blockWriter) String() string {
+	w.mux.Lock()
+	defer w.mux.Unlock()
+	return w.buf.String()
 }
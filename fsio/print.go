@@ -16,17 +16,27 @@ import (
 
 var _ io.Writer = (*PrintByteWriter)(nil)
 
+// PrintByteWriter 将写入的数据格式化后输出，用于调试
 type PrintByteWriter struct {
 	Name string
 
 	// Out 实际输出目标
 	Out io.Writer
 
+	// Format 输出格式，可选，默认为 PrintFormatDefault
+	Format PrintFormat
+
 	// LineMax 单行最大字符数，可选，默认 40
 	LineMax int
 
 	id  atomic.Int64
 	mux sync.Mutex
+
+	// Direction 数据的方向，可选，用于在输出的首行添加方向标记
+	Direction PrintDirection
+
+	// Color 是否使用彩色输出方向标记
+	Color bool
 }
 
 func (pb *PrintByteWriter) getLineMax() int {
@@ -55,7 +65,24 @@ func (pb *PrintByteWriter) WriteWithMeta(p []byte, meta string) (n int, err erro
 	maxLen := pb.getLineMax()
 
 	bf := &bytes.Buffer{}
+	bf.WriteString(pb.Direction.marker(pb.Color))
 	fmt.Fprintf(bf, "[%s][%d][Len=%d] %s %s\n", pb.Name, pb.id.Add(1), len(p), meta, time.Now().Format(time.DateTime+".99999"))
+
+	switch pb.Format {
+	case PrintFormatHexdump:
+		writeHexdump(bf, p)
+	case PrintFormatQuote:
+		writeQuoted(bf, p, maxLen)
+	case PrintFormatProtocol:
+		writeProtocol(bf, p, maxLen)
+	default:
+		pb.writeDefault(bf, p, maxLen)
+	}
+	_, err = pb.getOut().Write(bf.Bytes())
+	return total, err
+}
+
+func (pb *PrintByteWriter) writeDefault(bf *bytes.Buffer, p []byte, maxLen int) {
 	lineNo := -1
 	startIndex := 0
 	for len(p) > 0 {
@@ -70,8 +97,6 @@ func (pb *PrintByteWriter) WriteWithMeta(p []byte, meta string) (n int, err erro
 		p = p[end:]
 		startIndex += end
 	}
-	_, err = pb.getOut().Write(bf.Bytes())
-	return total, err
 }
 
 func (pb *PrintByteWriter) format(bf []byte) []string {
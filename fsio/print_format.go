@@ -0,0 +1,246 @@
+// Copyright(C) 2026 github.com/fsgo  All Rights Reserved.
+// Author: hidu <duv123@gmail.com>
+// Date: 2026/10/19
+
+package fsio
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+)
+
+// PrintFormat PrintByteWriter 的输出格式
+type PrintFormat string
+
+const (
+	// PrintFormatDefault 默认格式，每行输出转义后的字符串和每个字节的十进制值
+	PrintFormatDefault PrintFormat = ""
+
+	// PrintFormatHexdump 和 hexdump -C 相同的格式
+	PrintFormatHexdump PrintFormat = "hexdump"
+
+	// PrintFormatQuote 按行输出 Go 转义后的字符串
+	PrintFormatQuote PrintFormat = "quote"
+
+	// PrintFormatProtocol 识别 HTTP/1.x、Redis RESP 以及使用 RegisterProtocolPrinter 注册的协议，
+	// 并输出结构化的内容，不能识别时使用 hexdump 格式
+	PrintFormatProtocol PrintFormat = "protocol"
+)
+
+// ParsePrintFormat 解析输出格式的名称，不支持时返回错误
+func ParsePrintFormat(name string) (PrintFormat, error) {
+	switch f := PrintFormat(name); f {
+	case PrintFormatDefault, PrintFormatHexdump, PrintFormatQuote, PrintFormatProtocol:
+		return f, nil
+	default:
+		return "", fmt.Errorf("not support PrintFormat %q", name)
+	}
+}
+
+// PrintDirection 数据的方向
+type PrintDirection uint8
+
+const (
+	// PrintDirectionNone 未知，不输出方向标记
+	PrintDirectionNone PrintDirection = iota
+
+	// PrintDirectionRead 读取的数据，标记为 "<<"
+	PrintDirectionRead
+
+	// PrintDirectionWrite 写出的数据，标记为 ">>"
+	PrintDirectionWrite
+)
+
+const (
+	colorReset = "\033[0m"
+	colorRead  = "\033[32m"
+	colorWrite = "\033[33m"
+)
+
+func (d PrintDirection) marker(color bool) string {
+	var mk, c string
+	switch d {
+	case PrintDirectionRead:
+		mk, c = "<<", colorRead
+	case PrintDirectionWrite:
+		mk, c = ">>", colorWrite
+	default:
+		return ""
+	}
+	if color {
+		return c + mk + colorReset + " "
+	}
+	return mk + " "
+}
+
+func writeHexdump(bf *bytes.Buffer, p []byte) {
+	if len(p) == 0 {
+		return
+	}
+	bf.WriteString(hex.Dump(p))
+	fmt.Fprintf(bf, "%08x\n", len(p))
+}
+
+// writeQuoted 按行输出转义后的内容，每行不超过 maxLen 个字节
+func writeQuoted(bf *bytes.Buffer, p []byte, maxLen int) {
+	for len(p) > 0 {
+		end := bytes.IndexByte(p, '\n') + 1
+		if end <= 0 || end > maxLen {
+			end = min(len(p), maxLen)
+		}
+		bf.WriteString(strconv.Quote(string(p[:end])))
+		bf.WriteByte('\n')
+		p = p[end:]
+	}
+}
+
+// ProtocolPrinter PrintFormatProtocol 格式下，识别并输出一种协议的内容
+type ProtocolPrinter struct {
+	// Name 协议名称，必填
+	Name string
+
+	// Print 必填，识别 p 并将结构化的内容写入 w，不能识别时返回 false，此时写入的内容会被丢弃
+	Print func(w io.Writer, p []byte) bool
+}
+
+var (
+	protocolPrinters    []*ProtocolPrinter
+	protocolPrintersMux sync.RWMutex
+)
+
+// RegisterProtocolPrinter 注册 PrintFormatProtocol 格式可以识别的协议（如 fsrpc），
+// 在内置的 HTTP/1.x 和 Redis RESP 之后按照注册的顺序检测，若和已有的重名，会覆盖掉
+func RegisterProtocolPrinter(pp *ProtocolPrinter) error {
+	if len(pp.Name) == 0 || pp.Print == nil {
+		return fmt.Errorf("invalid ProtocolPrinter: %v", pp)
+	}
+	protocolPrintersMux.Lock()
+	defer protocolPrintersMux.Unlock()
+	for i, item := range protocolPrinters {
+		if item.Name == pp.Name {
+			protocolPrinters[i] = pp
+			return nil
+		}
+	}
+	protocolPrinters = append(protocolPrinters, pp)
+	return nil
+}
+
+func writeProtocol(bf *bytes.Buffer, p []byte, maxLen int) {
+	if writeHTTP(bf, p, maxLen) || writeRESP(bf, p) {
+		return
+	}
+	protocolPrintersMux.RLock()
+	printers := protocolPrinters
+	protocolPrintersMux.RUnlock()
+	out := &bytes.Buffer{}
+	for _, pp := range printers {
+		out.Reset()
+		if pp.Print(out, p) {
+			bf.Write(out.Bytes())
+			return
+		}
+	}
+	writeHexdump(bf, p)
+}
+
+var httpMethods = []string{"GET", "POST", "PUT", "DELETE", "HEAD", "OPTIONS", "PATCH", "CONNECT", "TRACE"}
+
+// IsHTTPStart 判断 b 是否是 HTTP/1.x 请求或者响应的开头
+func IsHTTPStart(b []byte) bool {
+	if bytes.HasPrefix(b, []byte("HTTP/1.")) {
+		return true
+	}
+	for _, m := range httpMethods {
+		if bytes.HasPrefix(b, []byte(m+" ")) {
+			return true
+		}
+	}
+	return false
+}
+
+// writeHTTP 输出 HTTP/1.x 的请求或者响应
+func writeHTTP(bf *bytes.Buffer, p []byte, maxLen int) bool {
+	idx := bytes.Index(p, []byte("\r\n"))
+	if idx < 0 || !IsHTTPStart(p[:idx]) {
+		return false
+	}
+	if line := p[:idx]; !bytes.HasPrefix(line, []byte("HTTP/")) && !bytes.Contains(line, []byte(" HTTP/1.")) {
+		return false
+	}
+	head, body, found := bytes.Cut(p, []byte("\r\n\r\n"))
+	lines := bytes.Split(head, []byte("\r\n"))
+	if bytes.HasPrefix(lines[0], []byte("HTTP/")) {
+		fmt.Fprintf(bf, "HTTP Response: %s\n", lines[0])
+	} else {
+		fmt.Fprintf(bf, "HTTP Request: %s\n", lines[0])
+	}
+	for _, line := range lines[1:] {
+		k, v, _ := bytes.Cut(line, []byte(":"))
+		fmt.Fprintf(bf, "  %s: %s\n", bytes.TrimSpace(k), bytes.TrimSpace(v))
+	}
+	if !found {
+		bf.WriteString("  (incomplete header)\n")
+		return true
+	}
+	if len(body) > 0 {
+		fmt.Fprintf(bf, "Body: (%d)\n", len(body))
+		writeQuoted(bf, body, maxLen)
+	}
+	return true
+}
+
+// writeRESP 输出 Redis RESP 协议的内容
+func writeRESP(bf *bytes.Buffer, p []byte) bool {
+	if !IsRESPStart(p) {
+		return false
+	}
+	out := &bytes.Buffer{}
+	rest := p
+	for len(rest) > 0 {
+		v, n, err := ParseRESP(rest)
+		if err != nil || n == 0 {
+			return false
+		}
+		writeRESPValue(out, v, 0)
+		rest = rest[n:]
+	}
+	bf.WriteString("RESP:\n")
+	bf.Write(out.Bytes())
+	return true
+}
+
+func writeRESPValue(out *bytes.Buffer, v *RESPValue, depth int) {
+	indent := bytes.Repeat([]byte("  "), depth+1)
+	switch v.Type {
+	case '+':
+		fmt.Fprintf(out, "%sString %q\n", indent, v.Str)
+	case '-':
+		fmt.Fprintf(out, "%sError %q\n", indent, v.Str)
+	case ':':
+		fmt.Fprintf(out, "%sInteger %s\n", indent, v.Str)
+	case '_':
+		fmt.Fprintf(out, "%sNull\n", indent)
+	case '$':
+		if v.Null {
+			fmt.Fprintf(out, "%sBulk (nil)\n", indent)
+			return
+		}
+		fmt.Fprintf(out, "%sBulk(%d) %q\n", indent, len(v.Str), v.Str)
+	case '*':
+		if v.Null {
+			fmt.Fprintf(out, "%sArray (nil)\n", indent)
+			return
+		}
+		fmt.Fprintf(out, "%sArray(%d)\n", indent, len(v.Array))
+		for _, item := range v.Array {
+			writeRESPValue(out, item, depth+1)
+		}
+	default:
+		fmt.Fprintf(out, "%sValue %s\n", indent, v.Str)
+	}
+}
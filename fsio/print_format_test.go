@@ -0,0 +1,94 @@
+// Copyright(C) 2026 github.com/fsgo  All Rights Reserved.
+// Author: hidu <duv123@gmail.com>
+// Date: 2026/10/19
+
+package fsio
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/fsgo/fst"
+)
+
+func TestPrintByteWriter(t *testing.T) {
+	out := &bytes.Buffer{}
+	pb := &PrintByteWriter{
+		Name: "Read",
+		Out:  out,
+	}
+	check := func(t *testing.T, format PrintFormat, data string, want ...string) {
+		t.Helper()
+		out.Reset()
+		pb.Format = format
+		n, err := pb.Write([]byte(data))
+		fst.NoError(t, err)
+		fst.Equal(t, len(data), n)
+		for _, w := range want {
+			fst.Contains(t, out.String(), w)
+		}
+	}
+
+	t.Run("hexdump", func(t *testing.T) {
+		check(t, PrintFormatHexdump, "hello world\n",
+			"00000000  68 65 6c 6c 6f 20 77 6f  72 6c 64 0a              |hello world.|\n0000000c\n",
+		)
+	})
+
+	t.Run("quote", func(t *testing.T) {
+		check(t, PrintFormatQuote, "hello\nworld", "\"hello\\n\"\n\"world\"\n")
+	})
+
+	t.Run("http", func(t *testing.T) {
+		check(t, PrintFormatProtocol, "GET /a HTTP/1.1\r\nHost: example.com\r\n\r\n",
+			"HTTP Request: GET /a HTTP/1.1\n", "  Host: example.com\n",
+		)
+		check(t, PrintFormatProtocol, "HTTP/1.1 200 OK\r\nContent-Length: 2\r\n\r\nok",
+			"HTTP Response: HTTP/1.1 200 OK\n", "Body: (2)\n\"ok\"\n",
+		)
+	})
+
+	t.Run("resp", func(t *testing.T) {
+		check(t, PrintFormatProtocol, "*2\r\n$3\r\nGET\r\n$1\r\nk\r\n",
+			"RESP:\n  Array(2)\n    Bulk(3) \"GET\"\n    Bulk(1) \"k\"\n",
+		)
+		check(t, PrintFormatProtocol, "+OK\r\n", "  String \"OK\"\n")
+		check(t, PrintFormatProtocol, "+OK", "00000000  2b 4f 4b")
+	})
+
+	t.Run("registered", func(t *testing.T) {
+		fst.Error(t, RegisterProtocolPrinter(&ProtocolPrinter{Name: "demo"}))
+		fst.NoError(t, RegisterProtocolPrinter(&ProtocolPrinter{
+			Name: "demo",
+			Print: func(w io.Writer, p []byte) bool {
+				_, _ = w.Write([]byte("partial"))
+				if !bytes.HasPrefix(p, []byte("DEMO")) {
+					return false
+				}
+				_, _ = fmt.Fprintf(w, "\nDEMO: %d\n", len(p))
+				return true
+			},
+		}))
+		check(t, PrintFormatProtocol, "DEMO12", "partial\nDEMO: 6\n")
+		check(t, PrintFormatProtocol, "abc", "00000000  61 62 63")
+		fst.False(t, strings.Contains(out.String(), "partial"))
+	})
+
+	t.Run("direction", func(t *testing.T) {
+		pb.Direction = PrintDirectionWrite
+		pb.Color = true
+		check(t, PrintFormatDefault, "a", "\033[33m>>\033[0m [Read][")
+	})
+}
+
+func TestParsePrintFormat(t *testing.T) {
+	f, err := ParsePrintFormat("protocol")
+	fst.NoError(t, err)
+	fst.Equal(t, PrintFormatProtocol, f)
+
+	_, err = ParsePrintFormat("json")
+	fst.Error(t, err)
+}
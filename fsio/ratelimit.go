@@ -0,0 +1,240 @@
+// Copyright(C) 2026 github.com/fsgo  All Rights Reserved.
+// Author: hidu <duv123@gmail.com>
+// Date: 2026/10/19
+
+package fsio
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+)
+
+// RateLimiter 基于令牌桶的字节限速器
+//
+// 同一个 RateLimiter 可以被多个 reader、writer 共享，作为它们总的限速
+type RateLimiter struct {
+	last time.Time
+
+	// Rate 每秒允许的字节数，<= 0 时不限速
+	Rate int64
+
+	// Burst 允许突发的最大字节数，可选，默认等于 Rate
+	Burst int64
+
+	tokens float64
+
+	mux sync.Mutex
+}
+
+// NewRateLimiter 创建一个新的限速器，burst <= 0 时等于 rate
+func NewRateLimiter(rate int64, burst int64) *RateLimiter {
+	return &RateLimiter{
+		Rate:  rate,
+		Burst: burst,
+	}
+}
+
+func (l *RateLimiter) getBurst() int64 {
+	if l.Burst > 0 {
+		return l.Burst
+	}
+	return l.Rate
+}
+
+// SetRate 调整限速
+func (l *RateLimiter) SetRate(rate int64, burst int64) {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+	if l.Rate > 0 {
+		l.advance(time.Now())
+	} else {
+		// 之前不限速，下次使用时令牌桶为满的
+		l.last = time.Time{}
+	}
+	l.Rate = rate
+	l.Burst = burst
+	if b := float64(l.getBurst()); l.tokens > b {
+		l.tokens = b
+	}
+}
+
+// BurstSize 返回单次允许的最大字节数，不限速时返回 0
+func (l *RateLimiter) BurstSize() int {
+	if l == nil {
+		return 0
+	}
+	l.mux.Lock()
+	defer l.mux.Unlock()
+	if l.Rate <= 0 {
+		return 0
+	}
+	return int(l.getBurst())
+}
+
+// advance 补充从上次到 now 期间产生的令牌
+func (l *RateLimiter) advance(now time.Time) {
+	if l.last.IsZero() {
+		l.last = now
+		l.tokens = float64(l.getBurst())
+		return
+	}
+	if l.Rate > 0 {
+		l.tokens += now.Sub(l.last).Seconds() * float64(l.Rate)
+		if b := float64(l.getBurst()); l.tokens > b {
+			l.tokens = b
+		}
+	}
+	l.last = now
+}
+
+// reserve 预留 n 个令牌，返回需要等待的时长
+func (l *RateLimiter) reserve(n int) time.Duration {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+	if l.Rate <= 0 {
+		return 0
+	}
+	l.advance(time.Now())
+	l.tokens -= float64(n)
+	if l.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-l.tokens / float64(l.Rate) * float64(time.Second))
+}
+
+func (l *RateLimiter) cancel(n int) {
+	l.mux.Lock()
+	l.tokens += float64(n)
+	l.mux.Unlock()
+}
+
+// AllowN 是否允许立即通过 n 个字节，不等待
+//
+// n 大于 Burst 时，在令牌桶满时允许通过
+func (l *RateLimiter) AllowN(n int) bool {
+	if l == nil || n <= 0 {
+		return true
+	}
+	l.mux.Lock()
+	defer l.mux.Unlock()
+	if l.Rate <= 0 {
+		return true
+	}
+	l.advance(time.Now())
+	if l.tokens < float64(min(int64(n), l.getBurst())) {
+		return false
+	}
+	l.tokens -= float64(n)
+	return true
+}
+
+// WaitN 等待，直到允许通过 n 个字节或者 ctx 结束
+//
+// n 可以大于 Burst，此时需要等待更长的时间
+func (l *RateLimiter) WaitN(ctx context.Context, n int) error {
+	if l == nil || n <= 0 {
+		return nil
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	wait := l.reserve(n)
+	if wait <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		l.cancel(n)
+		return ctx.Err()
+	}
+}
+
+// RateLimiters 多个限速器，如一个全局共享的和一个单个流独享的
+type RateLimiters []*RateLimiter
+
+// ChunkSize 返回不超过 n 且不超过所有限速器 Burst 的单次读写字节数
+func (ls RateLimiters) ChunkSize(n int) int {
+	for _, l := range ls {
+		if b := l.BurstSize(); b > 0 && b < n {
+			n = b
+		}
+	}
+	return n
+}
+
+// WaitN 等待，直到所有限速器都允许通过 n 个字节或者 ctx 结束
+func (ls RateLimiters) WaitN(ctx context.Context, n int) error {
+	for _, l := range ls {
+		if err := l.WaitN(ctx, n); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// NewRateLimitReader 创建限速的 Reader，可以同时使用多个限速器
+func NewRateLimitReader(ctx context.Context, r io.Reader, limiters ...*RateLimiter) io.Reader {
+	return &rateLimitReader{
+		ctx:      ctx,
+		reader:   r,
+		limiters: limiters,
+	}
+}
+
+type rateLimitReader struct {
+	ctx      context.Context
+	reader   io.Reader
+	limiters RateLimiters
+}
+
+func (r *rateLimitReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	p = p[:r.limiters.ChunkSize(len(p))]
+	n, err := r.reader.Read(p)
+	if n > 0 {
+		if err1 := r.limiters.WaitN(r.ctx, n); err1 != nil && err == nil {
+			err = err1
+		}
+	}
+	return n, err
+}
+
+// NewRateLimitWriter 创建限速的 Writer，可以同时使用多个限速器
+func NewRateLimitWriter(ctx context.Context, w io.Writer, limiters ...*RateLimiter) io.Writer {
+	return &rateLimitWriter{
+		ctx:      ctx,
+		writer:   w,
+		limiters: limiters,
+	}
+}
+
+type rateLimitWriter struct {
+	ctx      context.Context
+	writer   io.Writer
+	limiters RateLimiters
+}
+
+func (w *rateLimitWriter) Write(p []byte) (int, error) {
+	var wrote int
+	for len(p) > 0 {
+		size := w.limiters.ChunkSize(len(p))
+		if err := w.limiters.WaitN(w.ctx, size); err != nil {
+			return wrote, err
+		}
+		n, err := w.writer.Write(p[:size])
+		wrote += n
+		if err != nil {
+			return wrote, err
+		}
+		p = p[size:]
+	}
+	return wrote, nil
+}
@@ -0,0 +1,76 @@
+// Copyright(C) 2026 github.com/fsgo  All Rights Reserved.
+// Author: hidu <duv123@gmail.com>
+// Date: 2026/10/19
+
+package fsio
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fsgo/fst"
+)
+
+func TestRateLimiter(t *testing.T) {
+	t.Run("writer", func(t *testing.T) {
+		l := NewRateLimiter(10*1024, 1024)
+		bf := &bytes.Buffer{}
+		w := NewRateLimitWriter(context.Background(), bf, l)
+		start := time.Now()
+		n, err := w.Write(make([]byte, 3*1024))
+		fst.NoError(t, err)
+		fst.Equal(t, 3*1024, n)
+		fst.Equal(t, 3*1024, bf.Len())
+		cost := time.Since(start)
+		fst.True(t, cost >= 150*time.Millisecond)
+		fst.True(t, cost < time.Second)
+	})
+
+	t.Run("reader", func(t *testing.T) {
+		global := NewRateLimiter(100*1024, 0)
+		stream := NewRateLimiter(10*1024, 1024)
+		r := NewRateLimitReader(context.Background(), strings.NewReader(strings.Repeat("a", 2048)), global, stream)
+		start := time.Now()
+		bf, err := io.ReadAll(r)
+		fst.NoError(t, err)
+		fst.Len(t, bf, 2048)
+		fst.True(t, time.Since(start) >= 50*time.Millisecond)
+	})
+
+	t.Run("ctx", func(t *testing.T) {
+		l := NewRateLimiter(10, 10)
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		w := NewRateLimitWriter(ctx, io.Discard, l)
+		n, err := w.Write(make([]byte, 100))
+		fst.Equal(t, 10, n)
+		fst.Error(t, err)
+	})
+
+	t.Run("AllowN", func(t *testing.T) {
+		l := NewRateLimiter(100, 10)
+		fst.True(t, l.AllowN(6))
+		fst.False(t, l.AllowN(6))
+		fst.True(t, l.AllowN(4))
+		time.Sleep(110 * time.Millisecond)
+		fst.True(t, l.AllowN(20))
+		fst.False(t, l.AllowN(1))
+	})
+
+	t.Run("no limit", func(t *testing.T) {
+		l := NewRateLimiter(0, 0)
+		fst.Equal(t, 0, l.BurstSize())
+		fst.NoError(t, l.WaitN(context.Background(), 1<<30))
+		l.SetRate(100, 0)
+		fst.Equal(t, 100, l.BurstSize())
+
+		// 从不限速切换为限速后，令牌桶是满的
+		start := time.Now()
+		fst.NoError(t, l.WaitN(context.Background(), 100))
+		fst.True(t, time.Since(start) < 50*time.Millisecond)
+	})
+}
@@ -0,0 +1,108 @@
+// Copyright(C) 2026 github.com/fsgo  All Rights Reserved.
+// Author: hidu <duv123@gmail.com>
+// Date: 2026/10/19
+
+package fsio
+
+import (
+	"bytes"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// RESPValue Redis RESP 协议的一个值
+type RESPValue struct {
+	// Str 除数组外，其他类型的内容，如 Bulk 的数据、Integer 的数字
+	Str string
+
+	// Array 数组的元素
+	Array []*RESPValue
+
+	// Type 类型，如 '+'、'-'、':'、'$'、'*'、'_'、'#'、','
+	Type byte
+
+	// Null 是否是空值，如 "$-1"、"*-1" 和 "_"
+	Null bool
+}
+
+// ErrInvalidRESP 不是合法的 RESP 数据
+var ErrInvalidRESP = errors.New("invalid RESP data")
+
+// IsRESPStart 判断 b 是否以 RESP 的类型标识开头
+func IsRESPStart(b []byte) bool {
+	return len(b) > 0 && strings.IndexByte("+-:$*_#,", b[0]) >= 0
+}
+
+// ParseRESP 从 b 的开头解析一个 RESP 值，返回值和使用的字节数，
+// 数据不完整时返回的字节数为 0
+func ParseRESP(b []byte) (*RESPValue, int, error) {
+	return parseRESP(b, 0)
+}
+
+func parseRESP(b []byte, depth int) (*RESPValue, int, error) {
+	if depth > 32 {
+		return nil, 0, ErrInvalidRESP
+	}
+	idx := bytes.Index(b, []byte("\r\n"))
+	if idx < 0 {
+		return nil, 0, nil
+	}
+	if idx == 0 {
+		return nil, 0, ErrInvalidRESP
+	}
+	v := &RESPValue{
+		Type: b[0],
+		Str:  string(b[1:idx]),
+	}
+	used := idx + 2
+	switch v.Type {
+	case '+', '-', '#', ',':
+	case ':':
+		if _, err := strconv.ParseInt(v.Str, 10, 64); err != nil {
+			return nil, 0, ErrInvalidRESP
+		}
+	case '_':
+		v.Null = true
+	case '$':
+		size, err := strconv.Atoi(v.Str)
+		if err != nil || size < -1 {
+			return nil, 0, ErrInvalidRESP
+		}
+		v.Str = ""
+		if size == -1 {
+			v.Null = true
+			return v, used, nil
+		}
+		if len(b) < used+size+2 {
+			return nil, 0, nil
+		}
+		if string(b[used+size:used+size+2]) != "\r\n" {
+			return nil, 0, ErrInvalidRESP
+		}
+		v.Str = string(b[used : used+size])
+		used += size + 2
+	case '*':
+		num, err := strconv.Atoi(v.Str)
+		if err != nil || num < -1 {
+			return nil, 0, ErrInvalidRESP
+		}
+		v.Str = ""
+		if num == -1 {
+			v.Null = true
+			return v, used, nil
+		}
+		v.Array = make([]*RESPValue, 0, min(num, 1024))
+		for i := 0; i < num; i++ {
+			item, n, err := parseRESP(b[used:], depth+1)
+			if err != nil || n == 0 {
+				return nil, 0, err
+			}
+			v.Array = append(v.Array, item)
+			used += n
+		}
+	default:
+		return nil, 0, ErrInvalidRESP
+	}
+	return v, used, nil
+}
@@ -0,0 +1,37 @@
+// Copyright(C) 2026 github.com/fsgo  All Rights Reserved.
+// Author: hidu <duv123@gmail.com>
+// Date: 2026/10/19
+
+package fsio
+
+import (
+	"testing"
+
+	"github.com/fsgo/fst"
+)
+
+func TestParseRESP(t *testing.T) {
+	v, n, err := ParseRESP([]byte("*3\r\n$3\r\nGET\r\n$-1\r\n:12\r\n+OK"))
+	fst.NoError(t, err)
+	fst.Equal(t, 23, n)
+	fst.Equal(t, byte('*'), v.Type)
+	fst.Len(t, v.Array, 3)
+	fst.Equal(t, "GET", v.Array[0].Str)
+	fst.True(t, v.Array[1].Null)
+	fst.Equal(t, "12", v.Array[2].Str)
+
+	// 数据不完整
+	_, n, err = ParseRESP([]byte("$5\r\nhel"))
+	fst.NoError(t, err)
+	fst.Equal(t, 0, n)
+
+	_, _, err = ParseRESP([]byte(":abc\r\n"))
+	fst.Error(t, err)
+	_, _, err = ParseRESP([]byte("$2\r\nabcd\r\n"))
+	fst.Error(t, err)
+
+	fst.True(t, IsRESPStart([]byte("+OK")))
+	fst.False(t, IsRESPStart([]byte("GET")))
+	fst.True(t, IsHTTPStart([]byte("GET /")))
+	fst.False(t, IsHTTPStart([]byte("GETX")))
+}
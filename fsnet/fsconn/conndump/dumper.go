@@ -6,7 +6,9 @@ package conndump
 
 import (
 	"encoding/binary"
+	"errors"
 	"fmt"
+	"io"
 	"net"
 	"path/filepath"
 	"sync"
@@ -32,6 +34,9 @@ type Dumper struct {
 
 	conns map[fsconn.Info]*connInfo
 
+	// policy 采样、过滤和限制规则，见 SetPolicy
+	policy policyState
+
 	// RotatorConfig 可选，用于配置 dump 的 Rotator
 	RotatorConfig func(client bool, r *fsfs.Rotator)
 
@@ -149,7 +154,7 @@ func (d *Dumper) DumpAllClientRead(enable bool) {
 
 // DumpAllClientWrite 设置所有的 client 是否允许 dump Write 的数据
 func (d *Dumper) DumpAllClientWrite(enable bool) {
-	d.clientReadStatus.SetAllEnable(enable)
+	d.clientWriteStatus.SetAllEnable(enable)
 }
 
 // DumpServerRead 设置所有 server 是否都允许 dump Read 的数据
@@ -196,43 +201,63 @@ func (d *Dumper) WrapListener(name string, l net.Listener) net.Listener {
 
 // dumpWrite dump conn 里写出的数据
 func (d *Dumper) dumpWrite(isClient bool, conn fsconn.Info, b []byte, size int, err error) {
-	if err != nil {
+	err = realError(err)
+	if size <= 0 && err == nil {
 		return
 	}
 	name := service(conn)
+	var enable bool
 	if isClient {
-		if !d.clientWriteStatus.IsEnable(name) {
-			return
-		}
+		enable = d.clientWriteStatus.IsEnable(name)
 	} else {
-		if !d.serverWriteStatus.IsEnable(name) {
-			return
-		}
+		enable = d.serverWriteStatus.IsEnable(name)
 	}
-	d.doDumpReadWrite(isClient, conn, b, size, MessageAction_Write)
+	d.doDumpReadWrite(isClient, conn, b, size, MessageAction_Write, err, enable)
 }
 
 // dumpRead dump conn 里收到的数据
 func (d *Dumper) dumpRead(isClient bool, conn fsconn.Info, b []byte, size int, err error) {
-	if err != nil {
+	err = realError(err)
+	if size <= 0 && err == nil {
 		return
 	}
 	name := service(conn)
+	var enable bool
 	if isClient {
-		if !d.clientReadStatus.IsEnable(name) {
-			return
-		}
+		enable = d.clientReadStatus.IsEnable(name)
 	} else {
-		if !d.serverReadStatus.IsEnable(name) {
-			return
-		}
+		enable = d.serverReadStatus.IsEnable(name)
 	}
-	d.doDumpReadWrite(isClient, conn, b, size, MessageAction_Read)
+	d.doDumpReadWrite(isClient, conn, b, size, MessageAction_Read, err, enable)
 }
 
-func (d *Dumper) doDumpReadWrite(isClient bool, conn fsconn.Info, b []byte, size int, tp MessageAction) {
-	ci := d.getConnInfo(conn, true)
-	msg := ci.newMessage(b, size, tp)
+// realError 过滤掉读写时的正常情况：EOF、连接已关闭和超时，
+// 避免轮询读取的连接产生大量只有 error 的消息
+func realError(err error) error {
+	if err == nil || errors.Is(err, io.EOF) || errors.Is(err, net.ErrClosed) {
+		return nil
+	}
+	var ne net.Error
+	if errors.As(err, &ne) && ne.Timeout() {
+		return nil
+	}
+	return err
+}
+
+// doDumpReadWrite 记录读写的数据
+//
+// 即使没有开启 dump，也需要累加连接的读写偏移量，以保证中途开启 dump 后 Message.Offset 依然是在数据流中的偏移量
+func (d *Dumper) doDumpReadWrite(isClient bool, conn fsconn.Info, b []byte, size int, tp MessageAction, err error, enable bool) {
+	ci := d.getConnInfo(isClient, conn, true)
+	offset := ci.addOffset(tp, size)
+	if !enable || ci.skip {
+		return
+	}
+	if size = d.policy.allowSize(ci, size); size <= 0 && err == nil {
+		return
+	}
+	msg := ci.newMessage(b, size, tp, err)
+	msg.Offset = offset
 	d.writeMessage(isClient, msg)
 }
 
@@ -257,7 +282,7 @@ func (d *Dumper) nextConnID() int64 {
 	return atomic.AddInt64(&d.connID, 1)
 }
 
-func (d *Dumper) getConnInfo(conn fsconn.Info, create bool) *connInfo {
+func (d *Dumper) getConnInfo(isClient bool, conn fsconn.Info, create bool) *connInfo {
 	d.connsMux.RLock()
 	info := d.conns[conn]
 	d.connsMux.RUnlock()
@@ -274,19 +299,26 @@ func (d *Dumper) getConnInfo(conn fsconn.Info, create bool) *connInfo {
 		return info
 	}
 	info = &connInfo{
-		connID: d.nextConnID(),
-		Conn:   conn,
+		connID:   d.nextConnID(),
+		Conn:     conn,
+		isClient: isClient,
+		skip:     !d.policy.sample(conn),
 	}
 	d.conns[conn] = info
 	return info
 }
 
-func (d *Dumper) dumpClose(isClient bool, info fsconn.Info, _ error) {
-	ci := d.getConnInfo(info, false)
+func (d *Dumper) dumpClose(isClient bool, info fsconn.Info, err error) {
+	ci := d.getConnInfo(isClient, info, false)
 	if ci == nil {
 		// 在此之前没有 Read 和 Write，直接 Close 的情况
 		return
 	}
+	// 不论是否开启了 dump，读写时都会创建 connInfo，所以需要先删除
+	d.connsMux.Lock()
+	delete(d.conns, info)
+	d.connsMux.Unlock()
+
 	name := service(info)
 	if isClient {
 		if !d.clientReadStatus.IsEnable(name) && !d.clientWriteStatus.IsEnable(name) {
@@ -297,13 +329,11 @@ func (d *Dumper) dumpClose(isClient bool, info fsconn.Info, _ error) {
 			return
 		}
 	}
-
-	msg := ci.newMessage(nil, 0, MessageAction_Close)
+	if ci.skip {
+		return
+	}
+	msg := ci.newMessage(nil, 0, MessageAction_Close, err)
 	d.writeMessage(isClient, msg)
-
-	d.connsMux.Lock()
-	defer d.connsMux.Unlock()
-	delete(d.conns, info)
 }
 
 // Stop 停止
@@ -318,26 +348,73 @@ type connInfo struct {
 	Conn     fsconn.Info
 	connID   int64
 	subGroup int64
+
+	// readOffset 已读取的字节数
+	readOffset atomic.Int64
+
+	// writeOffset 已写出的字节数
+	writeOffset atomic.Int64
+
+	// dumpedBytes 已 dump 的 Payload 的字节数
+	dumpedBytes atomic.Int64
+
+	isClient bool
+
+	// skip 此连接没有被采样或者不符合过滤条件，不 dump
+	skip bool
 }
 
 var msgID int64
 
-func (in *connInfo) newMessage(b []byte, size int, tp MessageAction) *Message {
+func (in *connInfo) newMessage(b []byte, size int, tp MessageAction, err error) *Message {
+	remote := in.Conn.RemoteAddr().String()
 	msg := &Message{
-		ID:      atomic.AddInt64(&msgID, 1),
-		Service: in.service(),
-		ConnID:  in.connID,
-		SubID:   atomic.AddInt64(&in.subGroup, 1),
-		Addr:    in.Conn.RemoteAddr().String(),
-		Time:    timestamppb.New(time.Now()),
-		Action:  tp,
+		ID:         atomic.AddInt64(&msgID, 1),
+		Service:    in.service(),
+		ConnID:     in.connID,
+		SubID:      atomic.AddInt64(&in.subGroup, 1),
+		Addr:       remote,
+		Time:       timestamppb.New(time.Now()),
+		Action:     tp,
+		LocalAddr:  in.Conn.LocalAddr().String(),
+		RemoteAddr: remote,
+		Direction:  in.direction(tp),
+	}
+	if size < 0 {
+		size = 0
 	}
 	if size > 0 {
 		msg.Payload = b[:size]
 	}
+	if err != nil {
+		msg.Error = err.Error()
+	}
 	return msg
 }
 
+// addOffset 累加读写的字节数，返回此次数据在数据流中的偏移量
+func (in *connInfo) addOffset(tp MessageAction, size int) int64 {
+	if size <= 0 {
+		size = 0
+	}
+	switch tp {
+	case MessageAction_Read:
+		return in.readOffset.Add(int64(size)) - int64(size)
+	case MessageAction_Write:
+		return in.writeOffset.Add(int64(size)) - int64(size)
+	}
+	return 0
+}
+
+// direction 消息的方向，Write 和 Close 是本端发出的，Read 是对端发出的
+func (in *connInfo) direction(tp MessageAction) Direction {
+	fromLocal := tp != MessageAction_Read
+	if fromLocal == in.isClient {
+		return Direction_ClientToServer
+	}
+	return Direction_ServerToClient
+}
+
 func (in *connInfo) service() string {
 	return service(in.Conn)
 }
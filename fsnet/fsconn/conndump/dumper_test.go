@@ -0,0 +1,135 @@
+// Copyright(C) 2026 github.com/fsgo  All Rights Reserved.
+// Author: hidu <duv123@gmail.com>
+// Date: 2026/10/19
+
+package conndump
+
+import (
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/fsgo/fst"
+
+	"github.com/fsgo/fsgo/fsnet/fsconn"
+)
+
+func TestDumper(t *testing.T) {
+	dir := t.TempDir()
+	d := &Dumper{
+		DataDir: dir,
+	}
+	d.DumpAll(true)
+
+	c1, c2 := net.Pipe()
+	client := fsconn.Wrap(c1, d.ClientConnInterceptor())
+	go func() {
+		_, _ = io.ReadFull(c2, make([]byte, 6))
+		_, _ = c2.Write([]byte("world"))
+		_, _ = io.Copy(io.Discard, c2)
+	}()
+	_, err := client.Write([]byte("hello"))
+	fst.NoError(t, err)
+	_, err = client.Write([]byte("!"))
+	fst.NoError(t, err)
+	buf := make([]byte, 10)
+	_, err = io.ReadFull(client, buf[:5])
+	fst.NoError(t, err)
+	fst.NoError(t, client.Close())
+	d.Stop()
+
+	files, err := filepath.Glob(filepath.Join(dir, "client", "dump.pb.*"))
+	fst.NoError(t, err)
+	fst.Len(t, files, 1)
+	f, err := os.Open(files[0])
+	fst.NoError(t, err)
+	defer f.Close()
+
+	var msgs []*Message
+	fst.NoError(t, Scan(f, func(msg *Message) bool {
+		msgs = append(msgs, msg)
+		return true
+	}))
+	fst.Len(t, msgs, 4)
+	fst.Equal(t, int64(0), msgs[0].GetOffset())
+
+	fst.Equal(t, MessageAction_Write, msgs[1].GetAction())
+	fst.Equal(t, int64(5), msgs[1].GetOffset())
+	fst.Equal(t, Direction_ClientToServer, msgs[1].GetDirection())
+	fst.Equal(t, "pipe", msgs[1].Remote())
+	fst.Equal(t, "pipe", msgs[1].GetLocalAddr())
+
+	fst.Equal(t, MessageAction_Read, msgs[2].GetAction())
+	fst.Equal(t, Direction_ServerToClient, msgs[2].GetDirection())
+	fromClient, known := msgs[2].FromClient()
+	fst.True(t, fromClient)
+	fst.True(t, known)
+
+	fst.Equal(t, MessageAction_Close, msgs[3].GetAction())
+	fst.Equal(t, Direction_ClientToServer, msgs[3].GetDirection())
+}
+
+func TestDumper_enableLater(t *testing.T) {
+	dir := t.TempDir()
+	d := &Dumper{
+		DataDir: dir,
+	}
+
+	c1, c2 := net.Pipe()
+	client := fsconn.Wrap(c1, d.ClientConnInterceptor())
+	go func() {
+		_, _ = io.Copy(io.Discard, c2)
+	}()
+	// 没有开启 dump 时写出的数据，也需要计入偏移量
+	_, err := client.Write([]byte("hello"))
+	fst.NoError(t, err)
+	d.DumpAll(true)
+	_, err = client.Write([]byte("world"))
+	fst.NoError(t, err)
+	fst.NoError(t, client.Close())
+	d.Stop()
+
+	fst.Empty(t, d.conns)
+
+	files, err := filepath.Glob(filepath.Join(dir, "client", "dump.pb.*"))
+	fst.NoError(t, err)
+	fst.Len(t, files, 1)
+	f, err := os.Open(files[0])
+	fst.NoError(t, err)
+	defer f.Close()
+
+	var msgs []*Message
+	fst.NoError(t, Scan(f, func(msg *Message) bool {
+		msgs = append(msgs, msg)
+		return true
+	}))
+	fst.Len(t, msgs, 2)
+	fst.Equal(t, "world", string(msgs[0].GetPayload()))
+	fst.Equal(t, int64(5), msgs[0].GetOffset())
+	fst.Equal(t, MessageAction_Close, msgs[1].GetAction())
+}
+
+func TestMessageFromClient(t *testing.T) {
+	old := &Message{Addr: "127.0.0.1:80", Action: MessageAction_Read}
+	fst.Equal(t, "127.0.0.1:80", old.Remote())
+	_, known := old.FromClient()
+	fst.False(t, known)
+
+	fst.False(t, old.ClientToServer(false))
+	fst.True(t, old.ClientToServer(true))
+
+	msg := &Message{Action: MessageAction_Read, Direction: Direction_ClientToServer}
+	client, known := msg.FromClient()
+	fst.False(t, client)
+	fst.True(t, known)
+	fst.True(t, msg.ClientToServer(false))
+}
+
+func TestRealError(t *testing.T) {
+	fst.Nil(t, realError(io.EOF))
+	fst.Nil(t, realError(net.ErrClosed))
+	fst.Nil(t, realError(os.ErrDeadlineExceeded))
+	fst.Error(t, realError(io.ErrUnexpectedEOF))
+}
@@ -1,6 +1,6 @@
 // Code generated by protoc-gen-go. DO NOT EDIT.
 // versions:
-// 	protoc-gen-go v1.31.0
+// 	protoc-gen-go v1.34.2
 // 	protoc        v4.23.3
 // source: fsgo_conndump_message.proto
 
@@ -77,6 +77,58 @@ func (MessageAction) EnumDescriptor() ([]byte, []int) {
 	return file_fsgo_conndump_message_proto_rawDescGZIP(), []int{0}
 }
 
+// Direction 数据的方向
+type Direction int32
+
+const (
+	Direction_DirectionUnknown Direction = 0
+	// 客户端发送给服务端的数据
+	Direction_ClientToServer Direction = 1
+	// 服务端发送给客户端的数据
+	Direction_ServerToClient Direction = 2
+)
+
+// Enum value maps for Direction.
+var (
+	Direction_name = map[int32]string{
+		0: "DirectionUnknown",
+		1: "ClientToServer",
+		2: "ServerToClient",
+	}
+	Direction_value = map[string]int32{
+		"DirectionUnknown": 0,
+		"ClientToServer":   1,
+		"ServerToClient":   2,
+	}
+)
+
+func (x Direction) Enum() *Direction {
+	p := new(Direction)
+	*p = x
+	return p
+}
+
+func (x Direction) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Direction) Descriptor() protoreflect.EnumDescriptor {
+	return file_fsgo_conndump_message_proto_enumTypes[1].Descriptor()
+}
+
+func (Direction) Type() protoreflect.EnumType {
+	return &file_fsgo_conndump_message_proto_enumTypes[1]
+}
+
+func (x Direction) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Direction.Descriptor instead.
+func (Direction) EnumDescriptor() ([]byte, []int) {
+	return file_fsgo_conndump_message_proto_rawDescGZIP(), []int{1}
+}
+
 // Message net.Conn 一次读写(调用一次 Read 或者 Write 方法)的内容
 type Message struct {
 	state         protoimpl.MessageState
@@ -99,6 +151,16 @@ type Message struct {
 	Time *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=Time,proto3" json:"Time,omitempty"`
 	// 消息内容
 	Payload []byte `protobuf:"bytes,8,opt,name=Payload,proto3" json:"Payload,omitempty"`
+	// 本端地址
+	LocalAddr string `protobuf:"bytes,9,opt,name=LocalAddr,proto3" json:"LocalAddr,omitempty"`
+	// 对端地址，同 Addr
+	RemoteAddr string `protobuf:"bytes,10,opt,name=RemoteAddr,proto3" json:"RemoteAddr,omitempty"`
+	// 数据的方向，Close 消息为关闭连接的一方发送给另一方
+	Direction Direction `protobuf:"varint,11,opt,name=Direction,proto3,enum=conndump.Direction" json:"Direction,omitempty"`
+	// Payload 在此方向的数据流中的偏移量，即在此之前同方向已读或者已写的字节数
+	Offset int64 `protobuf:"varint,12,opt,name=Offset,proto3" json:"Offset,omitempty"`
+	// Read、Write 或者 Close 返回的错误
+	Error string `protobuf:"bytes,13,opt,name=Error,proto3" json:"Error,omitempty"`
 }
 
 func (x *Message) Reset() {
@@ -189,6 +251,41 @@ func (x *Message) GetPayload() []byte {
 	return nil
 }
 
+func (x *Message) GetLocalAddr() string {
+	if x != nil {
+		return x.LocalAddr
+	}
+	return ""
+}
+
+func (x *Message) GetRemoteAddr() string {
+	if x != nil {
+		return x.RemoteAddr
+	}
+	return ""
+}
+
+func (x *Message) GetDirection() Direction {
+	if x != nil {
+		return x.Direction
+	}
+	return Direction_DirectionUnknown
+}
+
+func (x *Message) GetOffset() int64 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *Message) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
 var File_fsgo_conndump_message_proto protoreflect.FileDescriptor
 
 var file_fsgo_conndump_message_proto_rawDesc = []byte{
@@ -196,7 +293,7 @@ var file_fsgo_conndump_message_proto_rawDesc = []byte{
 	0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x08, 0x63,
 	0x6f, 0x6e, 0x6e, 0x64, 0x75, 0x6d, 0x70, 0x1a, 0x1f, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f,
 	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2f, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61,
-	0x6d, 0x70, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0xf0, 0x01, 0x0a, 0x07, 0x4d, 0x65, 0x73,
+	0x6d, 0x70, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0x8f, 0x03, 0x0a, 0x07, 0x4d, 0x65, 0x73,
 	0x73, 0x61, 0x67, 0x65, 0x12, 0x0e, 0x0a, 0x02, 0x49, 0x44, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03,
 	0x52, 0x02, 0x49, 0x44, 0x12, 0x2f, 0x0a, 0x06, 0x41, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x02,
 	0x20, 0x01, 0x28, 0x0e, 0x32, 0x17, 0x2e, 0x63, 0x6f, 0x6e, 0x6e, 0x64, 0x75, 0x6d, 0x70, 0x2e,
@@ -211,12 +308,27 @@ var file_fsgo_conndump_message_proto_rawDesc = []byte{
 	0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75,
 	0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x04, 0x54, 0x69, 0x6d,
 	0x65, 0x12, 0x18, 0x0a, 0x07, 0x50, 0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x18, 0x08, 0x20, 0x01,
-	0x28, 0x0c, 0x52, 0x07, 0x50, 0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x2a, 0x3c, 0x0a, 0x0d, 0x4d,
-	0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x41, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x0b, 0x0a, 0x07,
-	0x55, 0x6e, 0x6b, 0x6e, 0x6f, 0x77, 0x6e, 0x10, 0x00, 0x12, 0x08, 0x0a, 0x04, 0x52, 0x65, 0x61,
-	0x64, 0x10, 0x01, 0x12, 0x09, 0x0a, 0x05, 0x57, 0x72, 0x69, 0x74, 0x65, 0x10, 0x02, 0x12, 0x09,
-	0x0a, 0x05, 0x43, 0x6c, 0x6f, 0x73, 0x65, 0x10, 0x03, 0x42, 0x0d, 0x5a, 0x0b, 0x2e, 0x2e, 0x2f,
-	0x63, 0x6f, 0x6e, 0x6e, 0x64, 0x75, 0x6d, 0x70, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x28, 0x0c, 0x52, 0x07, 0x50, 0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x12, 0x1c, 0x0a, 0x09, 0x4c,
+	0x6f, 0x63, 0x61, 0x6c, 0x41, 0x64, 0x64, 0x72, 0x18, 0x09, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09,
+	0x4c, 0x6f, 0x63, 0x61, 0x6c, 0x41, 0x64, 0x64, 0x72, 0x12, 0x1e, 0x0a, 0x0a, 0x52, 0x65, 0x6d,
+	0x6f, 0x74, 0x65, 0x41, 0x64, 0x64, 0x72, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x52,
+	0x65, 0x6d, 0x6f, 0x74, 0x65, 0x41, 0x64, 0x64, 0x72, 0x12, 0x31, 0x0a, 0x09, 0x44, 0x69, 0x72,
+	0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x13, 0x2e, 0x63,
+	0x6f, 0x6e, 0x6e, 0x64, 0x75, 0x6d, 0x70, 0x2e, 0x44, 0x69, 0x72, 0x65, 0x63, 0x74, 0x69, 0x6f,
+	0x6e, 0x52, 0x09, 0x44, 0x69, 0x72, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x16, 0x0a, 0x06,
+	0x4f, 0x66, 0x66, 0x73, 0x65, 0x74, 0x18, 0x0c, 0x20, 0x01, 0x28, 0x03, 0x52, 0x06, 0x4f, 0x66,
+	0x66, 0x73, 0x65, 0x74, 0x12, 0x14, 0x0a, 0x05, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x18, 0x0d, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x05, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x2a, 0x3c, 0x0a, 0x0d, 0x4d, 0x65,
+	0x73, 0x73, 0x61, 0x67, 0x65, 0x41, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x0b, 0x0a, 0x07, 0x55,
+	0x6e, 0x6b, 0x6e, 0x6f, 0x77, 0x6e, 0x10, 0x00, 0x12, 0x08, 0x0a, 0x04, 0x52, 0x65, 0x61, 0x64,
+	0x10, 0x01, 0x12, 0x09, 0x0a, 0x05, 0x57, 0x72, 0x69, 0x74, 0x65, 0x10, 0x02, 0x12, 0x09, 0x0a,
+	0x05, 0x43, 0x6c, 0x6f, 0x73, 0x65, 0x10, 0x03, 0x2a, 0x49, 0x0a, 0x09, 0x44, 0x69, 0x72, 0x65,
+	0x63, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x14, 0x0a, 0x10, 0x44, 0x69, 0x72, 0x65, 0x63, 0x74, 0x69,
+	0x6f, 0x6e, 0x55, 0x6e, 0x6b, 0x6e, 0x6f, 0x77, 0x6e, 0x10, 0x00, 0x12, 0x12, 0x0a, 0x0e, 0x43,
+	0x6c, 0x69, 0x65, 0x6e, 0x74, 0x54, 0x6f, 0x53, 0x65, 0x72, 0x76, 0x65, 0x72, 0x10, 0x01, 0x12,
+	0x12, 0x0a, 0x0e, 0x53, 0x65, 0x72, 0x76, 0x65, 0x72, 0x54, 0x6f, 0x43, 0x6c, 0x69, 0x65, 0x6e,
+	0x74, 0x10, 0x02, 0x42, 0x0d, 0x5a, 0x0b, 0x2e, 0x2e, 0x2f, 0x63, 0x6f, 0x6e, 0x6e, 0x64, 0x75,
+	0x6d, 0x70, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
 }
 
 var (
@@ -231,21 +343,23 @@ func file_fsgo_conndump_message_proto_rawDescGZIP() []byte {
 	return file_fsgo_conndump_message_proto_rawDescData
 }
 
-var file_fsgo_conndump_message_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_fsgo_conndump_message_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
 var file_fsgo_conndump_message_proto_msgTypes = make([]protoimpl.MessageInfo, 1)
-var file_fsgo_conndump_message_proto_goTypes = []interface{}{
+var file_fsgo_conndump_message_proto_goTypes = []any{
 	(MessageAction)(0),            // 0: conndump.MessageAction
-	(*Message)(nil),               // 1: conndump.Message
-	(*timestamppb.Timestamp)(nil), // 2: google.protobuf.Timestamp
+	(Direction)(0),                // 1: conndump.Direction
+	(*Message)(nil),               // 2: conndump.Message
+	(*timestamppb.Timestamp)(nil), // 3: google.protobuf.Timestamp
 }
 var file_fsgo_conndump_message_proto_depIdxs = []int32{
 	0, // 0: conndump.Message.Action:type_name -> conndump.MessageAction
-	2, // 1: conndump.Message.Time:type_name -> google.protobuf.Timestamp
-	2, // [2:2] is the sub-list for method output_type
-	2, // [2:2] is the sub-list for method input_type
-	2, // [2:2] is the sub-list for extension type_name
-	2, // [2:2] is the sub-list for extension extendee
-	0, // [0:2] is the sub-list for field type_name
+	3, // 1: conndump.Message.Time:type_name -> google.protobuf.Timestamp
+	1, // 2: conndump.Message.Direction:type_name -> conndump.Direction
+	3, // [3:3] is the sub-list for method output_type
+	3, // [3:3] is the sub-list for method input_type
+	3, // [3:3] is the sub-list for extension type_name
+	3, // [3:3] is the sub-list for extension extendee
+	0, // [0:3] is the sub-list for field type_name
 }
 
 func init() { file_fsgo_conndump_message_proto_init() }
@@ -254,7 +368,7 @@ func file_fsgo_conndump_message_proto_init() {
 		return
 	}
 	if !protoimpl.UnsafeEnabled {
-		file_fsgo_conndump_message_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+		file_fsgo_conndump_message_proto_msgTypes[0].Exporter = func(v any, i int) any {
 			switch v := v.(*Message); i {
 			case 0:
 				return &v.state
@@ -272,7 +386,7 @@ func file_fsgo_conndump_message_proto_init() {
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_fsgo_conndump_message_proto_rawDesc,
-			NumEnums:      1,
+			NumEnums:      2,
 			NumMessages:   1,
 			NumExtensions: 0,
 			NumServices:   0,
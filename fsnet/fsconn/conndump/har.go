@@ -0,0 +1,338 @@
+// Copyright(C) 2026 github.com/fsgo  All Rights Reserved.
+// Author: hidu <duv123@gmail.com>
+// Date: 2026/10/19
+
+package conndump
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+	"unicode/utf8"
+)
+
+// HAR HAR 1.2 文档，见 http://www.softwareishard.com/blog/har-12-spec/
+type HAR struct {
+	Log HARLog `json:"log"`
+}
+
+// HARLog HAR 的 log 字段
+type HARLog struct {
+	Version string      `json:"version"`
+	Creator HARCreator  `json:"creator"`
+	Entries []*HAREntry `json:"entries"`
+}
+
+// HARCreator 创建 HAR 的程序
+type HARCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// HAREntry 一次 HTTP 请求和响应
+type HAREntry struct {
+	StartedDateTime string      `json:"startedDateTime"`
+	Request         HARRequest  `json:"request"`
+	Response        HARResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         HARTimings  `json:"timings"`
+	ServerIPAddress string      `json:"serverIPAddress,omitempty"`
+	Connection      string      `json:"connection,omitempty"`
+	Time            float64     `json:"time"`
+
+	started time.Time
+}
+
+// HARRequest HAR 中的请求
+type HARRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []HARNameValue `json:"cookies"`
+	Headers     []HARNameValue `json:"headers"`
+	QueryString []HARNameValue `json:"queryString"`
+	PostData    *HARPostData   `json:"postData,omitempty"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+// HARResponse HAR 中的响应
+type HARResponse struct {
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []HARNameValue `json:"cookies"`
+	Headers     []HARNameValue `json:"headers"`
+	Content     HARContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	Status      int            `json:"status"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+// HARNameValue header、cookie 和 query 的一项
+type HARNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// HARPostData 请求的 body
+type HARPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+	Encoding string `json:"encoding,omitempty"`
+}
+
+// HARContent 响应的 body
+type HARContent struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+	Encoding string `json:"encoding,omitempty"`
+	Size     int    `json:"size"`
+}
+
+// HARTimings 请求各阶段的耗时，单位为毫秒，-1 表示不适用
+type HARTimings struct {
+	Blocked float64 `json:"blocked"`
+	DNS     float64 `json:"dns"`
+	Connect float64 `json:"connect"`
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+	SSL     float64 `json:"ssl"`
+}
+
+// HARConverter 将 dump 数据中的 HTTP/1.x 流量按照 ConnID 重组，并转换为 HAR
+//
+// 耗时根据 Message.Time 计算：send 为请求的首个到最后一个消息的间隔，
+// wait 为请求的最后一个消息到响应的首个消息的间隔，receive 为响应的首个到最后一个消息的间隔
+type HARConverter struct {
+	conns   map[int64]*harConn
+	entries []*HAREntry
+
+	// ServerSide 对于没有 Direction 字段的旧数据，dump 数据是否是 server 端的
+	ServerSide bool
+}
+
+type harConn struct {
+	stream HTTPStream
+	server string
+	connID int64
+}
+
+// Convert 读取 dump 数据，并全部转换
+func (hc *HARConverter) Convert(rd io.Reader) error {
+	return Scan(rd, func(msg *Message) bool {
+		hc.AddMessage(msg)
+		return true
+	})
+}
+
+func (hc *HARConverter) serverAddr(msg *Message) string {
+	addr := msg.Remote()
+	if fromClient, known := msg.FromClient(); (known && !fromClient) || (!known && hc.ServerSide) {
+		addr = msg.GetLocalAddr()
+	}
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return addr
+}
+
+// AddMessage 添加一条消息
+func (hc *HARConverter) AddMessage(msg *Message) {
+	if hc.conns == nil {
+		hc.conns = make(map[int64]*harConn)
+	}
+	c := hc.conns[msg.GetConnID()]
+	if msg.GetAction() == MessageAction_Close {
+		if c != nil {
+			hc.flush(c)
+			delete(hc.conns, msg.GetConnID())
+		}
+		return
+	}
+	if len(msg.GetPayload()) == 0 {
+		return
+	}
+	if c == nil {
+		c = &harConn{
+			connID: msg.GetConnID(),
+			server: hc.serverAddr(msg),
+		}
+		hc.conns[msg.GetConnID()] = c
+	}
+	// 数据不能解析时，此连接之后的数据都会被忽略
+	_, resps, _ := c.stream.Write(msg.GetPayload(), msg.ClientToServer(hc.ServerSide), msg.GetTime().AsTime())
+	hc.addExchanges(c, resps)
+}
+
+func (hc *HARConverter) addExchanges(c *harConn, list []*HTTPExchange) {
+	for _, ex := range list {
+		// 忽略没有对应请求的响应，如 dump 是从连接中途开始的
+		if ex.Request != nil {
+			hc.entries = append(hc.entries, newHAREntry(c, ex))
+		}
+	}
+}
+
+// flush 连接关闭，处理以连接关闭为结束的响应，没有响应的请求也会输出
+func (hc *HARConverter) flush(c *harConn) {
+	resps, unanswered := c.stream.Close()
+	hc.addExchanges(c, resps)
+	hc.addExchanges(c, unanswered)
+}
+
+func durationMS(d time.Duration) float64 {
+	if d < 0 {
+		return 0
+	}
+	return float64(d) / float64(time.Millisecond)
+}
+
+func harHeaders(h http.Header) []HARNameValue {
+	list := make([]HARNameValue, 0, len(h))
+	for k, vs := range h {
+		for _, v := range vs {
+			list = append(list, HARNameValue{Name: k, Value: v})
+		}
+	}
+	sort.SliceStable(list, func(i, j int) bool {
+		return list[i].Name < list[j].Name
+	})
+	return list
+}
+
+func harCookies(cookies []*http.Cookie) []HARNameValue {
+	list := make([]HARNameValue, 0, len(cookies))
+	for _, c := range cookies {
+		list = append(list, HARNameValue{Name: c.Name, Value: c.Value})
+	}
+	return list
+}
+
+// harText 文本内容直接输出，二进制内容使用 base64 编码
+func harText(b []byte) (text string, encoding string) {
+	if utf8.Valid(b) {
+		return string(b), ""
+	}
+	return base64.StdEncoding.EncodeToString(b), "base64"
+}
+
+func newHAREntry(c *harConn, ex *HTTPExchange) *HAREntry {
+	req := ex.Request
+	u := *req.URL
+	if len(u.Host) == 0 {
+		u.Host = req.Host
+	}
+	if len(u.Scheme) == 0 {
+		u.Scheme = "http"
+	}
+	query := make([]HARNameValue, 0)
+	for k, vs := range u.Query() {
+		for _, v := range vs {
+			query = append(query, HARNameValue{Name: k, Value: v})
+		}
+	}
+	sort.SliceStable(query, func(i, j int) bool {
+		return query[i].Name < query[j].Name
+	})
+	entry := &HAREntry{
+		StartedDateTime: ex.RequestStart.Format(time.RFC3339Nano),
+		Request: HARRequest{
+			Method:      req.Method,
+			URL:         u.String(),
+			HTTPVersion: req.Proto,
+			Cookies:     harCookies(req.Cookies()),
+			Headers:     harHeaders(req.Header),
+			QueryString: query,
+			HeadersSize: ex.RequestHeaderSize,
+			BodySize:    len(ex.RequestBody),
+		},
+		ServerIPAddress: c.server,
+		Connection:      strconv.FormatInt(c.connID, 10),
+		Timings: HARTimings{
+			Blocked: -1,
+			DNS:     -1,
+			Connect: -1,
+			SSL:     -1,
+			Send:    durationMS(ex.RequestEnd.Sub(ex.RequestStart)),
+			Wait:    -1,
+			Receive: -1,
+		},
+		started: ex.RequestStart,
+	}
+	entry.Time = entry.Timings.Send
+	if len(ex.RequestBody) > 0 {
+		text, enc := harText(ex.RequestBody)
+		entry.Request.PostData = &HARPostData{
+			MimeType: req.Header.Get("Content-Type"),
+			Text:     text,
+			Encoding: enc,
+		}
+	}
+	resp := ex.Response
+	if resp == nil {
+		entry.Response.Cookies = make([]HARNameValue, 0)
+		entry.Response.Headers = make([]HARNameValue, 0)
+		return entry
+	}
+	entry.Timings.Wait = durationMS(ex.ResponseStart.Sub(ex.RequestEnd))
+	entry.Timings.Receive = durationMS(ex.ResponseEnd.Sub(ex.ResponseStart))
+	entry.Time += entry.Timings.Wait + entry.Timings.Receive
+
+	text, enc := harText(ex.ResponseBody)
+	entry.Response = HARResponse{
+		Status:      resp.StatusCode,
+		StatusText:  http.StatusText(resp.StatusCode),
+		HTTPVersion: resp.Proto,
+		Cookies:     harCookies(resp.Cookies()),
+		Headers:     harHeaders(resp.Header),
+		Content: HARContent{
+			Size:     len(ex.ResponseBody),
+			MimeType: resp.Header.Get("Content-Type"),
+			Text:     text,
+			Encoding: enc,
+		},
+		RedirectURL: resp.Header.Get("Location"),
+		HeadersSize: ex.ResponseHeaderSize,
+		BodySize:    len(ex.ResponseBody),
+	}
+	return entry
+}
+
+// HAR 返回已转换的内容，未关闭的连接中的请求也会包含在内
+func (hc *HARConverter) HAR() *HAR {
+	for id, c := range hc.conns {
+		hc.flush(c)
+		delete(hc.conns, id)
+	}
+	entries := append([]*HAREntry(nil), hc.entries...)
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].started.Before(entries[j].started)
+	})
+	return &HAR{
+		Log: HARLog{
+			Version: "1.2",
+			Creator: HARCreator{
+				Name:    "fsgo conndump",
+				Version: "1.0",
+			},
+			Entries: entries,
+		},
+	}
+}
+
+// WriteTo 将 HAR 以 JSON 格式写入 w
+func (h *HAR) WriteTo(w io.Writer) (int64, error) {
+	bf, err := json.MarshalIndent(h, "", "  ")
+	if err != nil {
+		return 0, err
+	}
+	n, err := w.Write(bf)
+	return int64(n), err
+}
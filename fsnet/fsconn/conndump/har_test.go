@@ -0,0 +1,133 @@
+// Copyright(C) 2026 github.com/fsgo  All Rights Reserved.
+// Author: hidu <duv123@gmail.com>
+// Date: 2026/10/19
+
+package conndump
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/fsgo/fst"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestHARConverter(t *testing.T) {
+	start := time.Date(2026, 10, 19, 8, 0, 0, 0, time.UTC)
+	at := func(ms int) *timestamppb.Timestamp {
+		return timestamppb.New(start.Add(time.Duration(ms) * time.Millisecond))
+	}
+	msgs := []*Message{
+		{ConnID: 1, RemoteAddr: "10.0.0.2:80", Action: MessageAction_Write, Direction: Direction_ClientToServer,
+			Payload: []byte("POST /api?id=1 HTTP/1.1\r\nHost: example.com\r\nContent-Length: 4\r\n\r\nab"), Time: at(0)},
+		{ConnID: 1, RemoteAddr: "10.0.0.2:80", Action: MessageAction_Write, Direction: Direction_ClientToServer,
+			Payload: []byte("cd"), Time: at(2)},
+		{ConnID: 2, RemoteAddr: "10.0.0.3:80", Action: MessageAction_Write, Direction: Direction_ClientToServer,
+			Payload: []byte("GET /nil HTTP/1.1\r\nHost: example.com\r\n\r\n"), Time: at(5)},
+		{ConnID: 1, RemoteAddr: "10.0.0.2:80", Action: MessageAction_Read, Direction: Direction_ServerToClient,
+			Payload: []byte("HTTP/1.1 200 OK\r\nContent-Type: application/octet-stream\r\nContent-Length: 2\r\n\r\n"), Time: at(10)},
+		{ConnID: 1, RemoteAddr: "10.0.0.2:80", Action: MessageAction_Read, Direction: Direction_ServerToClient,
+			Payload: []byte{0xff, 0xfe}, Time: at(13)},
+		{ConnID: 2, RemoteAddr: "10.0.0.3:80", Action: MessageAction_Close, Direction: Direction_ClientToServer, Time: at(20)},
+	}
+	hc := &HARConverter{}
+	for _, msg := range msgs {
+		hc.AddMessage(msg)
+	}
+	h := hc.HAR()
+	fst.Equal(t, "1.2", h.Log.Version)
+	fst.Len(t, h.Log.Entries, 2)
+
+	e := h.Log.Entries[0]
+	fst.Equal(t, "POST", e.Request.Method)
+	fst.Equal(t, "http://example.com/api?id=1", e.Request.URL)
+	fst.Equal(t, "abcd", e.Request.PostData.Text)
+	fst.Equal(t, []HARNameValue{{Name: "id", Value: "1"}}, e.Request.QueryString)
+	fst.Equal(t, 200, e.Response.Status)
+	fst.Equal(t, "base64", e.Response.Content.Encoding)
+	fst.Equal(t, "//4=", e.Response.Content.Text)
+	fst.Equal(t, "10.0.0.2", e.ServerIPAddress)
+	fst.Equal(t, "1", e.Connection)
+	fst.Equal(t, float64(2), e.Timings.Send)
+	fst.Equal(t, float64(8), e.Timings.Wait)
+	fst.Equal(t, float64(3), e.Timings.Receive)
+	fst.Equal(t, float64(13), e.Time)
+	fst.Equal(t, float64(-1), e.Timings.DNS)
+
+	// 没有响应的请求
+	e = h.Log.Entries[1]
+	fst.Equal(t, "http://example.com/nil", e.Request.URL)
+	fst.Equal(t, 0, e.Response.Status)
+	fst.Equal(t, float64(-1), e.Timings.Wait)
+
+	bf := &bytes.Buffer{}
+	_, err := h.WriteTo(bf)
+	fst.NoError(t, err)
+	var got map[string]any
+	fst.NoError(t, json.Unmarshal(bf.Bytes(), &got))
+	fst.NotEmpty(t, got["log"])
+}
+
+func TestHARConverterServerSide(t *testing.T) {
+	now := timestamppb.New(time.Now())
+	msgs := []*Message{
+		// 旧格式的数据，没有 Direction
+		{ConnID: 1, Addr: "10.0.0.9:5000", Action: MessageAction_Read,
+			Payload: []byte("HEAD / HTTP/1.1\r\nHost: a.com\r\n\r\n"), Time: now},
+		{ConnID: 1, Addr: "10.0.0.9:5000", Action: MessageAction_Write,
+			Payload: []byte("HTTP/1.1 200 OK\r\nContent-Length: 100\r\n\r\n"), Time: now},
+		{ConnID: 1, Addr: "10.0.0.9:5000", Action: MessageAction_Read,
+			Payload: []byte("GET /b HTTP/1.1\r\nHost: a.com\r\n\r\n"), Time: now},
+		{ConnID: 1, Addr: "10.0.0.9:5000", Action: MessageAction_Write,
+			Payload: []byte("HTTP/1.0 404 Not Found\r\n\r\nnot found"), Time: now},
+		{ConnID: 1, Addr: "10.0.0.9:5000", Action: MessageAction_Close, Time: now},
+	}
+	hc := &HARConverter{ServerSide: true}
+	for _, msg := range msgs {
+		hc.AddMessage(msg)
+	}
+	entries := hc.HAR().Log.Entries
+	fst.Len(t, entries, 2)
+	fst.Equal(t, "HEAD", entries[0].Request.Method)
+	fst.Equal(t, 200, entries[0].Response.Status)
+	fst.Equal(t, 0, entries[0].Response.BodySize)
+	fst.Equal(t, "http://a.com/b", entries[1].Request.URL)
+	fst.Equal(t, 404, entries[1].Response.Status)
+	fst.Equal(t, "not found", entries[1].Response.Content.Text)
+}
+
+func TestHARConverterCloseDelimited(t *testing.T) {
+	start := time.Date(2026, 10, 19, 8, 0, 0, 0, time.UTC)
+	at := func(ms int) *timestamppb.Timestamp {
+		return timestamppb.New(start.Add(time.Duration(ms) * time.Millisecond))
+	}
+	newMsg := func(connID int64, dir Direction, payload string, ms int) *Message {
+		return &Message{ConnID: connID, Action: MessageAction_Read, Direction: dir, Payload: []byte(payload), Time: at(ms)}
+	}
+	msgs := []*Message{
+		newMsg(1, Direction_ClientToServer, "GET /a HTTP/1.0\r\nHost: a.com\r\n\r\n", 0),
+		// 没有 Content-Length，也不是 chunked，body 以连接关闭为结束
+		newMsg(1, Direction_ServerToClient, "HTTP/1.0 200 OK\r\n\r\nhello ", 10),
+		newMsg(1, Direction_ServerToClient, "wor", 12),
+		newMsg(1, Direction_ServerToClient, "ld", 15),
+		{ConnID: 1, Action: MessageAction_Close, Time: at(20)},
+
+		// 连接未关闭，在 HAR() 时输出
+		newMsg(2, Direction_ClientToServer, "GET /b HTTP/1.1\r\nHost: a.com\r\n\r\n", 30),
+		newMsg(2, Direction_ServerToClient, "HTTP/1.1 200 OK\r\nConnection: close\r\n\r\nbye", 31),
+		newMsg(2, Direction_ServerToClient, " bye", 32),
+	}
+	hc := &HARConverter{}
+	for _, msg := range msgs {
+		hc.AddMessage(msg)
+	}
+	entries := hc.HAR().Log.Entries
+	fst.Len(t, entries, 2)
+	fst.Equal(t, "hello world", entries[0].Response.Content.Text)
+	fst.Equal(t, 11, entries[0].Response.BodySize)
+	fst.Equal(t, float64(5), entries[0].Timings.Receive)
+	fst.Equal(t, "http://a.com/b", entries[1].Request.URL)
+	fst.Equal(t, "bye bye", entries[1].Response.Content.Text)
+}
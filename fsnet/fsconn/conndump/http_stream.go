@@ -0,0 +1,417 @@
+// Copyright(C) 2026 github.com/fsgo  All Rights Reserved.
+// Author: hidu <duv123@gmail.com>
+// Date: 2026/10/19
+
+package conndump
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// HTTPExchange 一次 HTTP 请求和它的响应
+type HTTPExchange struct {
+	// Request 请求，对于没有对应请求的响应（如 dump 是从连接中途开始的）为 nil
+	Request *http.Request
+
+	// Response 响应，还没有响应时为 nil
+	Response *http.Response
+
+	RequestBody  []byte
+	ResponseBody []byte
+
+	// RequestStart、RequestEnd 请求的首个和最后一个字节所在消息的时间
+	RequestStart time.Time
+	RequestEnd   time.Time
+
+	// ResponseStart、ResponseEnd 响应的首个和最后一个字节所在消息的时间
+	ResponseStart time.Time
+	ResponseEnd   time.Time
+
+	// RequestHeaderSize、ResponseHeaderSize 请求头和响应头的字节数
+	RequestHeaderSize  int
+	ResponseHeaderSize int
+}
+
+// HTTPStream 将一个连接中双向的 HTTP/1.x 数据重组为请求和响应
+//
+// 响应和等待响应的请求按照顺序配对，以正确的解析 HEAD 请求的响应。
+// 没有 Content-Length、也不是 chunked 的响应以连接关闭为结束，在 Close 时才会返回。
+// 每个消息的头只解析一次，之后按照 Content-Length 或者 chunked 的格式增量的读取 body
+type HTTPStream struct {
+	req  timedBuffer
+	resp timedBuffer
+
+	// reqMsg、respMsg 已读取了头，正在读取 body 的请求和响应
+	reqMsg  *httpMessage
+	respMsg *httpMessage
+
+	pending []*HTTPExchange
+	broken  bool
+}
+
+// httpMessage 正在读取 body 的请求或者响应
+type httpMessage struct {
+	ex   *HTTPExchange
+	resp *http.Response
+	body httpBody
+
+	headerSize int
+	start      time.Time
+	end        time.Time
+}
+
+// Write 添加一段数据，clientToServer 为数据的方向，tm 为数据所在消息的时间
+//
+// 返回此次完整的请求和响应，响应的 Request 为与之配对的请求。
+// 数据不能解析时返回错误，之后的数据都会被忽略
+func (s *HTTPStream) Write(p []byte, clientToServer bool, tm time.Time) (reqs []*HTTPExchange, resps []*HTTPExchange, err error) {
+	if s.broken || len(p) == 0 {
+		return nil, nil, nil
+	}
+	if clientToServer {
+		s.req.append(p, tm)
+		if reqs, err = s.parseRequests(); err != nil {
+			return reqs, nil, err
+		}
+	} else {
+		s.resp.append(p, tm)
+	}
+	resps, err = s.parseResponses()
+	return reqs, resps, err
+}
+
+// Close 连接已关闭，返回以连接关闭为结束的响应，以及所有没有响应的请求
+//
+// 连接关闭时 body 还不完整的响应，也会使用已读取到的部分返回
+func (s *HTTPStream) Close() (resps []*HTTPExchange, unanswered []*HTTPExchange) {
+	if !s.broken && s.respMsg != nil {
+		if ex := s.finishResponse(); ex != nil {
+			resps = append(resps, ex)
+		}
+	}
+	unanswered = s.pending
+	s.pending = nil
+	s.reqMsg = nil
+	s.respMsg = nil
+	s.req = timedBuffer{}
+	s.resp = timedBuffer{}
+	s.broken = true
+	return resps, unanswered
+}
+
+func (s *HTTPStream) parseRequests() ([]*HTTPExchange, error) {
+	var result []*HTTPExchange
+	for {
+		if s.reqMsg == nil {
+			end := s.req.headerEnd()
+			if end < 0 {
+				break
+			}
+			req, err := http.ReadRequest(bufio.NewReader(bytes.NewReader(s.req.buf[:end])))
+			if err != nil {
+				s.broken = true
+				return result, fmt.Errorf("invalid request: %w", err)
+			}
+			msg := &httpMessage{
+				ex: &HTTPExchange{
+					Request:           req,
+					RequestHeaderSize: end,
+				},
+				body: newRequestBody(req),
+			}
+			msg.start, msg.end = s.req.consume(end)
+			s.reqMsg = msg
+		}
+		msg := s.reqMsg
+		done, err := s.readBody(&s.req, msg)
+		if err != nil {
+			s.broken = true
+			return result, fmt.Errorf("invalid request: %w", err)
+		}
+		if !done {
+			break
+		}
+		s.reqMsg = nil
+		ex := msg.ex
+		ex.RequestBody = msg.body.data
+		ex.RequestStart, ex.RequestEnd = msg.start, msg.end
+		s.pending = append(s.pending, ex)
+		result = append(result, ex)
+	}
+	return result, nil
+}
+
+// readBody 从 tb 中读取 msg 的 body，返回 body 是否已完整
+func (s *HTTPStream) readBody(tb *timedBuffer, msg *httpMessage) (bool, error) {
+	n, done, err := msg.body.read(tb.buf)
+	if n > 0 {
+		_, msg.end = tb.consume(n)
+	}
+	return done, err
+}
+
+// parseResponses 解析响应，并和等待响应的请求配对
+func (s *HTTPStream) parseResponses() ([]*HTTPExchange, error) {
+	var result []*HTTPExchange
+	for {
+		if s.respMsg == nil {
+			var ex *HTTPExchange
+			if len(s.pending) > 0 {
+				ex = s.pending[0]
+			} else if s.reqMsg == nil && len(s.req.buf) == 0 {
+				// 没有对应的请求
+				ex = &HTTPExchange{}
+			} else {
+				// 请求还不完整，等待请求
+				break
+			}
+			end := s.resp.headerEnd()
+			if end < 0 {
+				break
+			}
+			resp, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(s.resp.buf[:end])), ex.Request)
+			if err != nil {
+				s.broken = true
+				return result, fmt.Errorf("invalid response: %w", err)
+			}
+			msg := &httpMessage{
+				ex:         ex,
+				resp:       resp,
+				body:       newResponseBody(resp),
+				headerSize: end,
+			}
+			msg.start, msg.end = s.resp.consume(end)
+			s.respMsg = msg
+		}
+		done, err := s.readBody(&s.resp, s.respMsg)
+		if err != nil {
+			s.broken = true
+			return result, fmt.Errorf("invalid response: %w", err)
+		}
+		if !done {
+			break
+		}
+		if ex := s.finishResponse(); ex != nil {
+			result = append(result, ex)
+		}
+	}
+	return result, nil
+}
+
+// finishResponse 当前的响应已完整，若是 1xx 的响应则返回 nil
+func (s *HTTPStream) finishResponse() *HTTPExchange {
+	msg := s.respMsg
+	s.respMsg = nil
+	resp := msg.resp
+	if resp.StatusCode >= 100 && resp.StatusCode < 200 && resp.StatusCode != http.StatusSwitchingProtocols {
+		// 1xx 的响应之后，还有最终的响应
+		return nil
+	}
+	ex := msg.ex
+	ex.Response = resp
+	ex.ResponseBody = msg.body.data
+	ex.ResponseHeaderSize = msg.headerSize
+	ex.ResponseStart, ex.ResponseEnd = msg.start, msg.end
+	if len(s.pending) > 0 && s.pending[0] == ex {
+		s.pending = s.pending[1:]
+	}
+	return ex
+}
+
+// bodyMode body 的长度的确定方式
+type bodyMode uint8
+
+const (
+	bodyNone bodyMode = iota
+	bodyLength
+	bodyChunked
+	bodyUntilClose
+)
+
+// chunkState 读取 chunked body 的状态
+type chunkState uint8
+
+const (
+	chunkSize chunkState = iota
+	chunkData
+	chunkDataEnd
+	chunkTrailer
+)
+
+// maxChunkLine chunk size 行和 trailer 行的最大长度
+const maxChunkLine = 4096
+
+var errMalformedChunk = errors.New("malformed chunked encoding")
+
+// httpBody 增量的读取 body，已读取的数据不会再次解析
+type httpBody struct {
+	data []byte
+
+	// remain 固定长度的 body，或者当前 chunk 剩余的字节数
+	remain int64
+
+	mode  bodyMode
+	chunk chunkState
+}
+
+func newRequestBody(req *http.Request) httpBody {
+	switch {
+	case req.Body == http.NoBody:
+		return httpBody{mode: bodyNone}
+	case isChunked(req.TransferEncoding):
+		return httpBody{mode: bodyChunked}
+	case req.ContentLength > 0:
+		return httpBody{mode: bodyLength, remain: req.ContentLength}
+	default:
+		return httpBody{mode: bodyNone}
+	}
+}
+
+func newResponseBody(resp *http.Response) httpBody {
+	switch {
+	case resp.Body == http.NoBody:
+		return httpBody{mode: bodyNone}
+	case isChunked(resp.TransferEncoding):
+		return httpBody{mode: bodyChunked}
+	case resp.ContentLength >= 0:
+		return httpBody{mode: bodyLength, remain: resp.ContentLength}
+	default:
+		return httpBody{mode: bodyUntilClose}
+	}
+}
+
+func isChunked(te []string) bool {
+	return len(te) > 0 && te[0] == "chunked"
+}
+
+// read 从 b 的开头读取 body，返回使用的字节数，以及 body 是否已完整
+func (hb *httpBody) read(b []byte) (n int, done bool, err error) {
+	switch hb.mode {
+	case bodyLength:
+		size := int(min(hb.remain, int64(len(b))))
+		hb.data = append(hb.data, b[:size]...)
+		hb.remain -= int64(size)
+		return size, hb.remain == 0, nil
+	case bodyChunked:
+		return hb.readChunked(b)
+	case bodyUntilClose:
+		hb.data = append(hb.data, b...)
+		return len(b), false, nil
+	default:
+		return 0, true, nil
+	}
+}
+
+func (hb *httpBody) readChunked(b []byte) (n int, done bool, err error) {
+	for {
+		switch hb.chunk {
+		case chunkData:
+			size := int(min(hb.remain, int64(len(b)-n)))
+			hb.data = append(hb.data, b[n:n+size]...)
+			n += size
+			hb.remain -= int64(size)
+			if hb.remain > 0 {
+				return n, false, nil
+			}
+			hb.chunk = chunkDataEnd
+		case chunkDataEnd:
+			if len(b)-n < 2 {
+				return n, false, nil
+			}
+			if b[n] != '\r' || b[n+1] != '\n' {
+				return n, false, errMalformedChunk
+			}
+			n += 2
+			hb.chunk = chunkSize
+		default:
+			idx := bytes.Index(b[n:], []byte("\r\n"))
+			if idx < 0 {
+				if len(b)-n > maxChunkLine {
+					return n, false, errMalformedChunk
+				}
+				return n, false, nil
+			}
+			line := b[n : n+idx]
+			n += idx + 2
+			if hb.chunk == chunkTrailer {
+				if len(line) == 0 {
+					return n, true, nil
+				}
+				continue
+			}
+			sizeStr, _, _ := bytes.Cut(line, []byte(";"))
+			size, err := strconv.ParseInt(string(bytes.TrimSpace(sizeStr)), 16, 64)
+			if err != nil || size < 0 {
+				return n, false, errMalformedChunk
+			}
+			if size == 0 {
+				hb.chunk = chunkTrailer
+			} else {
+				hb.remain = size
+				hb.chunk = chunkData
+			}
+		}
+	}
+}
+
+// timedBuffer 数据流的缓存，并记录每段数据的时间
+type timedBuffer struct {
+	buf   []byte
+	marks []timedMark
+
+	// scanned 查找消息头结尾时，已检查过的字节数
+	scanned int
+}
+
+type timedMark struct {
+	tm  time.Time
+	end int
+}
+
+// headerEnd 返回消息头（包括结尾的空行）的长度，消息头还不完整时返回 -1
+func (tb *timedBuffer) headerEnd() int {
+	from := max(0, tb.scanned-3)
+	if idx := bytes.Index(tb.buf[from:], []byte("\r\n\r\n")); idx >= 0 {
+		return from + idx + 4
+	}
+	tb.scanned = len(tb.buf)
+	return -1
+}
+
+func (tb *timedBuffer) append(b []byte, tm time.Time) {
+	tb.buf = append(tb.buf, b...)
+	tb.marks = append(tb.marks, timedMark{end: len(tb.buf), tm: tm})
+}
+
+// consume 消费 n 个字节，返回这些数据的首个和最后一个字节的时间
+func (tb *timedBuffer) consume(n int) (first time.Time, last time.Time) {
+	for _, m := range tb.marks {
+		if first.IsZero() && m.end > 0 {
+			first = m.tm
+		}
+		if m.end >= n {
+			last = m.tm
+			break
+		}
+	}
+	tb.buf = tb.buf[n:]
+	tb.scanned = 0
+	marks := tb.marks[:0]
+	for _, m := range tb.marks {
+		m.end -= n
+		if m.end > 0 {
+			marks = append(marks, m)
+		}
+	}
+	tb.marks = marks
+	if len(tb.buf) == 0 {
+		tb.buf = nil
+	}
+	return first, last
+}
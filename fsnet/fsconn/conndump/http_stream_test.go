@@ -0,0 +1,106 @@
+// Copyright(C) 2026 github.com/fsgo  All Rights Reserved.
+// Author: hidu <duv123@gmail.com>
+// Date: 2026/10/19
+
+package conndump
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fsgo/fst"
+)
+
+func TestHTTPStream(t *testing.T) {
+	t.Run("pipeline", func(t *testing.T) {
+		s := &HTTPStream{}
+		now := time.Now()
+		reqs, resps, err := s.Write([]byte("HEAD /a HTTP/1.1\r\nHost: a.com\r\n\r\nGET /b HTTP/1.1\r\nHost: a.com\r\n\r\n"), true, now)
+		fst.NoError(t, err)
+		fst.Len(t, reqs, 2)
+		fst.Empty(t, resps)
+
+		// 100 Continue 之后是最终的响应，HEAD 请求的响应没有 body
+		reqs, resps, err = s.Write([]byte("HTTP/1.1 100 Continue\r\n\r\nHTTP/1.1 200 OK\r\nContent-Length: 5\r\n\r\n"+
+			"HTTP/1.1 200 OK\r\nContent-Length: 5\r\n\r\nhel"), false, now)
+		fst.NoError(t, err)
+		fst.Empty(t, reqs)
+		fst.Len(t, resps, 1)
+		fst.Equal(t, "HEAD", resps[0].Request.Method)
+		fst.Equal(t, 200, resps[0].Response.StatusCode)
+		fst.Empty(t, resps[0].ResponseBody)
+
+		_, resps, err = s.Write([]byte("lo"), false, now)
+		fst.NoError(t, err)
+		fst.Len(t, resps, 1)
+		fst.Equal(t, "/b", resps[0].Request.URL.Path)
+		fst.Equal(t, "hello", string(resps[0].ResponseBody))
+
+		resps, unanswered := s.Close()
+		fst.Empty(t, resps)
+		fst.Empty(t, unanswered)
+	})
+
+	t.Run("close", func(t *testing.T) {
+		s := &HTTPStream{}
+		now := time.Now()
+		_, _, err := s.Write([]byte("GET /a HTTP/1.0\r\n\r\nGET /b HTTP/1.0\r\n\r\n"), true, now)
+		fst.NoError(t, err)
+		_, resps, err := s.Write([]byte("HTTP/1.0 200 OK\r\n\r\nhel"), false, now)
+		fst.NoError(t, err)
+		fst.Empty(t, resps)
+
+		resps, unanswered := s.Close()
+		fst.Len(t, resps, 1)
+		fst.Equal(t, "/a", resps[0].Request.URL.Path)
+		fst.Equal(t, "hel", string(resps[0].ResponseBody))
+		fst.Len(t, unanswered, 1)
+		fst.Equal(t, "/b", unanswered[0].Request.URL.Path)
+		fst.Nil(t, unanswered[0].Response)
+	})
+
+	t.Run("chunked", func(t *testing.T) {
+		s := &HTTPStream{}
+		start := time.Date(2026, 10, 19, 8, 0, 0, 0, time.UTC)
+		_, _, err := s.Write([]byte("POST /a HTTP/1.1\r\nTransfer-Encoding: chunked\r\n\r\n"), true, start)
+		fst.NoError(t, err)
+		// 每个字节一条消息，已读取的数据不会重复解析
+		data := "5;ext=1\r\nhello\r\n6\r\n world\r\n0\r\nX-Trailer: 1\r\n\r\n"
+		var reqs []*HTTPExchange
+		for i := 0; i < len(data); i++ {
+			got, _, err := s.Write([]byte{data[i]}, true, start.Add(time.Duration(i)*time.Millisecond))
+			fst.NoError(t, err)
+			reqs = append(reqs, got...)
+		}
+		fst.Len(t, reqs, 1)
+		fst.Equal(t, "hello world", string(reqs[0].RequestBody))
+		fst.Equal(t, start, reqs[0].RequestStart)
+		fst.Equal(t, start.Add(time.Duration(len(data)-1)*time.Millisecond), reqs[0].RequestEnd)
+
+		var resps []*HTTPExchange
+		resp := "HTTP/1.1 200 OK\r\nContent-Length: 1000\r\n\r\n" + strings.Repeat("a", 1000)
+		for i := 0; i < len(resp); i += 10 {
+			_, got, err := s.Write([]byte(resp[i:min(i+10, len(resp))]), false, start)
+			fst.NoError(t, err)
+			resps = append(resps, got...)
+		}
+		fst.Len(t, resps, 1)
+		fst.Equal(t, 1000, len(resps[0].ResponseBody))
+	})
+
+	t.Run("invalid chunk", func(t *testing.T) {
+		s := &HTTPStream{}
+		_, _, err := s.Write([]byte("POST /a HTTP/1.1\r\nTransfer-Encoding: chunked\r\n\r\nzz\r\n"), true, time.Now())
+		fst.Error(t, err)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		s := &HTTPStream{}
+		_, _, err := s.Write([]byte("HTTP/1.1 abc\r\n\r\n"), false, time.Now())
+		fst.Error(t, err)
+		_, resps, err := s.Write([]byte("HTTP/1.1 200 OK\r\nContent-Length: 0\r\n\r\n"), false, time.Now())
+		fst.NoError(t, err)
+		fst.Empty(t, resps)
+	})
+}
@@ -0,0 +1,38 @@
+// Copyright(C) 2026 github.com/fsgo  All Rights Reserved.
+// Author: hidu <duv123@gmail.com>
+// Date: 2026/10/19
+
+package conndump
+
+// Remote 返回对端地址，兼容只有 Addr 字段的旧数据
+func (x *Message) Remote() string {
+	if v := x.GetRemoteAddr(); len(v) > 0 {
+		return v
+	}
+	return x.GetAddr()
+}
+
+// FromClient 返回消息是否是由客户端一侧记录的，
+// 旧数据没有 Direction 字段，此时 known 为 false
+func (x *Message) FromClient() (client bool, known bool) {
+	dir := x.GetDirection()
+	if dir == Direction_DirectionUnknown {
+		return false, false
+	}
+	// Read 消息是对端发出的，Write 和 Close 消息是本端发出的
+	fromLocal := x.GetAction() != MessageAction_Read
+	return fromLocal == (dir == Direction_ClientToServer), true
+}
+
+// ClientToServer 返回数据是否是客户端发送给服务端的
+//
+// 旧数据没有 Direction 字段，此时使用 serverSide 判断：serverSide 表示数据是否是 server 端 dump 的
+func (x *Message) ClientToServer(serverSide bool) bool {
+	if dir := x.GetDirection(); dir != Direction_DirectionUnknown {
+		return dir == Direction_ClientToServer
+	}
+	if serverSide {
+		return x.GetAction() == MessageAction_Read
+	}
+	return x.GetAction() == MessageAction_Write
+}
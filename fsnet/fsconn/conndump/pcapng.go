@@ -0,0 +1,363 @@
+// Copyright(C) 2026 github.com/fsgo  All Rights Reserved.
+// Author: hidu <duv123@gmail.com>
+// Date: 2026/10/19
+
+package conndump
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net/netip"
+	"sync"
+)
+
+// pcapng 格式，见 https://www.ietf.org/archive/id/draft-tuexen-opsawg-pcapng-05.html
+const (
+	pcapngBlockSHB = 0x0A0D0D0A
+	pcapngBlockIDB = 0x00000001
+	pcapngBlockEPB = 0x00000006
+
+	pcapngByteOrderMagic = 0x1A2B3C4D
+
+	// pcapngLinkTypeRaw 数据包以 IPv4 或者 IPv6 头开始
+	pcapngLinkTypeRaw = 101
+
+	pcapngOptEnd      = 0
+	pcapngOptComment  = 1
+	pcapngOptTSResol  = 9
+	pcapngSnapLen     = 262144
+	pcapngMaxSegment  = 65000
+	pcapngLocalPort   = 20000
+	pcapngLocalPortsN = 40000
+)
+
+// TCP 标志位
+const (
+	tcpFIN = 0x01
+	tcpSYN = 0x02
+	tcpPSH = 0x08
+	tcpACK = 0x10
+)
+
+// PcapngWriter 将 Message 转换为 pcapng 格式，以便使用 Wireshark 分析
+//
+// 会按照 LocalAddr、RemoteAddr（旧数据为 Addr）、ConnID 和消息的方向生成 TCP/IP 头：
+// 每个连接的首条消息前会生成 TCP 握手，Close 消息会生成 FIN
+type PcapngWriter struct {
+	// Writer 输出，必填
+	Writer io.Writer
+
+	conns map[int64]*pcapConn
+
+	err error
+
+	// LocalAddr 本端的地址，可选，如 "10.0.0.1:8080"
+	// 为空时 IP 使用 127.0.0.1（IPv6 使用 ::1），端口为 0 时按照 ConnID 生成
+	LocalAddr string
+
+	local netip.AddrPort
+
+	once sync.Once
+
+	ipID uint16
+
+	// RemoteIsClient 对端是否为客户端（如 server 端的 dump 数据），用于生成 TCP 握手的方向
+	// 若消息有 Direction 字段，则以消息的为准
+	RemoteIsClient bool
+}
+
+type pcapConn struct {
+	local     netip.AddrPort
+	remote    netip.AddrPort
+	localSeq  uint32
+	remoteSeq uint32
+
+	// fromLocal 是否由本端发起的连接
+	fromLocal bool
+}
+
+func (pw *PcapngWriter) init() {
+	pw.conns = make(map[int64]*pcapConn)
+	if len(pw.LocalAddr) > 0 {
+		pw.local, pw.err = netip.ParseAddrPort(pw.LocalAddr)
+		if pw.err != nil {
+			return
+		}
+	}
+	pw.err = pw.writeHeader()
+}
+
+func (pw *PcapngWriter) writeHeader() error {
+	shb := make([]byte, 16)
+	binary.LittleEndian.PutUint32(shb[0:], pcapngByteOrderMagic)
+	binary.LittleEndian.PutUint16(shb[4:], 1)
+	binary.LittleEndian.PutUint16(shb[6:], 0)
+	// Section Length: -1，未指定
+	binary.LittleEndian.PutUint64(shb[8:], ^uint64(0))
+	if err := pw.writeBlock(pcapngBlockSHB, shb); err != nil {
+		return err
+	}
+
+	idb := make([]byte, 8)
+	binary.LittleEndian.PutUint16(idb[0:], pcapngLinkTypeRaw)
+	binary.LittleEndian.PutUint32(idb[4:], pcapngSnapLen)
+	// 时间戳精度为纳秒
+	idb = appendPcapngOption(idb, pcapngOptTSResol, []byte{9})
+	idb = appendPcapngOption(idb, pcapngOptEnd, nil)
+	return pw.writeBlock(pcapngBlockIDB, idb)
+}
+
+func appendPcapngOption(b []byte, code uint16, value []byte) []byte {
+	b = binary.LittleEndian.AppendUint16(b, code)
+	b = binary.LittleEndian.AppendUint16(b, uint16(len(value)))
+	b = append(b, value...)
+	return appendPadding(b)
+}
+
+func appendPadding(b []byte) []byte {
+	for len(b)%4 != 0 {
+		b = append(b, 0)
+	}
+	return b
+}
+
+func (pw *PcapngWriter) writeBlock(typ uint32, body []byte) error {
+	total := uint32(12 + len(body))
+	b := make([]byte, 0, total)
+	b = binary.LittleEndian.AppendUint32(b, typ)
+	b = binary.LittleEndian.AppendUint32(b, total)
+	b = append(b, body...)
+	b = binary.LittleEndian.AppendUint32(b, total)
+	_, err := pw.Writer.Write(b)
+	return err
+}
+
+// WriteMessage 将一条消息转换为 pcapng 的数据包写入
+func (pw *PcapngWriter) WriteMessage(msg *Message) error {
+	pw.once.Do(pw.init)
+	if pw.err != nil {
+		return pw.err
+	}
+	conn, isNew := pw.getConn(msg)
+	ts := uint64(msg.GetTime().AsTime().UnixNano())
+	comment := fmt.Sprintf("ID=%d Service=%s ConnID=%d SubID=%d Action=%s",
+		msg.GetID(), msg.GetService(), msg.GetConnID(), msg.GetSubID(), msg.GetAction())
+	if isNew {
+		if err := pw.handshake(conn, ts); err != nil {
+			return err
+		}
+	}
+	switch msg.GetAction() {
+	case MessageAction_Read:
+		return pw.writeData(conn, false, msg.GetPayload(), ts, comment)
+	case MessageAction_Write:
+		return pw.writeData(conn, true, msg.GetPayload(), ts, comment)
+	case MessageAction_Close:
+		delete(pw.conns, msg.GetConnID())
+		if err := pw.writeSegment(conn, true, tcpFIN|tcpACK, nil, ts, comment); err != nil {
+			return err
+		}
+		conn.localSeq++
+		return pw.writeSegment(conn, false, tcpACK, nil, ts, "")
+	default:
+		return nil
+	}
+}
+
+// Convert 读取 dump 数据，全部转换为 pcapng 格式
+func (pw *PcapngWriter) Convert(rd io.Reader) error {
+	var err error
+	errScan := Scan(rd, func(msg *Message) bool {
+		err = pw.WriteMessage(msg)
+		return err == nil
+	})
+	if err != nil {
+		return err
+	}
+	return errScan
+}
+
+func (pw *PcapngWriter) getConn(msg *Message) (*pcapConn, bool) {
+	if c, ok := pw.conns[msg.GetConnID()]; ok {
+		return c, false
+	}
+	remote, err := netip.ParseAddrPort(msg.Remote())
+	if err != nil {
+		// 如 unix socket 等非 IP 的地址
+		remote = netip.AddrPortFrom(netip.AddrFrom4([4]byte{127, 0, 0, 2}), 0)
+	}
+	remote = netip.AddrPortFrom(remote.Addr().Unmap(), remote.Port())
+
+	local := pw.local
+	if !local.IsValid() {
+		// 新版本的数据有记录本端地址
+		local, _ = netip.ParseAddrPort(msg.GetLocalAddr())
+	}
+	localIP := local.Addr().Unmap()
+	if !localIP.IsValid() || localIP.Is4() != remote.Addr().Is4() {
+		if remote.Addr().Is4() {
+			localIP = netip.AddrFrom4([4]byte{127, 0, 0, 1})
+		} else {
+			localIP = netip.IPv6Loopback()
+		}
+	}
+	localPort := local.Port()
+	if localPort == 0 {
+		localPort = uint16(pcapngLocalPort + msg.GetConnID()%pcapngLocalPortsN)
+	}
+	c := &pcapConn{
+		local:     netip.AddrPortFrom(localIP, localPort),
+		remote:    remote,
+		localSeq:  uint32(msg.GetConnID()) * 1000003,
+		remoteSeq: uint32(msg.GetConnID()) * 2000003,
+		fromLocal: !pw.RemoteIsClient,
+	}
+	if client, known := msg.FromClient(); known {
+		c.fromLocal = client
+	}
+	pw.conns[msg.GetConnID()] = c
+	return c, true
+}
+
+// handshake 生成 TCP 三次握手
+func (pw *PcapngWriter) handshake(c *pcapConn, ts uint64) error {
+	fromLocal := c.fromLocal
+	if err := pw.writeSegment(c, fromLocal, tcpSYN, nil, ts, ""); err != nil {
+		return err
+	}
+	pw.advance(c, fromLocal, 1)
+	if err := pw.writeSegment(c, !fromLocal, tcpSYN|tcpACK, nil, ts, ""); err != nil {
+		return err
+	}
+	pw.advance(c, !fromLocal, 1)
+	return pw.writeSegment(c, fromLocal, tcpACK, nil, ts, "")
+}
+
+func (pw *PcapngWriter) advance(c *pcapConn, fromLocal bool, n int) {
+	if fromLocal {
+		c.localSeq += uint32(n)
+	} else {
+		c.remoteSeq += uint32(n)
+	}
+}
+
+// writeData 写入数据，过大时拆分为多个 TCP 报文
+func (pw *PcapngWriter) writeData(c *pcapConn, fromLocal bool, payload []byte, ts uint64, comment string) error {
+	for len(payload) > 0 {
+		size := min(len(payload), pcapngMaxSegment)
+		if err := pw.writeSegment(c, fromLocal, tcpPSH|tcpACK, payload[:size], ts, comment); err != nil {
+			return err
+		}
+		pw.advance(c, fromLocal, size)
+		payload = payload[size:]
+		comment = ""
+	}
+	return nil
+}
+
+func (pw *PcapngWriter) writeSegment(c *pcapConn, fromLocal bool, flags byte, payload []byte, ts uint64, comment string) error {
+	src, dst := c.local, c.remote
+	seq, ack := c.localSeq, c.remoteSeq
+	if !fromLocal {
+		src, dst = dst, src
+		seq, ack = ack, seq
+	}
+	if flags&tcpACK == 0 {
+		ack = 0
+	}
+	tcp := make([]byte, 20, 20+len(payload))
+	binary.BigEndian.PutUint16(tcp[0:], src.Port())
+	binary.BigEndian.PutUint16(tcp[2:], dst.Port())
+	binary.BigEndian.PutUint32(tcp[4:], seq)
+	binary.BigEndian.PutUint32(tcp[8:], ack)
+	tcp[12] = 5 << 4
+	tcp[13] = flags
+	binary.BigEndian.PutUint16(tcp[14:], 65535)
+	tcp = append(tcp, payload...)
+	binary.BigEndian.PutUint16(tcp[16:], tcpChecksum(src.Addr(), dst.Addr(), tcp))
+
+	pw.ipID++
+	packet := ipPacket(src.Addr(), dst.Addr(), tcp, pw.ipID)
+	return pw.writePacket(packet, ts, comment)
+}
+
+func (pw *PcapngWriter) writePacket(packet []byte, ts uint64, comment string) error {
+	b := make([]byte, 20, 20+len(packet)+len(comment)+16)
+	binary.LittleEndian.PutUint32(b[0:], 0)
+	binary.LittleEndian.PutUint32(b[4:], uint32(ts>>32))
+	binary.LittleEndian.PutUint32(b[8:], uint32(ts))
+	binary.LittleEndian.PutUint32(b[12:], uint32(len(packet)))
+	binary.LittleEndian.PutUint32(b[16:], uint32(len(packet)))
+	b = append(b, packet...)
+	b = appendPadding(b)
+	if len(comment) > 0 {
+		b = appendPcapngOption(b, pcapngOptComment, []byte(comment))
+		b = appendPcapngOption(b, pcapngOptEnd, nil)
+	}
+	return pw.writeBlock(pcapngBlockEPB, b)
+}
+
+// ipPacket 生成 IPv4 或者 IPv6 数据包
+func ipPacket(src, dst netip.Addr, tcp []byte, id uint16) []byte {
+	if src.Is4() {
+		b := make([]byte, 20, 20+len(tcp))
+		b[0] = 0x45
+		binary.BigEndian.PutUint16(b[2:], uint16(20+len(tcp)))
+		binary.BigEndian.PutUint16(b[4:], id)
+		// Don't Fragment
+		binary.BigEndian.PutUint16(b[6:], 0x4000)
+		b[8] = 64
+		b[9] = 6
+		s4, d4 := src.As4(), dst.As4()
+		copy(b[12:], s4[:])
+		copy(b[16:], d4[:])
+		binary.BigEndian.PutUint16(b[10:], checksum(b, 0))
+		return append(b, tcp...)
+	}
+	b := make([]byte, 40, 40+len(tcp))
+	b[0] = 0x60
+	binary.BigEndian.PutUint16(b[4:], uint16(len(tcp)))
+	b[6] = 6
+	b[7] = 64
+	s16, d16 := src.As16(), dst.As16()
+	copy(b[8:], s16[:])
+	copy(b[24:], d16[:])
+	return append(b, tcp...)
+}
+
+// tcpChecksum 计算 TCP 校验和，包括伪首部
+func tcpChecksum(src, dst netip.Addr, tcp []byte) uint16 {
+	var sum uint32
+	add := func(b []byte) {
+		for i := 0; i+1 < len(b); i += 2 {
+			sum += uint32(binary.BigEndian.Uint16(b[i:]))
+		}
+	}
+	if src.Is4() {
+		s, d := src.As4(), dst.As4()
+		add(s[:])
+		add(d[:])
+	} else {
+		s, d := src.As16(), dst.As16()
+		add(s[:])
+		add(d[:])
+	}
+	sum += 6
+	sum += uint32(len(tcp))
+	return checksum(tcp, sum)
+}
+
+// checksum 计算 Internet 校验和（RFC 1071）
+func checksum(b []byte, sum uint32) uint16 {
+	for i := 0; i+1 < len(b); i += 2 {
+		sum += uint32(binary.BigEndian.Uint16(b[i:]))
+	}
+	if len(b)%2 == 1 {
+		sum += uint32(b[len(b)-1]) << 8
+	}
+	for sum > 0xffff {
+		sum = (sum >> 16) + (sum & 0xffff)
+	}
+	return ^uint16(sum)
+}
@@ -0,0 +1,80 @@
+// Copyright(C) 2026 github.com/fsgo  All Rights Reserved.
+// Author: hidu <duv123@gmail.com>
+// Date: 2026/10/19
+
+package conndump
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/fsgo/fst"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestPcapngWriter(t *testing.T) {
+	bf := &bytes.Buffer{}
+	pw := &PcapngWriter{
+		Writer: bf,
+	}
+	now := timestamppb.New(time.Now())
+	msgs := []*Message{
+		{ID: 1, ConnID: 1, Addr: "10.0.0.2:80", Action: MessageAction_Write, Payload: []byte("GET / HTTP/1.1\r\n\r\n"), Time: now},
+		{ID: 2, ConnID: 1, Addr: "10.0.0.2:80", Action: MessageAction_Read, Payload: []byte("HTTP/1.1 200 OK\r\n\r\n"), Time: now},
+		{ID: 3, ConnID: 2, Addr: "[::1]:6379", Action: MessageAction_Write, Payload: []byte("PING\r\n"), Time: now},
+		{ID: 4, ConnID: 1, Addr: "10.0.0.2:80", Action: MessageAction_Close, Time: now},
+	}
+	for _, msg := range msgs {
+		fst.NoError(t, pw.WriteMessage(msg))
+	}
+
+	var types []uint32
+	var packets [][]byte
+	data := bf.Bytes()
+	for len(data) > 0 {
+		fst.True(t, len(data) >= 12)
+		typ := binary.LittleEndian.Uint32(data)
+		total := binary.LittleEndian.Uint32(data[4:])
+		fst.Equal(t, total, binary.LittleEndian.Uint32(data[total-4:]))
+		fst.Equal(t, uint32(0), total%4)
+		types = append(types, typ)
+		if typ == pcapngBlockEPB {
+			capLen := binary.LittleEndian.Uint32(data[20:])
+			packets = append(packets, data[28:28+capLen])
+		}
+		data = data[total:]
+	}
+	fst.Equal(t, uint32(pcapngBlockSHB), types[0])
+	fst.Equal(t, uint32(pcapngBlockIDB), types[1])
+	// 2 个连接各 3 个握手包，3 个数据包，FIN 和 ACK
+	fst.Len(t, packets, 3+3+3+2)
+
+	for _, p := range packets {
+		if p[0]>>4 == 4 {
+			fst.Equal(t, uint16(0), checksum(p[:20], 0))
+			src, _ := netip.AddrFromSlice(p[12:16])
+			dst, _ := netip.AddrFromSlice(p[16:20])
+			fst.Equal(t, uint16(0), tcpChecksum(src, dst, p[20:]))
+		}
+	}
+
+	// 第一个数据包，本端写出的数据
+	p := packets[3]
+	fst.Equal(t, byte(0x45), p[0])
+	fst.Equal(t, []byte{127, 0, 0, 1}, p[12:16])
+	fst.Equal(t, []byte{10, 0, 0, 2}, p[16:20])
+	fst.Equal(t, uint16(80), binary.BigEndian.Uint16(p[22:]))
+	fst.Equal(t, byte(tcpPSH|tcpACK), p[20+13])
+	fst.Equal(t, "GET / HTTP/1.1\r\n\r\n", string(p[40:]))
+
+	// 读取到的数据，方向相反，seq 为握手后的值
+	p2 := packets[4]
+	fst.Equal(t, []byte{10, 0, 0, 2}, p2[12:16])
+	fst.Equal(t, binary.BigEndian.Uint32(p[20+8:]), binary.BigEndian.Uint32(p2[20+4:]))
+
+	// IPv6
+	fst.Equal(t, byte(0x60), packets[5][0])
+}
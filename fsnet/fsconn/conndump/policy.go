@@ -0,0 +1,193 @@
+// Copyright(C) 2026 github.com/fsgo  All Rights Reserved.
+// Author: hidu <duv123@gmail.com>
+// Date: 2026/10/19
+
+package conndump
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"slices"
+	"strings"
+	"sync/atomic"
+
+	"github.com/fsgo/fsgo/fsio"
+	"github.com/fsgo/fsgo/fsnet/fsconn"
+)
+
+// Policy dump 的采样、过滤和限制规则，可以通过 Dumper.SetPolicy 在运行时调整
+//
+// 是否 dump 一个连接在其首次 Read 或 Write 时决定，之后不再变化；
+// MaxBytesPerConn 和 BytesPerSecond 的调整对已有连接立即生效
+type Policy struct {
+	// AllowCIDRs 只 dump 对端地址属于这些网段的连接，可选，如 "10.0.0.0/8"、"192.168.1.1"
+	AllowCIDRs []string
+
+	// DenyCIDRs 不 dump 对端地址属于这些网段的连接，可选，优先于 AllowCIDRs
+	DenyCIDRs []string
+
+	// RemotePorts 只 dump 对端端口为这些的连接，可选
+	RemotePorts []uint16
+
+	// LocalPorts 只 dump 本端端口为这些的连接，可选，如 server 监听的端口
+	LocalPorts []uint16
+
+	// SampleRate 连接级别的采样，每 N 个连接 dump 1 个，可选，<= 1 时全部 dump
+	SampleRate int64
+
+	// MaxBytesPerConn 每个连接最多 dump 的数据量，可选，<= 0 时不限制
+	// 超过后不再记录此连接的 Read 和 Write，Close 依然会记录
+	MaxBytesPerConn int64
+
+	// BytesPerSecond 全局每秒 dump 数据量的上限，可选，<= 0 时不限制
+	// 超过后的数据会被丢弃，不会阻塞连接的读写
+	BytesPerSecond int64
+
+	allow []netip.Prefix
+	deny  []netip.Prefix
+}
+
+func parsePrefixes(list []string) ([]netip.Prefix, error) {
+	result := make([]netip.Prefix, 0, len(list))
+	for _, v := range list {
+		v = strings.TrimSpace(v)
+		if !strings.Contains(v, "/") {
+			addr, err := netip.ParseAddr(v)
+			if err != nil {
+				return nil, fmt.Errorf("invalid CIDR %q: %w", v, err)
+			}
+			result = append(result, netip.PrefixFrom(addr, addr.BitLen()))
+			continue
+		}
+		p, err := netip.ParsePrefix(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CIDR %q: %w", v, err)
+		}
+		result = append(result, p.Masked())
+	}
+	return result, nil
+}
+
+func (p *Policy) parse() error {
+	var err error
+	if p.allow, err = parsePrefixes(p.AllowCIDRs); err != nil {
+		return err
+	}
+	p.deny, err = parsePrefixes(p.DenyCIDRs)
+	return err
+}
+
+func addrPort(addr net.Addr) netip.AddrPort {
+	if addr == nil {
+		return netip.AddrPort{}
+	}
+	if ta, ok := addr.(*net.TCPAddr); ok {
+		return ta.AddrPort()
+	}
+	ap, _ := netip.ParseAddrPort(addr.String())
+	return ap
+}
+
+func containsAddr(list []netip.Prefix, addr netip.Addr) bool {
+	for _, p := range list {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// match 连接是否符合过滤条件
+func (p *Policy) match(conn fsconn.Info) bool {
+	remote := addrPort(conn.RemoteAddr())
+	ip := remote.Addr().Unmap()
+	if len(p.deny) > 0 && ip.IsValid() && containsAddr(p.deny, ip) {
+		return false
+	}
+	if len(p.allow) > 0 && (!ip.IsValid() || !containsAddr(p.allow, ip)) {
+		return false
+	}
+	if len(p.RemotePorts) > 0 && !slices.Contains(p.RemotePorts, remote.Port()) {
+		return false
+	}
+	if len(p.LocalPorts) > 0 && !slices.Contains(p.LocalPorts, addrPort(conn.LocalAddr()).Port()) {
+		return false
+	}
+	return true
+}
+
+// policyState Dumper 当前的 Policy 及其状态
+type policyState struct {
+	policy  atomic.Pointer[Policy]
+	limiter fsio.RateLimiter
+
+	// connNum 用于采样的连接计数
+	connNum atomic.Int64
+}
+
+func (ps *policyState) get() *Policy {
+	return ps.policy.Load()
+}
+
+func (ps *policyState) set(p *Policy) error {
+	if p == nil {
+		p = &Policy{}
+	}
+	cp := *p
+	if err := cp.parse(); err != nil {
+		return err
+	}
+	ps.limiter.SetRate(cp.BytesPerSecond, 0)
+	ps.policy.Store(&cp)
+	return nil
+}
+
+// sample 新的连接是否需要 dump
+func (ps *policyState) sample(conn fsconn.Info) bool {
+	p := ps.get()
+	if p == nil {
+		return true
+	}
+	if !p.match(conn) {
+		return false
+	}
+	if p.SampleRate <= 1 {
+		return true
+	}
+	return (ps.connNum.Add(1)-1)%p.SampleRate == 0
+}
+
+// allowSize 返回此次允许 dump 的字节数
+func (ps *policyState) allowSize(ci *connInfo, size int) int {
+	p := ps.get()
+	if p == nil || size <= 0 {
+		return size
+	}
+	if p.MaxBytesPerConn > 0 {
+		left := p.MaxBytesPerConn - ci.dumpedBytes.Load()
+		if left <= 0 {
+			return 0
+		}
+		size = int(min(int64(size), left))
+	}
+	if !ps.limiter.AllowN(size) {
+		return 0
+	}
+	ci.dumpedBytes.Add(int64(size))
+	return size
+}
+
+// SetPolicy 设置 dump 的采样、过滤和限制规则，可以在运行时调用，传入 nil 表示不限制
+func (d *Dumper) SetPolicy(p *Policy) error {
+	return d.policy.set(p)
+}
+
+// Policy 返回当前的 dump 规则
+func (d *Dumper) Policy() *Policy {
+	if p := d.policy.get(); p != nil {
+		cp := *p
+		return &cp
+	}
+	return &Policy{}
+}
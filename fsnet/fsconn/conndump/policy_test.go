@@ -0,0 +1,69 @@
+// Copyright(C) 2026 github.com/fsgo  All Rights Reserved.
+// Author: hidu <duv123@gmail.com>
+// Date: 2026/10/19
+
+package conndump
+
+import (
+	"net"
+	"testing"
+
+	"github.com/fsgo/fst"
+)
+
+type testConnInfo struct {
+	local  net.Addr
+	remote net.Addr
+}
+
+func (c *testConnInfo) LocalAddr() net.Addr {
+	return c.local
+}
+
+func (c *testConnInfo) RemoteAddr() net.Addr {
+	return c.remote
+}
+
+func newTestConnInfo(local string, remote string) *testConnInfo {
+	l, _ := net.ResolveTCPAddr("tcp", local)
+	r, _ := net.ResolveTCPAddr("tcp", remote)
+	return &testConnInfo{local: l, remote: r}
+}
+
+func TestPolicy(t *testing.T) {
+	var ps policyState
+	fst.True(t, ps.sample(newTestConnInfo("127.0.0.1:80", "10.0.0.1:1234")))
+
+	fst.Error(t, ps.set(&Policy{AllowCIDRs: []string{"abc"}}))
+
+	fst.NoError(t, ps.set(&Policy{
+		AllowCIDRs: []string{"10.0.0.0/8", "192.168.1.1"},
+		DenyCIDRs:  []string{"10.1.0.0/16"},
+		LocalPorts: []uint16{80},
+	}))
+	fst.True(t, ps.sample(newTestConnInfo("127.0.0.1:80", "10.0.0.1:1234")))
+	fst.True(t, ps.sample(newTestConnInfo("127.0.0.1:80", "192.168.1.1:1234")))
+	fst.False(t, ps.sample(newTestConnInfo("127.0.0.1:80", "192.168.1.2:1234")))
+	fst.False(t, ps.sample(newTestConnInfo("127.0.0.1:80", "10.1.0.1:1234")))
+	fst.False(t, ps.sample(newTestConnInfo("127.0.0.1:81", "10.0.0.1:1234")))
+
+	fst.NoError(t, ps.set(&Policy{SampleRate: 3}))
+	var sampled int
+	for i := 0; i < 9; i++ {
+		if ps.sample(newTestConnInfo("127.0.0.1:80", "10.0.0.1:1234")) {
+			sampled++
+		}
+	}
+	fst.Equal(t, 3, sampled)
+
+	fst.NoError(t, ps.set(&Policy{MaxBytesPerConn: 10}))
+	ci := &connInfo{}
+	fst.Equal(t, 6, ps.allowSize(ci, 6))
+	fst.Equal(t, 4, ps.allowSize(ci, 6))
+	fst.Equal(t, 0, ps.allowSize(ci, 6))
+
+	fst.NoError(t, ps.set(&Policy{BytesPerSecond: 10}))
+	ci = &connInfo{}
+	fst.Equal(t, 8, ps.allowSize(ci, 8))
+	fst.Equal(t, 0, ps.allowSize(ci, 8))
+}
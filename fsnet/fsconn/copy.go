@@ -9,11 +9,13 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"math/rand/v2"
 	"net"
 	"sync"
 	"sync/atomic"
 	"time"
 
+	"github.com/fsgo/fsgo/fsio"
 	"github.com/fsgo/fsgo/fsnet/fsaddr"
 )
 
@@ -72,6 +74,11 @@ func (cc *Copy) EnableWrite(enable bool) {
 var _ io.ReadWriteCloser = (*StreamConn)(nil)
 
 // StreamConn 一个总是保持连接的 WriterReader
+//
+// 设置 Buffered 后，可以作为可靠的日志传输通道：
+// Write 只将数据写入缓冲区（内存，以及可选的磁盘），由后台协程发送，
+// 连接断开时数据保留在缓冲区，重连后继续发送，Close 时会等待缓冲区的数据发送完成。
+// 后台协程在第一次调用 Write、Read 或者 Flush 时才开始拨号，在此之前 Stats、RemoteAddr 返回零值
 type StreamConn struct {
 	// Addr 要连接的网络地址，必填
 	Addr net.Addr
@@ -98,7 +105,45 @@ type StreamConn struct {
 	// 默认值为 1s
 	RetryWait time.Duration
 
-	mux sync.Mutex
+	// MaxRetryWait 可选，重试等待间隔的上限，
+	// 大于 RetryWait 时，等待间隔从 RetryWait 开始按照指数增长，默认等于 RetryWait
+	MaxRetryWait time.Duration
+
+	// Jitter 可选，重试等待间隔的随机波动比例，取值范围 [0,1]，
+	// 如 0.2 表示在 ±20% 范围内波动，默认为 0
+	Jitter float64
+
+	// Buffered 是否启用写缓冲，启用后 Retry 参数无效，会一直重试直到 Close
+	Buffered bool
+
+	// BufferSize 可选，Buffered 模式下内存缓冲区的大小，默认为 4MB
+	// 未确认的数据（AckReader）最多也占用 BufferSize 的内存
+	BufferSize int64
+
+	// SpillDir 可选，Buffered 模式下，内存缓冲区满后，将数据写入此目录下的临时文件
+	SpillDir string
+
+	// MaxSpillSize 可选，磁盘缓冲的最大字节数，<= 0 时不限制
+	MaxSpillSize int64
+
+	// Overflow 可选，Buffered 模式下，缓冲区满时的处理策略，默认为 fsio.OverflowBlock
+	Overflow fsio.OverflowPolicy
+
+	// AckReader 可选，Buffered 模式下，读取对端确认的数据
+	// 返回新确认的字节数，返回 error 时连接会被重置
+	// 设置后，已发送但是未确认的数据在重连后会重新发送（至少一次），并且不能再调用 Read
+	// 未设置时，数据写入连接成功即认为发送成功
+	AckReader func(r io.Reader) (int64, error)
+
+	// DrainTimeout 可选，Buffered 模式下，Close 时等待缓冲区的数据发送完成的最长时间，默认为 5s
+	DrainTimeout time.Duration
+
+	buffered atomic.Pointer[streamBuffered]
+
+	stats streamStats
+
+	mux      sync.Mutex
+	buffOnce sync.Once
 
 	// 关闭状态，0-正常，1-已关闭
 	closed atomic.Bool
@@ -128,8 +173,8 @@ func (sc *StreamConn) checkConn() error {
 		if !sc.canTry(try) {
 			return err
 		}
+		time.Sleep(sc.backoff(try))
 		try++
-		time.Sleep(sc.getRetryWait())
 	}
 }
 
@@ -151,8 +196,32 @@ func (sc *StreamConn) getRetryWait() time.Duration {
 	return time.Second
 }
 
+// backoff 第 try 次重试前的等待时间
+func (sc *StreamConn) backoff(try int) time.Duration {
+	wait := sc.getRetryWait()
+	if sc.MaxRetryWait > wait {
+		for i := 0; i < try && wait < sc.MaxRetryWait; i++ {
+			wait *= 2
+		}
+		wait = min(wait, sc.MaxRetryWait)
+	}
+	if sc.Jitter > 0 {
+		jitter := min(sc.Jitter, 1)
+		wait = time.Duration(float64(wait) * (1 - jitter + 2*jitter*rand.Float64()))
+	}
+	return wait
+}
+
 func (sc *StreamConn) connect() error {
-	ctx, cancel := context.WithTimeout(context.Background(), sc.getDialTimeout())
+	conn, err := sc.dial(context.Background())
+	if conn != nil {
+		sc.conn = conn
+	}
+	return err
+}
+
+func (sc *StreamConn) dial(ctx context.Context) (net.Conn, error) {
+	ctx, cancel := context.WithTimeout(ctx, sc.getDialTimeout())
 	defer cancel()
 	var conn net.Conn
 	var err error
@@ -161,13 +230,18 @@ func (sc *StreamConn) connect() error {
 	} else {
 		conn, err = zeroDialer.DialContext(ctx, sc.Addr.Network(), sc.Addr.String())
 	}
+	if err != nil {
+		sc.stats.dialFailures.Add(1)
+		sc.stats.setLastError(err)
+	} else {
+		sc.stats.connects.Add(1)
+	}
 	if conn != nil {
 		if sc.Wrap != nil {
 			conn = sc.Wrap(conn)
 		}
-		sc.conn = conn
 	}
-	return err
+	return conn, err
 }
 
 func (sc *StreamConn) log(args ...any) {
@@ -180,6 +254,12 @@ func (sc *StreamConn) log(args ...any) {
 
 // RemoteAddr 当前连接的远端地址
 func (sc *StreamConn) RemoteAddr() net.Addr {
+	if sc.Buffered {
+		if b := sc.startedBuffered(); b != nil {
+			return b.remoteAddr()
+		}
+		return fsaddr.Empty
+	}
 	sc.mux.Lock()
 	defer sc.mux.Unlock()
 	if sc.conn != nil {
@@ -199,6 +279,12 @@ func (sc *StreamConn) canTry(index int) bool {
 }
 
 func (sc *StreamConn) Write(b []byte) (int, error) {
+	if sc.Buffered {
+		if sc.isClosed() {
+			return 0, errClosed
+		}
+		return sc.getBuffered().write(b)
+	}
 	sc.mux.Lock()
 	defer sc.mux.Unlock()
 	if err := sc.checkConn(); err != nil {
@@ -207,9 +293,11 @@ func (sc *StreamConn) Write(b []byte) (int, error) {
 	var try int
 	for {
 		n, err := sc.conn.Write(b)
+		sc.stats.sent.Add(int64(n))
 		if err == nil {
 			return n, err
 		}
+		sc.stats.setLastError(err)
 		if sc.canLog() {
 			sc.log("write to ", sc.conn.RemoteAddr().String(), " failed, try=", try, " err=", err.Error())
 		}
@@ -229,6 +317,12 @@ func (sc *StreamConn) Write(b []byte) (int, error) {
 }
 
 func (sc *StreamConn) Read(b []byte) (int, error) {
+	if sc.Buffered {
+		if sc.isClosed() {
+			return 0, errClosed
+		}
+		return sc.getBuffered().read(b)
+	}
 	sc.mux.Lock()
 	defer sc.mux.Unlock()
 	if err := sc.checkConn(); err != nil {
@@ -264,10 +358,18 @@ func (sc *StreamConn) isClosed() bool {
 	return sc.closed.Load()
 }
 
+// Close 关闭连接，Buffered 模式下会等待缓冲区的数据发送完成，最长等待 DrainTimeout
+// 若仍有数据未发送，将返回 error
 func (sc *StreamConn) Close() error {
 	if !sc.closed.CompareAndSwap(false, true) {
 		return nil
 	}
+	if sc.Buffered {
+		if b := sc.startedBuffered(); b != nil {
+			return b.close()
+		}
+		return nil
+	}
 
 	sc.mux.Lock()
 	defer sc.mux.Unlock()
@@ -282,3 +384,29 @@ func (sc *StreamConn) close() error {
 	sc.conn = nil
 	return err
 }
+
+// Stats 获取连接状态和缓冲区的统计信息
+func (sc *StreamConn) Stats() StreamConnStats {
+	st := StreamConnStats{
+		Connects:     sc.stats.connects.Load(),
+		DialFailures: sc.stats.dialFailures.Load(),
+		Sent:         sc.stats.sent.Load(),
+		Replayed:     sc.stats.replayed.Load(),
+		Acked:        sc.stats.acked.Load(),
+		Dropped:      sc.stats.dropped.Load(),
+		DroppedBytes: sc.stats.droppedBytes.Load(),
+	}
+	if err := sc.stats.lastError.Load(); err != nil {
+		st.LastError = *err
+	}
+	if sc.Buffered {
+		if b := sc.startedBuffered(); b != nil {
+			b.fillStats(&st)
+		}
+	} else {
+		sc.mux.Lock()
+		st.Connected = sc.conn != nil
+		sc.mux.Unlock()
+	}
+	return st
+}
@@ -0,0 +1,370 @@
+// Copyright(C) 2026 github.com/fsgo  All Rights Reserved.
+// Author: hidu <duv123@gmail.com>
+// Date: 2026/10/19
+
+package fsconn
+
+import (
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// ErrFaultInjected 由 FaultInjector 注入的读写错误
+var ErrFaultInjected = errors.New("fsconn: fault injected")
+
+// LatencyDistribution 延迟的分布类型
+type LatencyDistribution uint8
+
+const (
+	// LatencyFixed 固定延迟：Base
+	LatencyFixed LatencyDistribution = iota
+
+	// LatencyUniform 均匀分布：[Base, Base+Jitter)
+	LatencyUniform
+
+	// LatencyNormal 正态分布：均值为 Base，标准差为 Jitter
+	LatencyNormal
+
+	// LatencyExponential 指数分布：Base + 均值为 Jitter 的指数分布
+	LatencyExponential
+)
+
+// Latency 注入的延迟
+type Latency struct {
+	// Distribution 分布类型，默认为 LatencyFixed
+	Distribution LatencyDistribution
+
+	// Base 基础延迟
+	Base time.Duration
+
+	// Jitter 延迟的波动，含义由 Distribution 决定
+	Jitter time.Duration
+
+	// Max 延迟的上限，可选，<= 0 时不限制
+	Max time.Duration
+}
+
+// Duration 按照分布随机生成一个延迟时间
+func (l Latency) Duration() time.Duration {
+	d := l.Base
+	switch l.Distribution {
+	case LatencyUniform:
+		if l.Jitter > 0 {
+			d += rand.N(l.Jitter)
+		}
+	case LatencyNormal:
+		d += time.Duration(rand.NormFloat64() * float64(l.Jitter))
+	case LatencyExponential:
+		d += time.Duration(rand.ExpFloat64() * float64(l.Jitter))
+	}
+	if l.Max > 0 && d > l.Max {
+		d = l.Max
+	}
+	if d < 0 {
+		return 0
+	}
+	return d
+}
+
+// Fault 故障注入的配置，概率的取值范围为 [0,1]
+type Fault struct {
+	// ReadLatency 每次 Read 前增加的延迟
+	ReadLatency Latency
+
+	// WriteLatency 每次 Write 前增加的延迟
+	WriteLatency Latency
+
+	// ReadErrorRate Read 时返回 ErrFaultInjected 的概率
+	ReadErrorRate float64
+
+	// WriteErrorRate Write 时返回 ErrFaultInjected 的概率
+	WriteErrorRate float64
+
+	// PartialWriteRate Write 时只写入部分数据并返回 io.ErrShortWrite 的概率
+	PartialWriteRate float64
+
+	// CorruptRate 每次读写时随机修改一个字节的概率
+	CorruptRate float64
+
+	// ResetAfterBytes 连接读写的总字节数达到此值后，重置连接，
+	// 之后的读写都返回 ECONNRESET，<= 0 时不启用
+	ResetAfterBytes int64
+
+	// BlackHole 黑洞模式：Write 的数据被丢弃并返回成功，
+	// Read 收到的数据也被丢弃，一直阻塞直到超时或者连接关闭
+	BlackHole bool
+}
+
+// FaultInjector 用于混沌测试的故障注入拦截器
+//
+// 可以按照服务名（通过 WithService 设置）配置不同的 Fault，
+// 所有配置都可以在运行时修改，并立即对已有连接生效
+type FaultInjector struct {
+	interceptor *Interceptor
+
+	defaultFault atomic.Pointer[Fault]
+
+	// services 服务名 -> *Fault
+	services sync.Map
+
+	// conns 连接的状态，Info -> *faultConn
+	conns sync.Map
+
+	once sync.Once
+
+	disabled atomic.Bool
+}
+
+// SetDefault 设置默认的 Fault，对没有单独配置的服务生效，f 为 nil 时表示不注入故障
+func (fi *FaultInjector) SetDefault(f *Fault) {
+	fi.defaultFault.Store(f)
+}
+
+// SetService 设置指定服务的 Fault，f 为 nil 时删除该服务的配置
+func (fi *FaultInjector) SetService(service any, f *Fault) {
+	if f == nil {
+		fi.services.Delete(service)
+		return
+	}
+	fi.services.Store(service, f)
+}
+
+// Enable 设置是否启用故障注入，默认为启用
+func (fi *FaultInjector) Enable(enable bool) {
+	fi.disabled.Store(!enable)
+}
+
+// Enabled 是否启用了故障注入
+func (fi *FaultInjector) Enabled() bool {
+	return !fi.disabled.Load()
+}
+
+func (fi *FaultInjector) getFault(info Info) *Fault {
+	if fi.disabled.Load() {
+		return nil
+	}
+	if service := Service(info); service != nil {
+		if v, ok := fi.services.Load(service); ok {
+			return v.(*Fault)
+		}
+	}
+	return fi.defaultFault.Load()
+}
+
+// faultConn 连接的故障注入状态
+type faultConn struct {
+	done      chan struct{}
+	bytes     atomic.Int64
+	reset     atomic.Bool
+	closeOnce sync.Once
+}
+
+func (fc *faultConn) close() {
+	fc.closeOnce.Do(func() {
+		close(fc.done)
+	})
+}
+
+// sleep 等待 d，连接关闭时立即返回
+func (fc *faultConn) sleep(d time.Duration) {
+	if d <= 0 {
+		return
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-fc.done:
+	}
+}
+
+func (fi *FaultInjector) getConn(info Info) *faultConn {
+	if v, ok := fi.conns.Load(info); ok {
+		return v.(*faultConn)
+	}
+	fc := &faultConn{
+		done: make(chan struct{}),
+	}
+	v, _ := fi.conns.LoadOrStore(info, fc)
+	return v.(*faultConn)
+}
+
+// release 释放连接的状态
+func (fi *FaultInjector) release(info Info) {
+	if v, ok := fi.conns.LoadAndDelete(info); ok {
+		v.(*faultConn).close()
+	}
+}
+
+func hit(rate float64) bool {
+	return rate > 0 && (rate >= 1 || rand.Float64() < rate)
+}
+
+func corrupt(b []byte) {
+	if len(b) > 0 {
+		b[rand.IntN(len(b))] ^= byte(1 + rand.IntN(255))
+	}
+}
+
+func errConnReset(op string, info Info) error {
+	return &net.OpError{
+		Op:     op,
+		Net:    info.LocalAddr().Network(),
+		Source: info.LocalAddr(),
+		Addr:   info.RemoteAddr(),
+		Err:    syscall.ECONNRESET,
+	}
+}
+
+// addBytes 累计读写的字节数，返回允许读写的字节数，以及是否达到了 ResetAfterBytes
+func (fc *faultConn) addBytes(f *Fault, n int) (int, bool) {
+	if f.ResetAfterBytes <= 0 {
+		return n, false
+	}
+	total := fc.bytes.Add(int64(n))
+	if total < f.ResetAfterBytes {
+		return n, false
+	}
+	allow := n - int(total-f.ResetAfterBytes)
+	return max(allow, 0), true
+}
+
+// resetConn 重置连接：对于 TCP 连接，关闭时会发送 RST
+//
+// 关闭的是最底层的连接，不会执行 AfterClose，所以连接的状态会保留到调用方 Close，
+// 在此之前的读写都返回 ECONNRESET
+func (fc *faultConn) resetConn(op string, info Info) error {
+	if !fc.reset.Swap(true) {
+		if c, ok := info.(net.Conn); ok {
+			origin := OriginConn(c)
+			if tc, ok := origin.(*net.TCPConn); ok {
+				_ = tc.SetLinger(0)
+			}
+			_ = origin.Close()
+		}
+	}
+	return errConnReset(op, info)
+}
+
+func (fi *FaultInjector) read(info Info, b []byte, invoker func([]byte) (int, error)) (int, error) {
+	f := fi.getFault(info)
+	if f == nil {
+		return invoker(b)
+	}
+	fc := fi.getConn(info)
+	if fc.reset.Load() {
+		return 0, errConnReset("read", info)
+	}
+	fc.sleep(f.ReadLatency.Duration())
+	if f.BlackHole {
+		return fi.blackHoleRead(info, b, invoker)
+	}
+	if hit(f.ReadErrorRate) {
+		return 0, ErrFaultInjected
+	}
+	n, err := invoker(b)
+	if isClosedErr(err) {
+		// 在 Close 之后调用的 Read，getConn 会重新创建状态，需要释放
+		fi.release(info)
+		return n, err
+	}
+	if n > 0 {
+		var reset bool
+		if n, reset = fc.addBytes(f, n); reset {
+			if err1 := fc.resetConn("read", info); err == nil {
+				err = err1
+			}
+		}
+		if hit(f.CorruptRate) {
+			corrupt(b[:n])
+		}
+	}
+	return n, err
+}
+
+// blackHoleRead 丢弃读取到的数据，直到读超时、连接关闭或者不再是黑洞模式，
+// 超时和关闭都使用连接本身的状态
+func (fi *FaultInjector) blackHoleRead(info Info, b []byte, invoker func([]byte) (int, error)) (int, error) {
+	for {
+		n, err := invoker(b)
+		if err != nil {
+			if isClosedErr(err) {
+				fi.release(info)
+			}
+			return 0, err
+		}
+		if f := fi.getFault(info); f == nil || !f.BlackHole {
+			return n, nil
+		}
+	}
+}
+
+func (fi *FaultInjector) write(info Info, b []byte, invoker func([]byte) (int, error)) (int, error) {
+	f := fi.getFault(info)
+	if f == nil {
+		return invoker(b)
+	}
+	fc := fi.getConn(info)
+	if fc.reset.Load() {
+		return 0, errConnReset("write", info)
+	}
+	fc.sleep(f.WriteLatency.Duration())
+	if f.BlackHole {
+		return len(b), nil
+	}
+	if hit(f.WriteErrorRate) {
+		return 0, ErrFaultInjected
+	}
+	var shortErr error
+	if len(b) > 1 && hit(f.PartialWriteRate) {
+		b = b[:1+rand.IntN(len(b)-1)]
+		shortErr = io.ErrShortWrite
+	}
+	allow, reset := fc.addBytes(f, len(b))
+	if reset {
+		b = b[:allow]
+	}
+	if len(b) > 0 && hit(f.CorruptRate) {
+		// 不能修改调用方的数据
+		b = append([]byte(nil), b...)
+		corrupt(b)
+	}
+	var n int
+	var err error
+	if len(b) > 0 {
+		if n, err = invoker(b); isClosedErr(err) {
+			fi.release(info)
+		}
+	}
+	if reset {
+		if err1 := fc.resetConn("write", info); err == nil {
+			err = err1
+		}
+	}
+	if err != nil {
+		return n, err
+	}
+	return n, shortErr
+}
+
+func (fi *FaultInjector) init() {
+	fi.interceptor = &Interceptor{
+		Read:  fi.read,
+		Write: fi.write,
+		AfterClose: func(info Info, _ error) {
+			fi.release(info)
+		},
+	}
+}
+
+// Interceptor 获取 Interceptor 实例
+func (fi *FaultInjector) Interceptor() *Interceptor {
+	fi.once.Do(fi.init)
+	return fi.interceptor
+}
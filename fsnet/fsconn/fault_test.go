@@ -0,0 +1,157 @@
+// Copyright(C) 2026 github.com/fsgo  All Rights Reserved.
+// Author: hidu <duv123@gmail.com>
+// Date: 2026/10/19
+
+package fsconn
+
+import (
+	"errors"
+	"io"
+	"net"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/fsgo/fst"
+)
+
+func TestLatency(t *testing.T) {
+	fst.Equal(t, 10*time.Millisecond, Latency{Base: 10 * time.Millisecond}.Duration())
+	l := Latency{Distribution: LatencyUniform, Base: time.Millisecond, Jitter: time.Millisecond}
+	for i := 0; i < 100; i++ {
+		d := l.Duration()
+		fst.True(t, d >= time.Millisecond && d < 2*time.Millisecond)
+	}
+	l = Latency{Distribution: LatencyNormal, Base: time.Millisecond, Jitter: time.Second, Max: 2 * time.Millisecond}
+	for i := 0; i < 100; i++ {
+		d := l.Duration()
+		fst.True(t, d >= 0 && d <= 2*time.Millisecond)
+	}
+}
+
+func TestFaultInjector(t *testing.T) {
+	fi := &FaultInjector{}
+	newPipe := func(service any) (net.Conn, net.Conn) {
+		c1, c2 := net.Pipe()
+		return Wrap(WithService(service, c1), fi.Interceptor()), c2
+	}
+
+	t.Run("no fault", func(t *testing.T) {
+		c, peer := newPipe("a")
+		defer c.Close()
+		go func() {
+			_, _ = io.Copy(peer, peer)
+		}()
+		_, err := c.Write([]byte("hello"))
+		fst.NoError(t, err)
+		buf := make([]byte, 5)
+		_, err = io.ReadFull(c, buf)
+		fst.NoError(t, err)
+		fst.Equal(t, "hello", string(buf))
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		fi.SetService("a", &Fault{ReadErrorRate: 1, WriteErrorRate: 1})
+		defer fi.SetService("a", nil)
+		c, _ := newPipe("a")
+		defer c.Close()
+		_, err := c.Write([]byte("hello"))
+		fst.True(t, errors.Is(err, ErrFaultInjected))
+		_, err = c.Read(make([]byte, 5))
+		fst.True(t, errors.Is(err, ErrFaultInjected))
+
+		// 其他服务不受影响
+		c2, peer := newPipe("b")
+		defer c2.Close()
+		go func() {
+			_, _ = io.Copy(io.Discard, peer)
+		}()
+		_, err = c2.Write([]byte("hello"))
+		fst.NoError(t, err)
+
+		fi.Enable(false)
+		defer fi.Enable(true)
+		go func() {
+			_, _ = io.Copy(io.Discard, peer)
+		}()
+		_, err = c2.Write([]byte("hello"))
+		fst.NoError(t, err)
+	})
+
+	t.Run("partial write and corrupt", func(t *testing.T) {
+		fi.SetDefault(&Fault{PartialWriteRate: 1, CorruptRate: 1})
+		defer fi.SetDefault(nil)
+		c, peer := newPipe(nil)
+		defer c.Close()
+		got := make(chan []byte, 1)
+		go func() {
+			bf, _ := io.ReadAll(peer)
+			got <- bf
+		}()
+		data := []byte("hello world")
+		n, err := c.Write(data)
+		fst.Equal(t, io.ErrShortWrite, err)
+		fst.True(t, n > 0 && n < len(data))
+		fst.Equal(t, "hello world", string(data))
+		fst.NoError(t, c.Close())
+		bf := <-got
+		fst.Len(t, bf, n)
+		fst.NotEqual(t, string(data[:n]), string(bf))
+	})
+
+	t.Run("reset after bytes", func(t *testing.T) {
+		fi.SetDefault(&Fault{ResetAfterBytes: 8})
+		defer fi.SetDefault(nil)
+		c, peer := newPipe(nil)
+		defer c.Close()
+		go func() {
+			_, _ = io.Copy(io.Discard, peer)
+		}()
+		n, err := c.Write([]byte("hello"))
+		fst.NoError(t, err)
+		fst.Equal(t, 5, n)
+		n, err = c.Write([]byte("world"))
+		fst.Equal(t, 3, n)
+		fst.True(t, errors.Is(err, syscall.ECONNRESET))
+		_, err = c.Write([]byte("x"))
+		fst.True(t, errors.Is(err, syscall.ECONNRESET))
+		_, err = c.Read(make([]byte, 5))
+		fst.True(t, errors.Is(err, syscall.ECONNRESET))
+	})
+
+	t.Run("read after close", func(t *testing.T) {
+		c, _ := newPipe(nil)
+		// Close 之后的读写不能再保留连接的状态
+		fi.SetDefault(&Fault{ReadLatency: Latency{Base: time.Millisecond}})
+		defer fi.SetDefault(nil)
+		fst.NoError(t, c.Close())
+		_, err := c.Read(make([]byte, 5))
+		fst.Error(t, err)
+		_, err = c.Write([]byte("hello"))
+		fst.Error(t, err)
+	})
+
+	t.Run("black hole", func(t *testing.T) {
+		fi.SetDefault(&Fault{BlackHole: true, WriteLatency: Latency{Base: 10 * time.Millisecond}})
+		defer fi.SetDefault(nil)
+		c, _ := newPipe(nil)
+		defer c.Close()
+		start := time.Now()
+		n, err := c.Write([]byte("hello"))
+		fst.NoError(t, err)
+		fst.Equal(t, 5, n)
+		fst.True(t, time.Since(start) >= 10*time.Millisecond)
+
+		fst.NoError(t, c.SetReadDeadline(time.Now().Add(20*time.Millisecond)))
+		_, err = c.Read(make([]byte, 5))
+		fst.True(t, errors.Is(err, os.ErrDeadlineExceeded))
+	})
+
+	var conns int
+	fi.conns.Range(func(_, _ any) bool {
+		conns++
+		return true
+	})
+	fst.Equal(t, 0, conns)
+}
@@ -0,0 +1,536 @@
+// Copyright(C) 2026 github.com/fsgo  All Rights Reserved.
+// Author: hidu <duv123@gmail.com>
+// Date: 2026/10/19
+
+package fsconn
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/netip"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// PROXY protocol，见 https://www.haproxy.org/download/2.8/doc/proxy-protocol.txt
+
+var (
+	proxyV1Prefix = []byte("PROXY ")
+	proxyV2Sig    = []byte("\r\n\r\n\x00\r\nQUIT\n")
+)
+
+// ErrNoProxyHeader 连接的数据不是以 PROXY protocol header 开始的
+var ErrNoProxyHeader = errors.New("fsconn: no PROXY protocol header")
+
+// ErrInvalidProxyHeader PROXY protocol header 格式错误
+var ErrInvalidProxyHeader = errors.New("fsconn: invalid PROXY protocol header")
+
+// ProxyCommand PROXY protocol 的命令
+type ProxyCommand uint8
+
+const (
+	// ProxyCommandProxy 连接是代理的，header 中包含原始的地址
+	ProxyCommandProxy ProxyCommand = iota
+
+	// ProxyCommandLocal 连接是代理自己建立的（如健康检查），应使用连接本身的地址
+	ProxyCommandLocal
+)
+
+// ProxyHeader PROXY protocol header
+type ProxyHeader struct {
+	// Source 原始的客户端地址，Command 为 ProxyCommandLocal 或者协议未知时为 nil
+	Source net.Addr
+
+	// Destination 原始的服务端地址
+	Destination net.Addr
+
+	// Version 协议版本，1 或者 2，写出时默认为 1
+	Version int
+
+	// Command 命令
+	Command ProxyCommand
+}
+
+// Bytes 编码为 PROXY protocol header
+func (h *ProxyHeader) Bytes() ([]byte, error) {
+	switch h.Version {
+	case 0, 1:
+		return h.bytesV1()
+	case 2:
+		return h.bytesV2()
+	default:
+		return nil, fmt.Errorf("not support PROXY protocol version %d", h.Version)
+	}
+}
+
+// WriteTo 将 header 写入 w
+func (h *ProxyHeader) WriteTo(w io.Writer) (int64, error) {
+	bf, err := h.Bytes()
+	if err != nil {
+		return 0, err
+	}
+	n, err := w.Write(bf)
+	return int64(n), err
+}
+
+// addrs 解析 Source 和 Destination，family 为 tcp4、tcp6、udp4、udp6、unix 或者 unixgram
+func (h *ProxyHeader) addrs() (family string, src netip.AddrPort, dst netip.AddrPort, err error) {
+	if h.Source == nil || h.Destination == nil {
+		return "", src, dst, nil
+	}
+	switch sa := h.Source.(type) {
+	case *net.UnixAddr:
+		if sa.Net == "unixgram" {
+			return "unixgram", src, dst, nil
+		}
+		return "unix", src, dst, nil
+	case *net.UDPAddr:
+		family = "udp"
+	default:
+		family = "tcp"
+	}
+	if src, err = netip.ParseAddrPort(h.Source.String()); err != nil {
+		return "", src, dst, err
+	}
+	if dst, err = netip.ParseAddrPort(h.Destination.String()); err != nil {
+		return "", src, dst, err
+	}
+	src = netip.AddrPortFrom(src.Addr().Unmap(), src.Port())
+	dst = netip.AddrPortFrom(dst.Addr().Unmap(), dst.Port())
+	if src.Addr().Is4() != dst.Addr().Is4() {
+		return "", src, dst, fmt.Errorf("address family mismatch: %s, %s", src, dst)
+	}
+	if src.Addr().Is4() {
+		return family + "4", src, dst, nil
+	}
+	return family + "6", src, dst, nil
+}
+
+func (h *ProxyHeader) bytesV1() ([]byte, error) {
+	if h.Command == ProxyCommandLocal {
+		return []byte("PROXY UNKNOWN\r\n"), nil
+	}
+	family, src, dst, err := h.addrs()
+	if err != nil {
+		return nil, err
+	}
+	switch family {
+	case "tcp4", "tcp6":
+		s := fmt.Sprintf("PROXY %s %s %s %d %d\r\n", strings.ToUpper(family),
+			src.Addr().String(), dst.Addr().String(), src.Port(), dst.Port())
+		return []byte(s), nil
+	case "":
+		return []byte("PROXY UNKNOWN\r\n"), nil
+	default:
+		return nil, fmt.Errorf("PROXY protocol v1 not support %s", family)
+	}
+}
+
+func (h *ProxyHeader) bytesV2() ([]byte, error) {
+	bf := &bytes.Buffer{}
+	bf.Write(proxyV2Sig)
+	if h.Command == ProxyCommandLocal {
+		bf.Write([]byte{0x20, 0x00, 0x00, 0x00})
+		return bf.Bytes(), nil
+	}
+	bf.WriteByte(0x21)
+	family, src, dst, err := h.addrs()
+	if err != nil {
+		return nil, err
+	}
+	var body []byte
+	switch family {
+	case "tcp4", "udp4", "tcp6", "udp6":
+		body = append(body, src.Addr().AsSlice()...)
+		body = append(body, dst.Addr().AsSlice()...)
+		body = binary.BigEndian.AppendUint16(body, src.Port())
+		body = binary.BigEndian.AppendUint16(body, dst.Port())
+	case "unix", "unixgram":
+		body = make([]byte, 216)
+		copy(body[:108], h.Source.String())
+		copy(body[108:], h.Destination.String())
+	}
+	bf.WriteByte(proxyV2Families[family])
+	bf.Write(binary.BigEndian.AppendUint16(nil, uint16(len(body))))
+	bf.Write(body)
+	return bf.Bytes(), nil
+}
+
+var proxyV2Families = map[string]byte{
+	"":         0x00,
+	"tcp4":     0x11,
+	"udp4":     0x12,
+	"tcp6":     0x21,
+	"udp6":     0x22,
+	"unix":     0x31,
+	"unixgram": 0x32,
+}
+
+// ReadProxyHeader 从 br 中读取 PROXY protocol header，支持 v1 和 v2
+//
+// 若数据不是以 header 开始的，返回 ErrNoProxyHeader，并且不会消费 br 中的数据
+func ReadProxyHeader(br *bufio.Reader) (*ProxyHeader, error) {
+	// 先检查第一个字节，避免没有 header 且数据较短时一直等待
+	b, err := br.Peek(1)
+	if err != nil {
+		return nil, err
+	}
+	if b[0] != proxyV1Prefix[0] && b[0] != proxyV2Sig[0] {
+		return nil, ErrNoProxyHeader
+	}
+	if b, err = br.Peek(len(proxyV1Prefix)); err != nil {
+		return nil, err
+	}
+	if bytes.Equal(b, proxyV1Prefix) {
+		return readProxyHeaderV1(br)
+	}
+	if !bytes.Equal(b, proxyV2Sig[:len(b)]) {
+		return nil, ErrNoProxyHeader
+	}
+	if b, err = br.Peek(len(proxyV2Sig)); err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(b, proxyV2Sig) {
+		return nil, ErrNoProxyHeader
+	}
+	return readProxyHeaderV2(br)
+}
+
+func readProxyHeaderV1(br *bufio.Reader) (*ProxyHeader, error) {
+	// v1 的 header 最长为 107 字节
+	var line []byte
+	for len(line) < 107 {
+		c, err := br.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		line = append(line, c)
+		if c == '\n' {
+			break
+		}
+	}
+	if !bytes.HasSuffix(line, []byte("\r\n")) {
+		return nil, fmt.Errorf("%w: v1 header not end with CRLF", ErrInvalidProxyHeader)
+	}
+	fields := strings.Split(string(line[:len(line)-2]), " ")
+	h := &ProxyHeader{Version: 1}
+	if len(fields) >= 2 && fields[1] == "UNKNOWN" {
+		return h, nil
+	}
+	if len(fields) != 6 || (fields[1] != "TCP4" && fields[1] != "TCP6") {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidProxyHeader, line)
+	}
+	src, err := parseProxyAddrV1(fields[2], fields[4], fields[1] == "TCP4")
+	if err != nil {
+		return nil, err
+	}
+	dst, err := parseProxyAddrV1(fields[3], fields[5], fields[1] == "TCP4")
+	if err != nil {
+		return nil, err
+	}
+	h.Source = net.TCPAddrFromAddrPort(src)
+	h.Destination = net.TCPAddrFromAddrPort(dst)
+	return h, nil
+}
+
+func parseProxyAddrV1(ip string, port string, is4 bool) (netip.AddrPort, error) {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil || addr.Is4() != is4 {
+		return netip.AddrPort{}, fmt.Errorf("%w: invalid ip %q", ErrInvalidProxyHeader, ip)
+	}
+	p, err := strconv.ParseUint(port, 10, 16)
+	if err != nil || (len(port) > 1 && port[0] == '0') {
+		return netip.AddrPort{}, fmt.Errorf("%w: invalid port %q", ErrInvalidProxyHeader, port)
+	}
+	return netip.AddrPortFrom(addr, uint16(p)), nil
+}
+
+func readProxyHeaderV2(br *bufio.Reader) (*ProxyHeader, error) {
+	head := make([]byte, 16)
+	if _, err := io.ReadFull(br, head); err != nil {
+		return nil, err
+	}
+	if head[12]>>4 != 2 {
+		return nil, fmt.Errorf("%w: version %d", ErrInvalidProxyHeader, head[12]>>4)
+	}
+	body := make([]byte, binary.BigEndian.Uint16(head[14:]))
+	if _, err := io.ReadFull(br, body); err != nil {
+		return nil, err
+	}
+	h := &ProxyHeader{Version: 2}
+	switch head[12] & 0x0f {
+	case 0x00:
+		h.Command = ProxyCommandLocal
+		return h, nil
+	case 0x01:
+	default:
+		return nil, fmt.Errorf("%w: command %d", ErrInvalidProxyHeader, head[12]&0x0f)
+	}
+
+	fam := head[13]
+	var size int
+	switch fam >> 4 {
+	case 0x0:
+		// UNSPEC
+		return h, nil
+	case 0x1:
+		size = 12
+	case 0x2:
+		size = 36
+	case 0x3:
+		size = 216
+	default:
+		return nil, fmt.Errorf("%w: address family %d", ErrInvalidProxyHeader, fam>>4)
+	}
+	if len(body) < size {
+		return nil, fmt.Errorf("%w: address too short", ErrInvalidProxyHeader)
+	}
+	// 剩余的数据为 TLV，忽略
+	if fam>>4 == 0x3 {
+		network := "unix"
+		if fam&0x0f == 0x2 {
+			network = "unixgram"
+		}
+		h.Source = &net.UnixAddr{Net: network, Name: string(bytes.TrimRight(body[:108], "\x00"))}
+		h.Destination = &net.UnixAddr{Net: network, Name: string(bytes.TrimRight(body[108:216], "\x00"))}
+		return h, nil
+	}
+	ipLen := (size - 4) / 2
+	srcIP, _ := netip.AddrFromSlice(body[:ipLen])
+	dstIP, _ := netip.AddrFromSlice(body[ipLen : 2*ipLen])
+	src := netip.AddrPortFrom(srcIP, binary.BigEndian.Uint16(body[2*ipLen:]))
+	dst := netip.AddrPortFrom(dstIP, binary.BigEndian.Uint16(body[2*ipLen+2:]))
+	switch fam & 0x0f {
+	case 0x1:
+		h.Source = net.TCPAddrFromAddrPort(src)
+		h.Destination = net.TCPAddrFromAddrPort(dst)
+	case 0x2:
+		h.Source = net.UDPAddrFromAddrPort(src)
+		h.Destination = net.UDPAddrFromAddrPort(dst)
+	default:
+		return nil, fmt.Errorf("%w: transport protocol %d", ErrInvalidProxyHeader, fam&0x0f)
+	}
+	return h, nil
+}
+
+// ProxyProtocol 解析 accept 的连接的 PROXY protocol header，并改写连接的 RemoteAddr 和 LocalAddr
+//
+// header 在第一次调用 Read、RemoteAddr、LocalAddr 时才读取，不会阻塞 Accept，
+// 所以对可信来源的连接，第一次调用 RemoteAddr、LocalAddr 可能会阻塞，最长为 HeaderTimeout。
+// 只会解析 TrustedCIDRs 中的来源或者 TrustAll 为 true 时的连接的 header，默认不信任任何来源。
+// 可以和 Listener 组合使用：
+//
+//	l = &fsconn.Listener{Listener: l, AfterAccepts: []func(net.Conn) (net.Conn, error){pp.AfterAccept}}
+type ProxyProtocol struct {
+	trusted []netip.Prefix
+	err     error
+
+	// TrustedCIDRs 可信的来源地址，如 "10.0.0.0/8"、"10.0.0.1"，可选
+	// 不可信来源的连接不会解析 header，为空并且 TrustAll 为 false 时，不信任任何来源
+	TrustedCIDRs []string
+
+	// TrustAll 是否信任所有的来源，可选，为 true 时忽略 TrustedCIDRs
+	// 只应在所有的连接都来自代理时使用，否则客户端可以伪造自己的地址
+	TrustAll bool
+
+	// HeaderTimeout 读取 header 的超时时间，可选，默认为 5s
+	HeaderTimeout time.Duration
+
+	// Required 可信来源的连接是否必须有 header，默认 false：没有 header 时使用连接本身的地址
+	Required bool
+
+	once sync.Once
+}
+
+func (pp *ProxyProtocol) init() {
+	for _, cidr := range pp.TrustedCIDRs {
+		if !strings.Contains(cidr, "/") {
+			// 单个 IP，如 "10.0.0.1"
+			addr, err := netip.ParseAddr(cidr)
+			if err != nil {
+				pp.err = fmt.Errorf("invalid TrustedCIDRs %q: %w", cidr, err)
+				return
+			}
+			addr = addr.Unmap()
+			pp.trusted = append(pp.trusted, netip.PrefixFrom(addr, addr.BitLen()))
+			continue
+		}
+		p, err := netip.ParsePrefix(cidr)
+		if err != nil {
+			pp.err = fmt.Errorf("invalid TrustedCIDRs %q: %w", cidr, err)
+			return
+		}
+		pp.trusted = append(pp.trusted, p.Masked())
+	}
+}
+
+func (pp *ProxyProtocol) isTrusted(addr net.Addr) bool {
+	if pp.TrustAll {
+		return true
+	}
+	ap, err := netip.ParseAddrPort(addr.String())
+	if err != nil {
+		return false
+	}
+	ip := ap.Addr().Unmap()
+	for _, p := range pp.trusted {
+		if p.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func (pp *ProxyProtocol) getHeaderTimeout() time.Duration {
+	if pp.HeaderTimeout > 0 {
+		return pp.HeaderTimeout
+	}
+	return 5 * time.Second
+}
+
+// AfterAccept 用于 Listener.AfterAccepts
+func (pp *ProxyProtocol) AfterAccept(conn net.Conn) (net.Conn, error) {
+	pp.once.Do(pp.init)
+	if pp.err != nil {
+		_ = conn.Close()
+		return nil, pp.err
+	}
+	if !pp.isTrusted(conn.RemoteAddr()) {
+		return conn, nil
+	}
+	return &proxyConn{
+		Conn:     conn,
+		br:       bufio.NewReader(conn),
+		timeout:  pp.getHeaderTimeout(),
+		required: pp.Required,
+	}, nil
+}
+
+// Listener 封装 l，返回的 Listener accept 的连接支持 PROXY protocol
+func (pp *ProxyProtocol) Listener(l net.Listener) *Listener {
+	return &Listener{
+		Listener:     l,
+		AfterAccepts: []func(conn net.Conn) (net.Conn, error){pp.AfterAccept},
+	}
+}
+
+// HasProxyHeader 用于获取连接的 PROXY protocol header
+type HasProxyHeader interface {
+	ProxyHeader() (*ProxyHeader, error)
+}
+
+var _ HasRaw = (*proxyConn)(nil)
+var _ HasProxyHeader = (*proxyConn)(nil)
+
+type proxyConn struct {
+	net.Conn
+	br     *bufio.Reader
+	header *ProxyHeader
+	err    error
+
+	// readDeadline 用户设置的读超时，读取 header 后恢复
+	readDeadline time.Time
+	mux          sync.Mutex
+
+	timeout  time.Duration
+	required bool
+	once     sync.Once
+}
+
+func (c *proxyConn) RawConn() net.Conn {
+	return c.Conn
+}
+
+func (c *proxyConn) readHeader() {
+	c.mux.Lock()
+	dl := c.readDeadline
+	c.mux.Unlock()
+	timeout := time.Now().Add(c.timeout)
+	if !dl.IsZero() && dl.Before(timeout) {
+		timeout = dl
+	}
+	_ = c.Conn.SetReadDeadline(timeout)
+	c.header, c.err = ReadProxyHeader(c.br)
+	if !c.required && c.err != nil && c.noHeader(c.err) {
+		c.err = nil
+	}
+	c.mux.Lock()
+	_ = c.Conn.SetReadDeadline(c.readDeadline)
+	c.mux.Unlock()
+}
+
+// noHeader 判断读取 header 的错误是否是没有 header
+//
+// 超时前没有收到任何数据（如客户端先等待服务端发送数据的协议），也作为没有 header，
+// 否则超时的错误会一直保留，之后的 Read 都会失败
+func (c *proxyConn) noHeader(err error) bool {
+	if errors.Is(err, ErrNoProxyHeader) {
+		return true
+	}
+	var ne net.Error
+	return errors.As(err, &ne) && ne.Timeout() && c.br.Buffered() == 0
+}
+
+// ProxyHeader 读取 PROXY protocol header，没有 header 时返回 nil
+func (c *proxyConn) ProxyHeader() (*ProxyHeader, error) {
+	c.once.Do(c.readHeader)
+	return c.header, c.err
+}
+
+func (c *proxyConn) Read(b []byte) (int, error) {
+	if _, err := c.ProxyHeader(); err != nil {
+		return 0, err
+	}
+	return c.br.Read(b)
+}
+
+func (c *proxyConn) RemoteAddr() net.Addr {
+	if h, _ := c.ProxyHeader(); h != nil && h.Source != nil {
+		return h.Source
+	}
+	return c.Conn.RemoteAddr()
+}
+
+func (c *proxyConn) LocalAddr() net.Addr {
+	if h, _ := c.ProxyHeader(); h != nil && h.Destination != nil {
+		return h.Destination
+	}
+	return c.Conn.LocalAddr()
+}
+
+func (c *proxyConn) SetDeadline(t time.Time) error {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	c.readDeadline = t
+	return c.Conn.SetDeadline(t)
+}
+
+func (c *proxyConn) SetReadDeadline(t time.Time) error {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	c.readDeadline = t
+	return c.Conn.SetReadDeadline(t)
+}
+
+// ReadProxyHeaderFrom 获取连接的 PROXY protocol header，
+// 连接不是由 ProxyProtocol 创建的或者没有 header 时返回 nil
+func ReadProxyHeaderFrom(conn net.Conn) (*ProxyHeader, error) {
+	for conn != nil {
+		if hc, ok := conn.(HasProxyHeader); ok {
+			return hc.ProxyHeader()
+		}
+		hr, ok := conn.(HasRaw)
+		if !ok {
+			return nil, nil
+		}
+		conn = hr.RawConn()
+	}
+	return nil, nil
+}
@@ -0,0 +1,221 @@
+// Copyright(C) 2026 github.com/fsgo  All Rights Reserved.
+// Author: hidu <duv123@gmail.com>
+// Date: 2026/10/19
+
+package fsconn
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fsgo/fst"
+)
+
+func TestProxyHeader(t *testing.T) {
+	tcp4 := &ProxyHeader{
+		Source:      &net.TCPAddr{IP: net.ParseIP("192.168.0.1"), Port: 56324},
+		Destination: &net.TCPAddr{IP: net.ParseIP("192.168.0.11"), Port: 443},
+	}
+	bf, err := tcp4.Bytes()
+	fst.NoError(t, err)
+	fst.Equal(t, "PROXY TCP4 192.168.0.1 192.168.0.11 56324 443\r\n", string(bf))
+
+	headers := []*ProxyHeader{
+		tcp4,
+		{
+			Version:     2,
+			Source:      &net.TCPAddr{IP: net.ParseIP("192.168.0.1"), Port: 56324},
+			Destination: &net.TCPAddr{IP: net.ParseIP("192.168.0.11"), Port: 443},
+		},
+		{
+			Version:     1,
+			Source:      &net.TCPAddr{IP: net.ParseIP("::1"), Port: 1},
+			Destination: &net.TCPAddr{IP: net.ParseIP("fe80::1"), Port: 2},
+		},
+		{
+			Version:     2,
+			Source:      &net.UDPAddr{IP: net.ParseIP("::1"), Port: 1},
+			Destination: &net.UDPAddr{IP: net.ParseIP("fe80::1"), Port: 2},
+		},
+		{
+			Version:     2,
+			Source:      &net.UnixAddr{Net: "unix", Name: "/tmp/a.sock"},
+			Destination: &net.UnixAddr{Net: "unix", Name: "/tmp/b.sock"},
+		},
+		{Version: 1, Command: ProxyCommandLocal},
+		{Version: 2, Command: ProxyCommandLocal},
+	}
+	for _, h := range headers {
+		bf, err = h.Bytes()
+		fst.NoError(t, err)
+		got, err := ReadProxyHeader(bufio.NewReader(strings.NewReader(string(bf) + "data")))
+		fst.NoError(t, err)
+		want := *h
+		if want.Version == 0 {
+			want.Version = 1
+		}
+		if want.Version == 1 && want.Command == ProxyCommandLocal {
+			// v1 的 UNKNOWN 不区分 LOCAL
+			want.Command = ProxyCommandProxy
+		}
+		fst.Equal(t, want.Version, got.Version)
+		fst.Equal(t, want.Command, got.Command)
+		if h.Source != nil {
+			fst.Equal(t, h.Source.String(), got.Source.String())
+			fst.Equal(t, h.Destination.String(), got.Destination.String())
+		} else {
+			fst.Nil(t, got.Source)
+		}
+	}
+
+	_, err = (&ProxyHeader{
+		Version:     1,
+		Source:      &net.TCPAddr{IP: net.ParseIP("::1"), Port: 1},
+		Destination: &net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: 2},
+	}).Bytes()
+	fst.Error(t, err)
+
+	br := bufio.NewReader(strings.NewReader("GET / HTTP/1.1\r\n"))
+	_, err = ReadProxyHeader(br)
+	fst.True(t, errors.Is(err, ErrNoProxyHeader))
+	fst.Equal(t, 16, br.Buffered())
+
+	invalid := []string{
+		"PROXY TCP4 1.1.1.1 2.2.2.2 80\r\n",
+		"PROXY TCP4 ::1 2.2.2.2 80 80\r\n",
+		"PROXY TCP4 1.1.1.1 2.2.2.2 080 80\r\n",
+		"PROXY TCP4 1.1.1.1 2.2.2.2 80 80\n",
+		"PROXY " + strings.Repeat("x", 200),
+	}
+	for _, s := range invalid {
+		_, err = ReadProxyHeader(bufio.NewReader(strings.NewReader(s)))
+		fst.True(t, errors.Is(err, ErrInvalidProxyHeader))
+	}
+}
+
+func TestProxyProtocol(t *testing.T) {
+	newConn := func(pp *ProxyProtocol, data string) net.Conn {
+		c1, c2 := net.Pipe()
+		go func() {
+			_, _ = c2.Write([]byte(data))
+			_ = c2.Close()
+		}()
+		conn, err := pp.AfterAccept(c1)
+		fst.NoError(t, err)
+		return conn
+	}
+
+	t.Run("v1", func(t *testing.T) {
+		conn := newConn(&ProxyProtocol{TrustAll: true}, "PROXY TCP4 10.0.0.1 10.0.0.2 1000 80\r\nhello")
+		defer conn.Close()
+		fst.Equal(t, "10.0.0.1:1000", conn.RemoteAddr().String())
+		fst.Equal(t, "10.0.0.2:80", conn.LocalAddr().String())
+		bf, err := io.ReadAll(conn)
+		fst.NoError(t, err)
+		fst.Equal(t, "hello", string(bf))
+
+		h, err := ReadProxyHeaderFrom(Wrap(conn))
+		fst.NoError(t, err)
+		fst.Equal(t, 1, h.Version)
+	})
+
+	t.Run("no header", func(t *testing.T) {
+		conn := newConn(&ProxyProtocol{TrustAll: true}, "hi")
+		defer conn.Close()
+		bf, err := io.ReadAll(conn)
+		fst.NoError(t, err)
+		fst.Equal(t, "hi", string(bf))
+		fst.Equal(t, "pipe", conn.RemoteAddr().String())
+	})
+
+	t.Run("required", func(t *testing.T) {
+		conn := newConn(&ProxyProtocol{TrustAll: true, Required: true}, "hi")
+		defer conn.Close()
+		_, err := io.ReadAll(conn)
+		fst.True(t, errors.Is(err, ErrNoProxyHeader))
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		c1, c2 := net.Pipe()
+		defer c2.Close()
+		pp := &ProxyProtocol{TrustAll: true, Required: true, HeaderTimeout: 10 * time.Millisecond}
+		conn, err := pp.AfterAccept(c1)
+		fst.NoError(t, err)
+		defer conn.Close()
+		_, err = conn.Read(make([]byte, 10))
+		fst.Error(t, err)
+	})
+
+	t.Run("timeout no header", func(t *testing.T) {
+		// 超时前没有收到数据，并且不是必须有 header 时，作为没有 header，之后的 Read 可以正常读取
+		c1, c2 := net.Pipe()
+		defer c2.Close()
+		pp := &ProxyProtocol{TrustAll: true, HeaderTimeout: 10 * time.Millisecond}
+		conn, err := pp.AfterAccept(c1)
+		fst.NoError(t, err)
+		defer conn.Close()
+		h, err := conn.(HasProxyHeader).ProxyHeader()
+		fst.NoError(t, err)
+		fst.Nil(t, h)
+
+		go func() {
+			_, _ = c2.Write([]byte("hi"))
+		}()
+		bf := make([]byte, 10)
+		n, err := conn.Read(bf)
+		fst.NoError(t, err)
+		fst.Equal(t, "hi", string(bf[:n]))
+	})
+
+	t.Run("untrusted", func(t *testing.T) {
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		fst.NoError(t, err)
+		pl := (&ProxyProtocol{TrustedCIDRs: []string{"10.0.0.0/8"}}).Listener(l)
+		defer pl.Close()
+		go func() {
+			c, err := net.Dial("tcp", l.Addr().String())
+			if err == nil {
+				_, _ = c.Write([]byte("PROXY TCP4 10.0.0.1 10.0.0.2 1000 80\r\n"))
+				_ = c.Close()
+			}
+		}()
+		conn, err := pl.Accept()
+		fst.NoError(t, err)
+		defer conn.Close()
+		bf, err := io.ReadAll(conn)
+		fst.NoError(t, err)
+		fst.True(t, strings.HasPrefix(string(bf), "PROXY"))
+		fst.True(t, strings.HasPrefix(conn.RemoteAddr().String(), "127.0.0.1:"))
+	})
+
+	t.Run("trusted ip", func(t *testing.T) {
+		pp := &ProxyProtocol{TrustedCIDRs: []string{"127.0.0.1", "::1"}}
+		pp.once.Do(pp.init)
+		fst.NoError(t, pp.err)
+		fst.True(t, pp.isTrusted(&net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: 80}))
+		fst.True(t, pp.isTrusted(&net.TCPAddr{IP: net.ParseIP("::1"), Port: 80}))
+		fst.False(t, pp.isTrusted(&net.TCPAddr{IP: net.ParseIP("127.0.0.2"), Port: 80}))
+	})
+
+	t.Run("trust nobody", func(t *testing.T) {
+		// 没有配置 TrustedCIDRs 和 TrustAll 时，不会解析 header
+		conn := newConn(&ProxyProtocol{Required: true}, "PROXY TCP4 10.0.0.1 10.0.0.2 1000 80\r\n")
+		defer conn.Close()
+		fst.Equal(t, "pipe", conn.RemoteAddr().String())
+		bf, err := io.ReadAll(conn)
+		fst.NoError(t, err)
+		fst.True(t, strings.HasPrefix(string(bf), "PROXY"))
+	})
+
+	t.Run("invalid cidr", func(t *testing.T) {
+		c1, c2 := net.Pipe()
+		defer c2.Close()
+		_, err := (&ProxyProtocol{TrustedCIDRs: []string{"abc"}}).AfterAccept(c1)
+		fst.Error(t, err)
+	})
+}
@@ -0,0 +1,129 @@
+// Copyright(C) 2026 github.com/fsgo  All Rights Reserved.
+// Author: hidu <duv123@gmail.com>
+// Date: 2026/10/19
+
+package fsconn
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"sync"
+
+	"github.com/fsgo/fsgo/fsio"
+)
+
+// RateLimit 对网络连接的读写限速
+//
+// 每个连接独享的限速器在 Close 后释放，所以使用了 ConnRead、ConnWrite 时，连接需要 Close
+type RateLimit struct {
+	// Read 读取数据的限速器，可选，被所有连接共享
+	Read *fsio.RateLimiter
+
+	// Write 写出数据的限速器，可选，被所有连接共享
+	Write *fsio.RateLimiter
+
+	interceptor *Interceptor
+
+	// conns 每个连接独享的限速器
+	conns sync.Map
+
+	// ConnRead 每个连接读取数据的限速，bytes/sec，可选，<= 0 时不限速
+	ConnRead int64
+
+	// ConnWrite 每个连接写出数据的限速，bytes/sec，可选，<= 0 时不限速
+	ConnWrite int64
+
+	once sync.Once
+}
+
+type connRateLimit struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	read   fsio.RateLimiters
+	write  fsio.RateLimiters
+}
+
+func (rl *RateLimit) getConn(info Info) *connRateLimit {
+	if v, ok := rl.conns.Load(info); ok {
+		return v.(*connRateLimit)
+	}
+	cl := &connRateLimit{
+		read:  fsio.RateLimiters{rl.Read},
+		write: fsio.RateLimiters{rl.Write},
+	}
+	if rl.ConnRead > 0 {
+		cl.read = append(cl.read, fsio.NewRateLimiter(rl.ConnRead, 0))
+	}
+	if rl.ConnWrite > 0 {
+		cl.write = append(cl.write, fsio.NewRateLimiter(rl.ConnWrite, 0))
+	}
+	cl.ctx, cl.cancel = context.WithCancel(context.Background())
+	v, loaded := rl.conns.LoadOrStore(info, cl)
+	if loaded {
+		cl.cancel()
+	}
+	return v.(*connRateLimit)
+}
+
+// release 释放连接独享的限速器
+func (rl *RateLimit) release(info Info) {
+	if v, ok := rl.conns.LoadAndDelete(info); ok {
+		v.(*connRateLimit).cancel()
+	}
+}
+
+// isClosedErr 连接已关闭后读写返回的错误
+func isClosedErr(err error) bool {
+	return errors.Is(err, net.ErrClosed) || errors.Is(err, io.ErrClosedPipe)
+}
+
+func (rl *RateLimit) init() {
+	rl.interceptor = &Interceptor{
+		Read: func(info Info, b []byte, invoker func([]byte) (int, error)) (int, error) {
+			cl := rl.getConn(info)
+			n, err := invoker(b[:cl.read.ChunkSize(len(b))])
+			if isClosedErr(err) {
+				// 在 Close 之后调用的 Read，getConn 会重新创建限速器，需要释放
+				rl.release(info)
+				return n, err
+			}
+			if n > 0 {
+				if err1 := cl.read.WaitN(cl.ctx, n); err1 != nil && err == nil {
+					err = err1
+				}
+			}
+			return n, err
+		},
+		Write: func(info Info, b []byte, invoker func([]byte) (int, error)) (int, error) {
+			cl := rl.getConn(info)
+			var wrote int
+			for len(b) > 0 {
+				size := cl.write.ChunkSize(len(b))
+				if err := cl.write.WaitN(cl.ctx, size); err != nil {
+					return wrote, err
+				}
+				n, err := invoker(b[:size])
+				wrote += n
+				if err != nil {
+					if isClosedErr(err) {
+						rl.release(info)
+					}
+					return wrote, err
+				}
+				b = b[size:]
+			}
+			return wrote, nil
+		},
+		AfterClose: func(info Info, _ error) {
+			rl.release(info)
+		},
+	}
+}
+
+// Interceptor 获取 Interceptor 实例
+func (rl *RateLimit) Interceptor() *Interceptor {
+	rl.once.Do(rl.init)
+	return rl.interceptor
+}
@@ -0,0 +1,67 @@
+// Copyright(C) 2026 github.com/fsgo  All Rights Reserved.
+// Author: hidu <duv123@gmail.com>
+// Date: 2026/10/19
+
+package fsconn
+
+import (
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/fsgo/fsgo/fsio"
+	"github.com/fsgo/fst"
+)
+
+func TestRateLimit(t *testing.T) {
+	rl := &RateLimit{
+		Write:    fsio.NewRateLimiter(100*1024, 0),
+		ConnRead: 10 * 1024,
+	}
+	c1, c2 := net.Pipe()
+	w := Wrap(c1, rl.Interceptor())
+	r := Wrap(c2, rl.Interceptor())
+
+	go func() {
+		_, _ = w.Write(make([]byte, 20*1024))
+		_ = w.Close()
+	}()
+	start := time.Now()
+	bf, err := io.ReadAll(r)
+	fst.NoError(t, err)
+	fst.Len(t, bf, 20*1024)
+	fst.True(t, time.Since(start) >= 900*time.Millisecond)
+	fst.NoError(t, r.Close())
+
+	var conns int
+	rl.conns.Range(func(_, _ any) bool {
+		conns++
+		return true
+	})
+	fst.Equal(t, 0, conns)
+}
+
+func TestRateLimit_afterClose(t *testing.T) {
+	rl := &RateLimit{
+		ConnRead:  10 * 1024,
+		ConnWrite: 10 * 1024,
+	}
+	c1, c2 := net.Pipe()
+	defer c2.Close()
+	w := Wrap(c1, rl.Interceptor())
+	fst.NoError(t, w.Close())
+
+	// Close 之后的读写不能再保留连接的限速器
+	_, err := w.Write([]byte("hello"))
+	fst.Error(t, err)
+	_, err = w.Read(make([]byte, 10))
+	fst.Error(t, err)
+
+	var conns int
+	rl.conns.Range(func(_, _ any) bool {
+		conns++
+		return true
+	})
+	fst.Equal(t, 0, conns)
+}
@@ -0,0 +1,484 @@
+// Copyright(C) 2026 github.com/fsgo  All Rights Reserved.
+// Author: hidu <duv123@gmail.com>
+// Date: 2026/10/19
+
+package fsconn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/fsgo/fsgo/fsio"
+	"github.com/fsgo/fsgo/fsnet/fsaddr"
+)
+
+// streamChunkSize 每次从缓冲区取出发送的最大字节数
+const streamChunkSize = 32 * 1024
+
+var errReadWithAck = errors.New("cannot Read when AckReader is set")
+
+// StreamConnStats StreamConn 的统计信息
+type StreamConnStats struct {
+	// LastError 最近一次拨号或者读写的错误
+	LastError string
+
+	// Connected 当前是否已连接
+	Connected bool
+
+	// Connects 连接成功的次数
+	Connects int64
+
+	// DialFailures 拨号失败的次数
+	DialFailures int64
+
+	// Buffered 缓冲区中待发送的字节数，包括磁盘中的
+	Buffered int64
+
+	// Spilled 磁盘中待发送的字节数
+	Spilled int64
+
+	// Unacked 已发送但是未确认的字节数
+	Unacked int64
+
+	// Sent 写入连接的字节数，包括重发的
+	Sent int64
+
+	// Replayed 重连后重新发送的字节数
+	Replayed int64
+
+	// Acked 对端已确认的字节数
+	Acked int64
+
+	// Dropped 缓冲区满时丢弃数据的次数
+	Dropped int64
+
+	// DroppedBytes 缓冲区满时丢弃的字节数
+	DroppedBytes int64
+}
+
+type streamStats struct {
+	lastError    atomic.Pointer[string]
+	connects     atomic.Int64
+	dialFailures atomic.Int64
+	sent         atomic.Int64
+	replayed     atomic.Int64
+	acked        atomic.Int64
+	dropped      atomic.Int64
+	droppedBytes atomic.Int64
+}
+
+func (s *streamStats) setLastError(err error) {
+	msg := err.Error()
+	s.lastError.Store(&msg)
+}
+
+// Flush 等待 Buffered 模式下缓冲区的数据全部发送（并确认）完成
+func (sc *StreamConn) Flush(ctx context.Context) error {
+	if !sc.Buffered {
+		return nil
+	}
+	if sc.isClosed() && sc.startedBuffered() == nil {
+		// 已关闭，并且从未写入过数据
+		return nil
+	}
+	return sc.getBuffered().flush(ctx)
+}
+
+func (sc *StreamConn) getBufferSize() int64 {
+	if sc.BufferSize > 0 {
+		return sc.BufferSize
+	}
+	return 4 * 1024 * 1024
+}
+
+func (sc *StreamConn) getDrainTimeout() time.Duration {
+	if sc.DrainTimeout > 0 {
+		return sc.DrainTimeout
+	}
+	return 5 * time.Second
+}
+
+// getBuffered 返回 Buffered 模式的状态，第一次调用时开始拨号和发送，
+// 只在 Write、Read、Flush 时调用，以免没有使用的连接也去拨号
+func (sc *StreamConn) getBuffered() *streamBuffered {
+	sc.buffOnce.Do(func() {
+		b := &streamBuffered{
+			sc: sc,
+			buf: streamBuffer{
+				dir:      sc.SpillDir,
+				maxMem:   sc.getBufferSize(),
+				maxSpill: sc.MaxSpillSize,
+			},
+			done: make(chan struct{}),
+		}
+		b.cond = sync.NewCond(&b.mux)
+		b.ctx, b.cancel = context.WithCancel(context.Background())
+		sc.buffered.Store(b)
+		go b.run()
+	})
+	return sc.buffered.Load()
+}
+
+// startedBuffered 返回已开始的 Buffered 模式的状态，还未开始时返回 nil
+func (sc *StreamConn) startedBuffered() *streamBuffered {
+	return sc.buffered.Load()
+}
+
+// streamSession 一个连接的发送状态
+type streamSession struct {
+	conn net.Conn
+
+	// err 连接的读写错误，非 nil 时需要重新连接
+	err error
+}
+
+// streamBuffered StreamConn 的 Buffered 模式
+type streamBuffered struct {
+	ctx     context.Context
+	sc      *StreamConn
+	cond    *sync.Cond
+	session *streamSession
+	cancel  context.CancelFunc
+	done    chan struct{}
+
+	// unacked 已发送但是未确认的数据
+	unacked [][]byte
+	buf     streamBuffer
+
+	unackedSize int64
+
+	// inflight 正在写入连接的字节数
+	inflight int64
+
+	mux sync.Mutex
+
+	// closing 调用了 Close，不再接收新的数据
+	closing bool
+
+	// stopped 等待发送超时，停止发送
+	stopped bool
+}
+
+func (b *streamBuffered) isEmpty() bool {
+	return b.buf.size() == 0 && b.unackedSize == 0 && b.inflight == 0
+}
+
+func (b *streamBuffered) shouldExit() bool {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	return b.stopped || (b.closing && b.isEmpty())
+}
+
+// sleep 等待 d，停止时返回 false
+func (b *streamBuffered) sleep(d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-b.ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+func (b *streamBuffered) run() {
+	defer close(b.done)
+	sc := b.sc
+	var try int
+	for !b.shouldExit() {
+		conn, err := sc.dial(b.ctx)
+		if err != nil {
+			if conn != nil {
+				_ = conn.Close()
+			}
+			if sc.canLog() {
+				sc.log("connect to ", sc.Addr.String(), " failed, try=", try, " err=", err.Error())
+			}
+			if !b.sleep(sc.backoff(try)) {
+				return
+			}
+			try++
+			continue
+		}
+		try = 0
+		err = b.serve(conn)
+		_ = conn.Close()
+		if err != nil {
+			sc.stats.setLastError(err)
+			if sc.canLog() {
+				sc.log("write to ", conn.RemoteAddr().String(), " failed, err=", err.Error())
+			}
+		}
+	}
+}
+
+// serve 在连接上先重发未确认的数据，然后发送缓冲区的数据，直到连接出错或者数据已经全部发送
+func (b *streamBuffered) serve(conn net.Conn) error {
+	s := &streamSession{conn: conn}
+	b.mux.Lock()
+	b.session = s
+	replay := slices.Clone(b.unacked)
+	b.cond.Broadcast()
+	b.mux.Unlock()
+
+	defer func() {
+		b.mux.Lock()
+		if b.session == s {
+			b.session = nil
+		}
+		b.mux.Unlock()
+	}()
+
+	sc := b.sc
+	if sc.AckReader != nil {
+		go b.readAck(s)
+	}
+	for _, p := range replay {
+		n, err := conn.Write(p)
+		sc.stats.sent.Add(int64(n))
+		sc.stats.replayed.Add(int64(n))
+		if err != nil {
+			return err
+		}
+	}
+	for {
+		p, err := b.next(s)
+		if err != nil || p == nil {
+			return err
+		}
+		n, err := conn.Write(p)
+		sc.stats.sent.Add(int64(n))
+		b.mux.Lock()
+		b.inflight = 0
+		if err != nil && sc.AckReader == nil {
+			b.buf.pushFront(p[n:])
+		}
+		b.cond.Broadcast()
+		b.mux.Unlock()
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// next 取出下一段要发送的数据，数据已经全部发送并且已经 Close 时返回 nil
+func (b *streamBuffered) next(s *streamSession) ([]byte, error) {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	withAck := b.sc.AckReader != nil
+	for {
+		if s.err != nil {
+			return nil, s.err
+		}
+		if b.stopped {
+			return nil, errClosed
+		}
+		windowFull := withAck && b.unackedSize >= b.buf.maxMem
+		if !windowFull && b.buf.size() > 0 {
+			p, err := b.buf.pop(streamChunkSize)
+			if err != nil {
+				return nil, err
+			}
+			if withAck {
+				b.unacked = append(b.unacked, p)
+				b.unackedSize += int64(len(p))
+			} else {
+				b.inflight = int64(len(p))
+			}
+			// 通知等待缓冲区空间的 Write
+			b.cond.Broadcast()
+			return p, nil
+		}
+		if b.closing && b.isEmpty() {
+			return nil, nil
+		}
+		b.cond.Wait()
+	}
+}
+
+func (b *streamBuffered) readAck(s *streamSession) {
+	for {
+		n, err := b.sc.AckReader(s.conn)
+		b.mux.Lock()
+		if b.session != s {
+			b.mux.Unlock()
+			return
+		}
+		if err != nil {
+			b.mux.Unlock()
+			b.fail(s, err)
+			return
+		}
+		b.ack(n)
+		b.cond.Broadcast()
+		b.mux.Unlock()
+	}
+}
+
+// ack 从 unacked 中删除已确认的数据
+func (b *streamBuffered) ack(n int64) {
+	for n > 0 && len(b.unacked) > 0 {
+		p := b.unacked[0]
+		size := min(n, int64(len(p)))
+		if size == int64(len(p)) {
+			b.unacked[0] = nil
+			b.unacked = b.unacked[1:]
+		} else {
+			b.unacked[0] = p[size:]
+		}
+		n -= size
+		b.unackedSize -= size
+		b.sc.stats.acked.Add(size)
+	}
+}
+
+// fail 连接出错，需要重新连接
+func (b *streamBuffered) fail(s *streamSession, err error) {
+	b.mux.Lock()
+	if s.err == nil {
+		s.err = err
+	}
+	b.cond.Broadcast()
+	b.mux.Unlock()
+	_ = s.conn.Close()
+}
+
+func (b *streamBuffered) write(p []byte) (int, error) {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	for {
+		if b.closing {
+			return 0, errClosed
+		}
+		ok, err := b.buf.push(p)
+		if err != nil {
+			return 0, err
+		}
+		if ok {
+			b.cond.Broadcast()
+			return len(p), nil
+		}
+		switch b.sc.Overflow {
+		case fsio.OverflowDropNewest:
+			b.sc.stats.dropped.Add(1)
+			b.sc.stats.droppedBytes.Add(int64(len(p)))
+			return 0, fsio.ErrDropped
+		case fsio.OverflowDropOldest:
+			b.sc.stats.dropped.Add(1)
+			b.sc.stats.droppedBytes.Add(b.buf.dropOldest())
+		default:
+			b.cond.Wait()
+		}
+	}
+}
+
+func (b *streamBuffered) read(p []byte) (int, error) {
+	if b.sc.AckReader != nil {
+		return 0, errReadWithAck
+	}
+	b.mux.Lock()
+	for b.session == nil && !b.stopped && !b.closing {
+		b.cond.Wait()
+	}
+	s := b.session
+	b.mux.Unlock()
+	if s == nil {
+		return 0, errClosed
+	}
+	n, err := s.conn.Read(p)
+	if err != nil {
+		var ne net.Error
+		if !errors.As(err, &ne) || !ne.Timeout() {
+			b.sc.stats.setLastError(err)
+			b.fail(s, err)
+		}
+	}
+	return n, err
+}
+
+func (b *streamBuffered) remoteAddr() net.Addr {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	if b.session != nil {
+		return b.session.conn.RemoteAddr()
+	}
+	return fsaddr.Empty
+}
+
+func (b *streamBuffered) fillStats(st *StreamConnStats) {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	st.Connected = b.session != nil && b.session.err == nil
+	st.Buffered = b.buf.size()
+	st.Spilled = b.buf.spilled()
+	st.Unacked = b.unackedSize
+}
+
+func (b *streamBuffered) flush(ctx context.Context) error {
+	stop := context.AfterFunc(ctx, func() {
+		b.mux.Lock()
+		b.cond.Broadcast()
+		b.mux.Unlock()
+	})
+	defer stop()
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	for !b.isEmpty() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if b.stopped {
+			return errClosed
+		}
+		b.cond.Wait()
+	}
+	return nil
+}
+
+// stop 停止发送，关闭当前的连接
+func (b *streamBuffered) stop() {
+	b.cancel()
+	b.mux.Lock()
+	b.stopped = true
+	s := b.session
+	b.cond.Broadcast()
+	b.mux.Unlock()
+	if s != nil {
+		_ = s.conn.Close()
+	}
+}
+
+// close 不再接收新的数据，等待缓冲区的数据发送完成，最长等待 DrainTimeout
+func (b *streamBuffered) close() error {
+	b.mux.Lock()
+	b.closing = true
+	empty := b.isEmpty()
+	b.cond.Broadcast()
+	b.mux.Unlock()
+	if empty {
+		// 没有需要发送的数据，不用等待连接
+		b.stop()
+	}
+
+	timer := time.AfterFunc(b.sc.getDrainTimeout(), b.stop)
+	<-b.done
+	timer.Stop()
+	b.stop()
+
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	remain := b.buf.size() + b.unackedSize + b.inflight
+	err := b.buf.close()
+	b.unacked = nil
+	b.unackedSize = 0
+	if remain > 0 {
+		return errors.Join(fmt.Errorf("closed with %d bytes not sent", remain), err)
+	}
+	return err
+}
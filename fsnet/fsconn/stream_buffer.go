@@ -0,0 +1,148 @@
+// Copyright(C) 2026 github.com/fsgo  All Rights Reserved.
+// Author: hidu <duv123@gmail.com>
+// Date: 2026/10/19
+
+package fsconn
+
+import (
+	"os"
+)
+
+// streamBuffer StreamConn 待发送数据的缓冲区
+//
+// 内存满后数据写入磁盘文件，并且之后的数据也写入磁盘，直到磁盘中的数据全部取出，
+// 所以内存中的数据总是比磁盘中的旧，取出时先取内存中的
+type streamBuffer struct {
+	spill *os.File
+
+	// dir 磁盘缓冲的目录，为空时不使用磁盘
+	dir string
+
+	mem [][]byte
+
+	memSize int64
+	maxMem  int64
+
+	// spillR spillW 磁盘文件的读写位置
+	spillR   int64
+	spillW   int64
+	maxSpill int64
+}
+
+// size 待发送的字节数
+func (b *streamBuffer) size() int64 {
+	return b.memSize + b.spilled()
+}
+
+// spilled 磁盘中待发送的字节数
+func (b *streamBuffer) spilled() int64 {
+	return b.spillW - b.spillR
+}
+
+// push 写入数据，空间不足时返回 false
+func (b *streamBuffer) push(p []byte) (bool, error) {
+	if len(p) == 0 {
+		return true, nil
+	}
+	if b.spilled() == 0 && (b.memSize == 0 || b.memSize+int64(len(p)) <= b.maxMem) {
+		b.mem = append(b.mem, append([]byte(nil), p...))
+		b.memSize += int64(len(p))
+		return true, nil
+	}
+	if b.dir == "" {
+		return false, nil
+	}
+	if b.maxSpill > 0 && b.spilled() > 0 && b.spilled()+int64(len(p)) > b.maxSpill {
+		return false, nil
+	}
+	if b.spill == nil {
+		f, err := os.CreateTemp(b.dir, "stream_conn_*.spill")
+		if err != nil {
+			return false, err
+		}
+		b.spill = f
+	}
+	n, err := b.spill.WriteAt(p, b.spillW)
+	if err != nil {
+		return false, err
+	}
+	b.spillW += int64(n)
+	return true, nil
+}
+
+// pushFront 将未发送成功的数据放回最前面
+func (b *streamBuffer) pushFront(p []byte) {
+	if len(p) == 0 {
+		return
+	}
+	b.mem = append([][]byte{p}, b.mem...)
+	b.memSize += int64(len(p))
+}
+
+// pop 取出最多 max 字节的数据，没有数据时返回 nil
+func (b *streamBuffer) pop(max int) ([]byte, error) {
+	if len(b.mem) > 0 {
+		p := b.mem[0]
+		if len(p) > max {
+			b.mem[0] = p[max:]
+			p = p[:max]
+		} else {
+			b.mem[0] = nil
+			b.mem = b.mem[1:]
+		}
+		b.memSize -= int64(len(p))
+		return p, nil
+	}
+	n := min(int64(max), b.spilled())
+	if n == 0 {
+		return nil, nil
+	}
+	p := make([]byte, n)
+	if _, err := b.spill.ReadAt(p, b.spillR); err != nil {
+		return nil, err
+	}
+	b.spillR += n
+	b.resetSpill()
+	return p, nil
+}
+
+// resetSpill 磁盘中的数据已经全部取出时，清空文件
+func (b *streamBuffer) resetSpill() {
+	if b.spilled() > 0 || b.spill == nil {
+		return
+	}
+	b.spillR = 0
+	b.spillW = 0
+	_ = b.spill.Truncate(0)
+}
+
+// dropOldest 丢弃最旧的一段数据，返回丢弃的字节数
+func (b *streamBuffer) dropOldest() int64 {
+	if len(b.mem) > 0 {
+		n := int64(len(b.mem[0]))
+		b.mem[0] = nil
+		b.mem = b.mem[1:]
+		b.memSize -= n
+		return n
+	}
+	n := min(streamChunkSize, b.spilled())
+	b.spillR += n
+	b.resetSpill()
+	return n
+}
+
+func (b *streamBuffer) close() error {
+	b.mem = nil
+	b.memSize = 0
+	if b.spill == nil {
+		return nil
+	}
+	err := b.spill.Close()
+	if err1 := os.Remove(b.spill.Name()); err == nil {
+		err = err1
+	}
+	b.spill = nil
+	b.spillR = 0
+	b.spillW = 0
+	return err
+}
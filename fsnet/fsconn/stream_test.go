@@ -0,0 +1,266 @@
+// Copyright(C) 2026 github.com/fsgo  All Rights Reserved.
+// Author: hidu <duv123@gmail.com>
+// Date: 2026/10/19
+
+package fsconn
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/fsgo/fst"
+
+	"github.com/fsgo/fsgo/fsio"
+	"github.com/fsgo/fsgo/fsnet/fsaddr"
+)
+
+// testStreamServer 用于测试的服务端，使用 net.Pipe 建立连接
+type testStreamServer struct {
+	// handle 处理连接，返回读取到的数据
+	handle func(conn net.Conn) []byte
+	data   bytes.Buffer
+	wg     sync.WaitGroup
+	mux    sync.Mutex
+	up     atomic.Bool
+}
+
+func (s *testStreamServer) Dial(ctx context.Context, _ net.Addr) (net.Conn, error) {
+	if !s.up.Load() {
+		return nil, errors.New("server is down")
+	}
+	c1, c2 := net.Pipe()
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		var bf []byte
+		if s.handle != nil {
+			bf = s.handle(c2)
+		} else {
+			bf, _ = io.ReadAll(c2)
+		}
+		_ = c2.Close()
+		s.mux.Lock()
+		s.data.Write(bf)
+		s.mux.Unlock()
+	}()
+	return c1, nil
+}
+
+func (s *testStreamServer) String() string {
+	s.wg.Wait()
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	return s.data.String()
+}
+
+func TestStreamConnBackoff(t *testing.T) {
+	sc := &StreamConn{RetryWait: 10 * time.Millisecond}
+	fst.Equal(t, 10*time.Millisecond, sc.backoff(5))
+
+	sc.MaxRetryWait = 100 * time.Millisecond
+	fst.Equal(t, 10*time.Millisecond, sc.backoff(0))
+	fst.Equal(t, 40*time.Millisecond, sc.backoff(2))
+	fst.Equal(t, 100*time.Millisecond, sc.backoff(10))
+
+	sc.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		d := sc.backoff(10)
+		fst.True(t, d >= 50*time.Millisecond && d <= 150*time.Millisecond)
+	}
+}
+
+func TestStreamConnBuffered(t *testing.T) {
+	srv := &testStreamServer{}
+	sc := &StreamConn{
+		Addr:       fsaddr.Empty,
+		Dial:       srv.Dial,
+		Buffered:   true,
+		RetryWait:  5 * time.Millisecond,
+		BufferSize: 1024,
+		SpillDir:   t.TempDir(),
+	}
+	want := &bytes.Buffer{}
+	for i := 0; i < 100; i++ {
+		line := bytes.Repeat([]byte{byte('a' + i%26)}, 100)
+		want.Write(line)
+		n, err := sc.Write(line)
+		fst.NoError(t, err)
+		fst.Equal(t, 100, n)
+	}
+	time.Sleep(20 * time.Millisecond)
+	st := sc.Stats()
+	fst.False(t, st.Connected)
+	fst.Equal(t, int64(100*100), st.Buffered)
+	fst.True(t, st.Spilled > 0)
+	fst.True(t, st.DialFailures > 0)
+
+	srv.up.Store(true)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	fst.NoError(t, sc.Flush(ctx))
+	st = sc.Stats()
+	fst.True(t, st.Connected)
+	fst.Equal(t, int64(0), st.Buffered)
+	fst.Equal(t, int64(100*100), st.Sent)
+
+	_, err := sc.Write([]byte("end"))
+	fst.NoError(t, err)
+	want.WriteString("end")
+	fst.NoError(t, sc.Close())
+	fst.Equal(t, want.String(), srv.String())
+
+	_, err = sc.Write([]byte("x"))
+	fst.Error(t, err)
+}
+
+func TestStreamConnBufferedLazy(t *testing.T) {
+	srv := &testStreamServer{}
+	srv.up.Store(true)
+	var dials atomic.Int64
+	sc := &StreamConn{
+		Addr: fsaddr.Empty,
+		Dial: func(ctx context.Context, addr net.Addr) (net.Conn, error) {
+			dials.Add(1)
+			return srv.Dial(ctx, addr)
+		},
+		Buffered: true,
+	}
+	// 在第一次 Write 之前，不会拨号
+	fst.Equal(t, StreamConnStats{}, sc.Stats())
+	fst.Equal[net.Addr](t, fsaddr.Empty, sc.RemoteAddr())
+	time.Sleep(10 * time.Millisecond)
+	fst.Equal(t, int64(0), dials.Load())
+
+	_, err := sc.Write([]byte("hello"))
+	fst.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	fst.NoError(t, sc.Flush(ctx))
+	fst.Equal(t, int64(1), dials.Load())
+	fst.True(t, sc.Stats().Connected)
+	fst.NoError(t, sc.Close())
+	fst.Equal(t, "hello", srv.String())
+
+	// 从未写入数据的连接，Close 时也不会拨号
+	sc2 := &StreamConn{Addr: fsaddr.Empty, Dial: sc.Dial, Buffered: true}
+	fst.NoError(t, sc2.Close())
+	fst.NoError(t, sc2.Flush(ctx))
+	_, err = sc2.Write([]byte("x"))
+	fst.Error(t, err)
+	fst.Equal(t, int64(1), dials.Load())
+}
+
+func TestStreamConnAck(t *testing.T) {
+	var conns atomic.Int32
+	srv := &testStreamServer{
+		handle: func(conn net.Conn) []byte {
+			buf := make([]byte, 5)
+			if conns.Add(1) == 1 {
+				// 第一个连接：读取数据后不确认，直接断开
+				_, _ = io.ReadFull(conn, buf)
+				return nil
+			}
+			var got []byte
+			for {
+				n, err := conn.Read(buf)
+				if n > 0 {
+					got = append(got, buf[:n]...)
+					_ = binary.Write(conn, binary.BigEndian, uint64(n))
+				}
+				if err != nil {
+					return got
+				}
+			}
+		},
+	}
+	srv.up.Store(true)
+	sc := &StreamConn{
+		Addr:      fsaddr.Empty,
+		Dial:      srv.Dial,
+		Buffered:  true,
+		RetryWait: time.Millisecond,
+		AckReader: func(r io.Reader) (int64, error) {
+			var n uint64
+			err := binary.Read(r, binary.BigEndian, &n)
+			return int64(n), err
+		},
+	}
+	_, err := sc.Write([]byte("hello"))
+	fst.NoError(t, err)
+	_, err = sc.Write([]byte(" world"))
+	fst.NoError(t, err)
+	_, err = sc.Read(make([]byte, 1))
+	fst.Error(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	fst.NoError(t, sc.Flush(ctx))
+	st := sc.Stats()
+	fst.Equal(t, int64(0), st.Unacked)
+	fst.Equal(t, int64(11), st.Acked)
+	fst.True(t, st.Replayed > 0)
+	fst.True(t, st.Connects >= 2)
+	fst.NoError(t, sc.Close())
+	fst.Equal(t, "hello world", srv.String())
+}
+
+func TestStreamConnOverflow(t *testing.T) {
+	srv := &testStreamServer{}
+	sc := &StreamConn{
+		Addr:         fsaddr.Empty,
+		Dial:         srv.Dial,
+		Buffered:     true,
+		RetryWait:    time.Millisecond,
+		BufferSize:   10,
+		Overflow:     fsio.OverflowDropNewest,
+		DrainTimeout: 20 * time.Millisecond,
+	}
+	_, err := sc.Write([]byte("0123456789"))
+	fst.NoError(t, err)
+	_, err = sc.Write([]byte("a"))
+	fst.Equal(t, fsio.ErrDropped, err)
+
+	sc.Overflow = fsio.OverflowDropOldest
+	_, err = sc.Write([]byte("abc"))
+	fst.NoError(t, err)
+	st := sc.Stats()
+	fst.Equal(t, int64(2), st.Dropped)
+	fst.Equal(t, int64(11), st.DroppedBytes)
+	fst.Equal(t, int64(3), st.Buffered)
+
+	// 一直无法连接，Close 超时后返回 error
+	start := time.Now()
+	fst.Error(t, sc.Close())
+	fst.True(t, time.Since(start) >= 20*time.Millisecond)
+}
+
+func TestStreamConnRead(t *testing.T) {
+	srv := &testStreamServer{
+		handle: func(conn net.Conn) []byte {
+			_, _ = io.Copy(conn, conn)
+			return nil
+		},
+	}
+	srv.up.Store(true)
+	sc := &StreamConn{
+		Addr:     fsaddr.Empty,
+		Dial:     srv.Dial,
+		Buffered: true,
+	}
+	_, err := sc.Write([]byte("ping"))
+	fst.NoError(t, err)
+	buf := make([]byte, 4)
+	_, err = io.ReadFull(sc, buf)
+	fst.NoError(t, err)
+	fst.Equal(t, "ping", string(buf))
+	fst.Equal(t, "pipe", sc.RemoteAddr().String())
+	fst.NoError(t, sc.Close())
+}
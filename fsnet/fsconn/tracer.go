@@ -97,16 +97,28 @@ type PrintByteTracer struct {
 	interceptor *Interceptor
 	once        sync.Once
 	Out         io.Writer
+
+	// Format 输出格式，可选
+	Format fsio.PrintFormat
+
+	// Color 是否使用彩色输出读写方向标记
+	Color bool
 }
 
 func (pb *PrintByteTracer) init() {
 	rb := &fsio.PrintByteWriter{
-		Name: "Read",
-		Out:  pb.Out,
+		Name:      "Read",
+		Out:       pb.Out,
+		Format:    pb.Format,
+		Direction: fsio.PrintDirectionRead,
+		Color:     pb.Color,
 	}
 	wb := &fsio.PrintByteWriter{
-		Name: "Write",
-		Out:  pb.Out,
+		Name:      "Write",
+		Out:       pb.Out,
+		Format:    pb.Format,
+		Direction: fsio.PrintDirectionWrite,
+		Color:     pb.Color,
 	}
 	pb.interceptor = &Interceptor{
 		AfterRead: func(info Info, b []byte, readSize int, err error) {
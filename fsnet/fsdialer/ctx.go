@@ -8,4 +8,5 @@ type ctxKey uint8
 
 const (
 	ctxKeyInterceptor ctxKey = iota
+	ctxKeyProxyHeader
 )
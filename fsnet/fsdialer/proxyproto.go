@@ -0,0 +1,85 @@
+// Copyright(C) 2026 github.com/fsgo  All Rights Reserved.
+// Author: hidu <duv123@gmail.com>
+// Date: 2026/10/19
+
+package fsdialer
+
+import (
+	"context"
+	"net"
+	"time"
+
+	"github.com/fsgo/fsgo/fsnet/fsconn"
+)
+
+// ContextWithProxyHeader 设置拨号时 ProxyProtocol 发送的 PROXY protocol header
+func ContextWithProxyHeader(ctx context.Context, h *fsconn.ProxyHeader) context.Context {
+	return context.WithValue(ctx, ctxKeyProxyHeader, h)
+}
+
+// ProxyHeaderFromContext 读取 ctx 中的 PROXY protocol header
+func ProxyHeaderFromContext(ctx context.Context) *fsconn.ProxyHeader {
+	h, _ := ctx.Value(ctxKeyProxyHeader).(*fsconn.ProxyHeader)
+	return h
+}
+
+// ProxyProtocol 拨号成功后，发送 PROXY protocol header
+//
+// header 的获取顺序：ctx 中的（ContextWithProxyHeader）、Header 方法返回的，
+// 都没有时发送 LOCAL 命令
+type ProxyProtocol struct {
+	// Header 可选，获取要发送的 header
+	Header func(ctx context.Context, conn net.Conn) *fsconn.ProxyHeader
+
+	// Version 协议版本，1 或者 2，可选，默认为 1
+	// header 中未设置 Version 时使用
+	Version int
+}
+
+func (pp *ProxyProtocol) getHeader(ctx context.Context, conn net.Conn) *fsconn.ProxyHeader {
+	h := ProxyHeaderFromContext(ctx)
+	if h == nil && pp.Header != nil {
+		h = pp.Header(ctx, conn)
+	}
+	if h == nil {
+		h = &fsconn.ProxyHeader{Command: fsconn.ProxyCommandLocal}
+	}
+	if h.Version == 0 || (h.Destination == nil && h.Source != nil) {
+		nh := *h
+		if nh.Version == 0 {
+			nh.Version = pp.Version
+		}
+		if nh.Destination == nil {
+			nh.Destination = conn.RemoteAddr()
+		}
+		h = &nh
+	}
+	return h
+}
+
+func (pp *ProxyProtocol) send(ctx context.Context, conn net.Conn) error {
+	if dl, ok := ctx.Deadline(); ok {
+		_ = conn.SetWriteDeadline(dl)
+		defer func() {
+			_ = conn.SetWriteDeadline(time.Time{})
+		}()
+	}
+	_, err := pp.getHeader(ctx, conn).WriteTo(conn)
+	return err
+}
+
+// Interceptor 获取拨号器的拦截器
+func (pp *ProxyProtocol) Interceptor() *Interceptor {
+	return &Interceptor{
+		AfterDialContext: func(ctx context.Context, _ string, _ string, conn net.Conn, err error) (net.Conn, error) {
+			if err != nil {
+				return conn, err
+			}
+			if err = pp.send(ctx, conn); err != nil {
+				_ = conn.Close()
+				return nil, err
+			}
+			return conn, nil
+		},
+	}
+}
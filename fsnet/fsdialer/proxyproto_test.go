@@ -0,0 +1,63 @@
+// Copyright(C) 2026 github.com/fsgo  All Rights Reserved.
+// Author: hidu <duv123@gmail.com>
+// Date: 2026/10/19
+
+package fsdialer
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/fsgo/fst"
+
+	"github.com/fsgo/fsgo/fsnet/fsconn"
+)
+
+func TestProxyProtocol(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	fst.NoError(t, err)
+	pp := &fsconn.ProxyProtocol{TrustedCIDRs: []string{"127.0.0.1/32"}, Required: true}
+	pl := pp.Listener(l)
+	defer pl.Close()
+
+	type result struct {
+		remote string
+		data   string
+	}
+	results := make(chan result, 2)
+	go func() {
+		for {
+			conn, err := pl.Accept()
+			if err != nil {
+				return
+			}
+			bf, _ := io.ReadAll(conn)
+			results <- result{remote: conn.RemoteAddr().String(), data: string(bf)}
+			_ = conn.Close()
+		}
+	}()
+
+	d := &Simple{
+		Interceptors: []*Interceptor{(&ProxyProtocol{Version: 2}).Interceptor()},
+	}
+
+	src := &net.TCPAddr{IP: net.ParseIP("192.168.1.10"), Port: 5000}
+	ctx := ContextWithProxyHeader(context.Background(), &fsconn.ProxyHeader{Source: src})
+	conn, err := d.DialContext(ctx, "tcp", l.Addr().String())
+	fst.NoError(t, err)
+	_, err = conn.Write([]byte("hello"))
+	fst.NoError(t, err)
+	fst.NoError(t, conn.Close())
+	ret := <-results
+	fst.Equal(t, "192.168.1.10:5000", ret.remote)
+	fst.Equal(t, "hello", ret.data)
+
+	// 没有 header 时发送 LOCAL 命令，使用连接本身的地址
+	conn, err = d.DialContext(context.Background(), "tcp", l.Addr().String())
+	fst.NoError(t, err)
+	fst.NoError(t, conn.Close())
+	ret = <-results
+	fst.Equal(t, conn.LocalAddr().String(), ret.remote)
+}
@@ -11,6 +11,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"sort"
@@ -174,10 +175,76 @@ type WorkerConfig struct {
 
 	// Watches 可选，用于监听版本变化情况的文件列表
 	Watches []string
+
+	// Instances 可选，同时运行的子进程实例数，默认为 1
+	// 所有实例共享 Listen 的资源句柄，由内核将连接分配给各个实例
+	// 子进程可以通过 InstanceIndex() 获取自己的实例序号
+	Instances int
+
+	// VersionMode 可选，计算版本（判断 Cmd、EnvFile、Watches 是否有变化）的方式，默认为 "stat"
+	// stat: 使用文件的 mode、mtime、size 计算，Watches 中的目录也会计算
+	// hash: 使用文件的内容计算，可以发现 mtime 和 size 都没变的文件替换，会忽略 Watches 中的目录
+	VersionMode string
+
+	// SettleDelay 可选，文件变化后，需要保持不变的时长，之后才会 reload，如 "3s"
+	// 用于避免文件（如二进制文件）还在写入过程中就触发 reload
+	SettleDelay string
+
+	// Notify 可选，是否使用 inotify 监听文件的变化，以在文件变化后及时 reload
+	// 仅在 linux 上有效，在不支持或者初始化失败时，依然是按照 CheckInterval 的间隔检查
+	Notify bool
+
+	// Log 可选，子进程 stdout、stderr 日志的配置
+	// 默认按小时切割，分别写入 LogDir 下的 stdout.log 和 stderr.log，并同时输出到 master 的 stdout、stderr
+	Log *WorkerLog
+
+	// Hooks 可选，在子进程生命周期的各个阶段执行的命令
+	Hooks *WorkerHooks
+
+	// Limits 可选，子进程的资源限制和隔离配置，若不配置，子进程继承 master 的
+	Limits *WorkerLimits
+
+	// MaxUnavailable 可选，reload 时每批同时重启的实例数，默认为 1
+	// reload 时，各实例分批滚动进行，且每个实例都是新进程启动成功后才会停止老进程，
+	// 所以 reload 过程中不会出现没有可用实例的情况
+	MaxUnavailable int
 }
 
+const (
+	// VersionModeStat 使用文件的 mode、mtime、size 计算版本
+	VersionModeStat = "stat"
+
+	// VersionModeHash 使用文件的内容计算版本
+	VersionModeHash = "hash"
+)
+
 // Parser 解析当前配置
 func (c *WorkerConfig) Parser() error {
+	switch c.VersionMode {
+	case "", VersionModeStat, VersionModeHash:
+	default:
+		return fmt.Errorf("not support VersionMode %q", c.VersionMode)
+	}
+	for _, stage := range []HookStage{HookPreStart, HookPostStart, HookPreStop, HookPostStop} {
+		if hc := c.Hooks.get(stage); hc != nil && len(hc.Cmd) == 0 {
+			return fmt.Errorf("empty Hooks.%s.Cmd", stage)
+		}
+	}
+	if c.Log != nil {
+		if err := c.Log.Parser(); err != nil {
+			return fmt.Errorf("invalid Log: %w", err)
+		}
+	}
+	if c.Limits != nil {
+		if err := c.Limits.Parser(); err != nil {
+			return fmt.Errorf("invalid Limits: %w", err)
+		}
+	}
+	if len(c.SettleDelay) > 0 {
+		if _, err := time.ParseDuration(c.SettleDelay); err != nil {
+			return fmt.Errorf("invalid SettleDelay %q: %w", c.SettleDelay, err)
+		}
+	}
 	return nil
 }
 
@@ -197,6 +264,39 @@ func (c *WorkerConfig) getStopTimeout() time.Duration {
 	return t
 }
 
+func (c *WorkerConfig) getSettleDelay() time.Duration {
+	t, _ := time.ParseDuration(c.SettleDelay)
+	return t
+}
+
+func (c *WorkerConfig) getInstances() int {
+	if c.Instances > 0 {
+		return c.Instances
+	}
+	return 1
+}
+
+func (c *WorkerConfig) getMaxUnavailable() int {
+	if c.MaxUnavailable < 1 {
+		return 1
+	}
+	return min(c.MaxUnavailable, c.getInstances())
+}
+
+// hashVersion 使用文件的内容计算版本
+func hashVersion(name string) string {
+	f, err := os.Open(name)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	h := md5.New()
+	if _, err = io.Copy(h, f); err != nil {
+		return ""
+	}
+	return name + ":" + hex.EncodeToString(h.Sum(nil))
+}
+
 func statVersion(info os.FileInfo) string {
 	var bf bytes.Buffer
 	bf.WriteString(info.Mode().String())
@@ -258,8 +358,14 @@ func (c *WorkerConfig) version() string {
 	var buf bytes.Buffer
 	for _, fn := range files {
 		info, err := os.Stat(fn)
-		if err == nil {
+		if err != nil {
+			continue
+		}
+		if c.VersionMode != VersionModeHash {
+			// 目录的 mtime 在其下创建、删除、重命名文件时会变化
 			buf.WriteString(statVersion(info))
+		} else if !info.IsDir() {
+			buf.WriteString(hashVersion(fn))
 		}
 	}
 	h := md5.New()
@@ -267,6 +373,49 @@ func (c *WorkerConfig) version() string {
 	return hex.EncodeToString(h.Sum(nil))
 }
 
+// versionPatterns 用于计算版本的文件的路径，可能包含通配符
+func (c *WorkerConfig) versionPatterns() []string {
+	cmd, _ := c.getWorkerCmd()
+	patterns := []string{cmd, c.getFilePath(cmd)}
+	if p := c.getEnvFilePath(); len(p) != 0 {
+		patterns = append(patterns, p)
+	}
+	for _, watchPath := range c.Watches {
+		patterns = append(patterns, c.getFilePath(watchPath))
+	}
+	return patterns
+}
+
+// watchDirs 用于 inotify 监听的目录，版本相关的文件都在这些目录下
+func (c *WorkerConfig) watchDirs() []string {
+	patterns := c.versionPatterns()
+
+	var dirs []string
+	dm := map[string]int8{}
+	for _, pattern := range patterns {
+		ms, _ := filepath.Glob(filepath.Dir(pattern))
+		for _, dir := range ms {
+			if _, has := dm[dir]; !has {
+				dm[dir] = 1
+				dirs = append(dirs, dir)
+			}
+		}
+	}
+	return dirs
+}
+
+// isVersionFile 判断文件是否是用于计算版本的文件
+func (c *WorkerConfig) isVersionFile(name string) bool {
+	patterns := c.versionPatterns()
+	name = filepath.Clean(name)
+	for _, pattern := range patterns {
+		if ok, _ := filepath.Match(filepath.Clean(pattern), name); ok {
+			return true
+		}
+	}
+	return false
+}
+
 func (c *WorkerConfig) getWorkerCmd() (string, []string) {
 	if len(c.Cmd) > 0 {
 		return c.Cmd, c.CmdArgs
@@ -0,0 +1,63 @@
+// Copyright(C) 2026 github.com/fsgo  All Rights Reserved.
+// Author: hidu <duv123@gmail.com>
+// Date: 2026/10/19
+
+package grace
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/fsgo/fst"
+)
+
+func TestWorkerConfig_version(t *testing.T) {
+	dir := t.TempDir()
+	cmd := filepath.Join(dir, "app")
+	mtime := time.Now().Add(-time.Hour)
+	writeFile := func(content string) {
+		fst.NoError(t, os.WriteFile(cmd, []byte(content), 0755))
+		fst.NoError(t, os.Chtimes(cmd, mtime, mtime))
+	}
+	writeFile("v1")
+
+	statCfg := &WorkerConfig{Cmd: cmd}
+	hashCfg := &WorkerConfig{Cmd: cmd, VersionMode: VersionModeHash}
+	fst.NoError(t, hashCfg.Parser())
+
+	v1 := statCfg.version()
+	h1 := hashCfg.version()
+
+	// 内容变化，但是 size 和 mtime 都没有变化
+	writeFile("v2")
+	fst.Equal(t, v1, statCfg.version())
+	fst.NotEqual(t, h1, hashCfg.version())
+
+	fst.True(t, hashCfg.isVersionFile(cmd))
+	fst.False(t, hashCfg.isVersionFile(filepath.Join(dir, "app.log")))
+	fst.Equal(t, []string{dir}, hashCfg.watchDirs())
+
+	fst.Error(t, (&WorkerConfig{VersionMode: "abc"}).Parser())
+}
+
+func TestWorkerConfig_versionDir(t *testing.T) {
+	dir := t.TempDir()
+	confDir := filepath.Join(dir, "conf")
+	fst.NoError(t, os.Mkdir(confDir, 0755))
+	old := time.Now().Add(-time.Hour)
+	fst.NoError(t, os.Chtimes(confDir, old, old))
+
+	statCfg := &WorkerConfig{Cmd: "sh", HomeDir: dir, Watches: []string{"conf"}}
+	hashCfg := &WorkerConfig{Cmd: "sh", HomeDir: dir, Watches: []string{"conf"}, VersionMode: VersionModeHash}
+	v1 := statCfg.version()
+	h1 := hashCfg.version()
+
+	// 在监听的目录下创建文件，目录的 mtime 变化
+	fst.NoError(t, os.WriteFile(filepath.Join(confDir, "app.toml"), []byte("a=1"), 0644))
+	fst.NotEqual(t, v1, statCfg.version())
+
+	// hash 模式不计算目录
+	fst.Equal(t, h1, hashCfg.version())
+}
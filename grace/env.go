@@ -19,11 +19,16 @@ const envMasterPidKey = "FsgoGraceMasterPID" // master 将自己的 pid 传给
 
 var envMasterPPIDValue = os.Getenv(envMasterPidKey)
 
+const envInstanceKey = "FsgoGraceInstance" // master 将子进程的实例序号传给子进程
+
+var envInstanceValue = os.Getenv(envInstanceKey)
+
 // 创建子进程时，需要额外携带的环境变量
-func envsForSubProcess() []string {
+func envsForSubProcess(index int) []string {
 	return []string{
 		envActionKey + "=" + actionSubStart,
 		envMasterPidKey + "=" + pidStr,
+		envInstanceKey + "=" + strconv.Itoa(index),
 	}
 }
 
@@ -37,6 +42,7 @@ func init() {
 	if envMasterPPIDValue != "" {
 		_ = os.Unsetenv(envActionKey)
 		_ = os.Unsetenv(envMasterPidKey)
+		_ = os.Unsetenv(envInstanceKey)
 	}
 }
 
@@ -45,3 +51,17 @@ func IsSubProcess() bool {
 	// 通过检查 ppid，来判断当前进程是否直接由子进程派生出来的
 	return envMasterPPIDValue == ppidStr
 }
+
+// InstanceIndex 当前子进程的实例序号，从 0 开始，见 WorkerConfig.Instances
+//
+// 非子进程时返回 -1
+func InstanceIndex() int {
+	if !IsSubProcess() {
+		return -1
+	}
+	index, err := strconv.Atoi(envInstanceValue)
+	if err != nil {
+		return -1
+	}
+	return index
+}
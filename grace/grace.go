@@ -16,6 +16,8 @@ import (
 	"time"
 
 	"golang.org/x/sync/errgroup"
+
+	"github.com/fsgo/fsgo/fsfs"
 )
 
 const (
@@ -79,6 +81,11 @@ func (c *Option) GetMainPIDPath() string {
 	return filepath.Join(c.StatusDir, "main.pid")
 }
 
+// GetMainLockPath 获取主程序的锁文件路径，用于保证同时只有一个主进程
+func (c *Option) GetMainLockPath() string {
+	return filepath.Join(c.StatusDir, "main.lock")
+}
+
 // GetCheckInterval 获取检查的时间间隔
 func (c *Option) GetCheckInterval() time.Duration {
 	if c.CheckInterval > 0 {
@@ -106,6 +113,7 @@ func (g *Grace) Register(name string, gg *Worker) error {
 		return fmt.Errorf("worker=%q already exists", name)
 	}
 	gg.main = g
+	gg.name = name
 	g.workers[name] = gg
 	return nil
 }
@@ -239,21 +247,30 @@ func (g *Grace) writePIDFile() error {
 	if err := keepDir(filepath.Dir(pidPath)); err != nil {
 		return err
 	}
-	err := os.WriteFile(pidPath, []byte(pidStr), 0644)
-	return err
+	return fsfs.AtomicWriteFile(pidPath, []byte(pidStr), 0644)
 }
 
 func (g *Grace) actionMainStart(ctx context.Context) error {
-	defer func() {
-		_ = os.Remove(g.Option.GetMainPIDPath())
-	}()
-
 	wd, err := os.Getwd()
 	g.logit("[grace][master] working dir=", wd, err)
 	if err != nil {
 		return err
 	}
 
+	// 避免启动第二个主进程时，覆盖 main.pid 文件
+	lock, err := fsfs.SingleInstance(g.Option.GetMainLockPath())
+	if err != nil {
+		return fmt.Errorf("another master is running: %w", err)
+	}
+	defer lock.Release()
+	if lock.Stale {
+		g.logit("last master pid=", lock.PrevPID, " exited unexpectedly")
+	}
+
+	defer func() {
+		_ = os.Remove(g.Option.GetMainPIDPath())
+	}()
+
 	if e := g.writePIDFile(); e != nil {
 		return e
 	}
@@ -0,0 +1,177 @@
+// Copyright(C) 2026 github.com/fsgo  All Rights Reserved.
+// Author: hidu <duv123@gmail.com>
+// Date: 2026/10/19
+
+package grace
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"time"
+)
+
+// HookStage 子进程生命周期的阶段
+type HookStage string
+
+const (
+	// HookPreStart 首次启动和 reload 时，在 fork 所有实例之前执行一次，若执行失败，则不会启动子进程（reload 也会中止）
+	// 子进程异常退出后的重启不会执行
+	HookPreStart HookStage = "PreStart"
+
+	// HookPostStart 新子进程启动，并且等待 StartWait 后依然存活
+	HookPostStart HookStage = "PostStart"
+
+	// HookPreStop 停止子进程前，如用于从服务发现中摘除
+	HookPreStop HookStage = "PreStop"
+
+	// HookPostStop 子进程已停止
+	HookPostStop HookStage = "PostStop"
+)
+
+// HookEvent 传递给 hook 的信息
+type HookEvent struct {
+	Stage HookStage
+
+	// Worker worker 注册的名称
+	Worker string
+
+	// Instance 子进程的实例序号，对于 HookPreStart 为 -1
+	Instance int
+
+	// PID 子进程的 pid，对于 HookPreStart 为 0
+	PID int
+}
+
+// HookFunc 生命周期的回调函数
+type HookFunc func(ctx context.Context, e HookEvent) error
+
+// HookCmd 在生命周期的各个阶段执行的命令
+//
+// 命令的工作目录为 WorkerConfig.HomeDir，除继承 master 的环境变量外，还有：
+// FsgoGraceHookStage、FsgoGraceHookWorker、FsgoGraceHookInstance、FsgoGraceHookPID
+type HookCmd struct {
+	// Cmd 必填，命令
+	Cmd string `validate:"required"`
+
+	// Args 可选，命令的参数
+	Args []string
+
+	// Timeout 可选，执行的超时时间，默认为 "10s"
+	Timeout string
+}
+
+func (hc *HookCmd) getTimeout() time.Duration {
+	if t, _ := time.ParseDuration(hc.Timeout); t > 0 {
+		return t
+	}
+	return 10 * time.Second
+}
+
+// WorkerHooks 在子进程生命周期的各个阶段执行的命令，均为可选
+type WorkerHooks struct {
+	PreStart  *HookCmd
+	PostStart *HookCmd
+	PreStop   *HookCmd
+	PostStop  *HookCmd
+}
+
+func (wh *WorkerHooks) get(stage HookStage) *HookCmd {
+	if wh == nil {
+		return nil
+	}
+	switch stage {
+	case HookPreStart:
+		return wh.PreStart
+	case HookPostStart:
+		return wh.PostStart
+	case HookPreStop:
+		return wh.PreStop
+	case HookPostStop:
+		return wh.PostStop
+	default:
+		return nil
+	}
+}
+
+// OnHook 注册生命周期的回调函数，在 WorkerConfig.Hooks 配置的命令之后执行
+//
+// 回调是在 master 进程中执行的，需要在 Grace.Start 之前注册
+func (w *Worker) OnHook(stage HookStage, fn HookFunc) {
+	if w.hooks == nil {
+		w.hooks = make(map[HookStage][]HookFunc)
+	}
+	w.hooks[stage] = append(w.hooks[stage], fn)
+}
+
+// runHooks 执行指定阶段的命令和回调函数
+func (w *Worker) runHooks(ctx context.Context, stage HookStage, ins *instance, pid int) error {
+	hc := w.option.Hooks.get(stage)
+	fns := w.hooks[stage]
+	if hc == nil && len(fns) == 0 {
+		return nil
+	}
+	e := HookEvent{
+		Stage:    stage,
+		Worker:   w.name,
+		Instance: -1,
+		PID:      pid,
+	}
+	if ins != nil {
+		e.Instance = ins.index
+	}
+	var errs []error
+	if hc != nil {
+		if err := w.runHookCmd(ctx, hc, e); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for _, fn := range fns {
+		if err := w.runHookFunc(ctx, fn, e); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (w *Worker) runHookCmd(ctx context.Context, hc *HookCmd, e HookEvent) error {
+	start := time.Now()
+	ctx, cancel := context.WithTimeout(ctx, hc.getTimeout())
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, hc.Cmd, hc.Args...)
+	cmd.Dir = w.option.HomeDir
+	cmd.Env = append(os.Environ(),
+		"FsgoGraceHookStage="+string(e.Stage),
+		"FsgoGraceHookWorker="+e.Worker,
+		"FsgoGraceHookInstance="+strconv.Itoa(e.Instance),
+		"FsgoGraceHookPID="+strconv.Itoa(e.PID),
+	)
+	out, err := cmd.CombinedOutput()
+	w.logit("[hook][", e.Stage, "] instance=", e.Instance, ", cmd=", cmd.String(),
+		", duration=", time.Since(start), ", err=", err, ", output=", string(out))
+	if err != nil {
+		return fmt.Errorf("hook %s cmd %q failed: %w, output=%q", e.Stage, cmd.String(), err, lastBytes(out, 512))
+	}
+	return nil
+}
+
+func (w *Worker) runHookFunc(ctx context.Context, fn HookFunc, e HookEvent) (err error) {
+	defer func() {
+		if re := recover(); re != nil {
+			err = fmt.Errorf("hook %s func panic: %v", e.Stage, re)
+		}
+		w.logit("[hook][", e.Stage, "] instance=", e.Instance, ", func, err=", err)
+	}()
+	return fn(ctx, e)
+}
+
+func lastBytes(bf []byte, n int) []byte {
+	if len(bf) <= n {
+		return bf
+	}
+	return bf[len(bf)-n:]
+}
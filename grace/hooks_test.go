@@ -0,0 +1,49 @@
+// Copyright(C) 2026 github.com/fsgo  All Rights Reserved.
+// Author: hidu <duv123@gmail.com>
+// Date: 2026/10/19
+
+package grace
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"testing"
+
+	"github.com/fsgo/fst"
+)
+
+func TestWorker_runHooks(t *testing.T) {
+	w := &Worker{
+		name: "demo",
+		main: &Grace{Logger: log.Default()},
+		option: &WorkerConfig{
+			Hooks: &WorkerHooks{
+				PreStart: &HookCmd{
+					Cmd:  "sh",
+					Args: []string{"-c", "echo $FsgoGraceHookStage $FsgoGraceHookWorker; exit 1"},
+				},
+			},
+		},
+	}
+	fst.NoError(t, w.option.Parser())
+
+	var got []HookEvent
+	w.OnHook(HookPostStop, func(ctx context.Context, e HookEvent) error {
+		got = append(got, e)
+		return errors.New("post stop failed")
+	})
+
+	ins := &instance{index: 1}
+	err := w.runHooks(context.Background(), HookPreStart, ins, 0)
+	fst.Error(t, err)
+	fst.True(t, strings.Contains(err.Error(), "PreStart demo"))
+
+	fst.NoError(t, w.runHooks(context.Background(), HookPreStop, ins, 100))
+
+	fst.Error(t, w.runHooks(context.Background(), HookPostStop, ins, 100))
+	fst.Equal(t, []HookEvent{{Stage: HookPostStop, Worker: "demo", Instance: 1, PID: 100}}, got)
+
+	fst.Error(t, (&WorkerConfig{Hooks: &WorkerHooks{PreStop: &HookCmd{}}}).Parser())
+}
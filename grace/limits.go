@@ -0,0 +1,275 @@
+// Copyright(C) 2026 github.com/fsgo  All Rights Reserved.
+// Author: hidu <duv123@gmail.com>
+// Date: 2026/10/19
+
+package grace
+
+// 此文件包含子进程资源限制和隔离相关的逻辑
+//
+// 由于 exec.Cmd 不支持给子进程设置 rlimit、umask 等，所以在配置了 WorkerLimits 后，
+// master 会先以当前程序启动一个辅助进程，由辅助进程在完成设置后，再 exec 为真正的 Cmd。
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"os/exec"
+	"os/user"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// WorkerLimits 子进程的资源限制和隔离配置，目前仅支持 linux
+type WorkerLimits struct {
+	// NoFile 可选，最大打开文件数（RLIMIT_NOFILE），如 "65535"、"unlimited"
+	NoFile string
+
+	// Core 可选，core 文件大小上限（RLIMIT_CORE），如 "0"、"1G"、"unlimited"
+	Core string
+
+	// AddressSpace 可选，虚拟内存大小上限（RLIMIT_AS），如 "4G"、"unlimited"
+	AddressSpace string
+
+	// Nice 可选，进程的 nice 值，取值范围 [-20,19]，为 0 时不修改
+	Nice int
+
+	// User 可选，运行子进程的用户，用户名或者 uid
+	User string
+
+	// Group 可选，运行子进程的用户组，组名或者 gid，若不填写且配置了 User，则使用 User 的主组
+	Group string
+
+	// Umask 可选，子进程的 umask，八进制，如 "022"
+	Umask string
+
+	// CgroupParent 可选，cgroup v2 的父目录，如 "/sys/fs/cgroup/grace"
+	// 配置后，会为每个 worker 在此目录下创建一个子 cgroup，worker 的所有实例都在此 cgroup 中
+	// 需要 master 有权限操作此目录，若创建失败，子进程会在没有 cgroup 限制的情况下运行
+	CgroupParent string
+
+	// MemoryMax 可选，cgroup 的内存上限（memory.max），如 "512M"、"max"，需要配置 CgroupParent
+	MemoryMax string
+
+	// CPUs 可选，cgroup 可使用的 CPU 核数（cpu.max），如 1.5，需要配置 CgroupParent
+	CPUs float64
+}
+
+// Parser 检查配置
+func (l *WorkerLimits) Parser() error {
+	for name, val := range map[string]string{"NoFile": l.NoFile, "Core": l.Core, "AddressSpace": l.AddressSpace} {
+		if _, err := parseLimitValue(val); err != nil {
+			return fmt.Errorf("invalid %s: %w", name, err)
+		}
+	}
+	if l.Nice < -20 || l.Nice > 19 {
+		return fmt.Errorf("invalid Nice %d, should in [-20,19]", l.Nice)
+	}
+	if _, err := parseUmask(l.Umask); err != nil {
+		return err
+	}
+	if l.MemoryMax != "" && l.MemoryMax != "max" {
+		if _, err := parseLimitValue(l.MemoryMax); err != nil {
+			return fmt.Errorf("invalid MemoryMax: %w", err)
+		}
+	}
+	if l.CPUs < 0 {
+		return fmt.Errorf("invalid CPUs %v", l.CPUs)
+	}
+	if (l.MemoryMax != "" || l.CPUs > 0) && l.CgroupParent == "" {
+		return fmt.Errorf("MemoryMax and CPUs require CgroupParent")
+	}
+	return nil
+}
+
+// parseLimitValue 解析资源限制的值，支持 K、M、G、T 的单位后缀
+//
+// 返回 -1 表示不修改，math.MaxInt64 表示不限制
+func parseLimitValue(val string) (int64, error) {
+	switch val {
+	case "":
+		return -1, nil
+	case "unlimited", "infinity":
+		return math.MaxInt64, nil
+	}
+	str := strings.ToUpper(val)
+	unit := int64(1)
+	for i, u := range []string{"K", "M", "G", "T"} {
+		if strings.HasSuffix(str, u) {
+			unit = 1 << (10 * (i + 1))
+			str = strings.TrimSuffix(str, u)
+			break
+		}
+	}
+	num, err := strconv.ParseInt(str, 10, 64)
+	if err != nil || num < 0 {
+		return 0, fmt.Errorf("wrong limit value %q", val)
+	}
+	if num > math.MaxInt64/unit {
+		return math.MaxInt64, nil
+	}
+	return num * unit, nil
+}
+
+func parseUmask(val string) (int, error) {
+	if len(val) == 0 {
+		return -1, nil
+	}
+	num, err := strconv.ParseUint(val, 8, 32)
+	if err != nil || num > 0777 {
+		return 0, fmt.Errorf("wrong Umask %q", val)
+	}
+	return int(num), nil
+}
+
+const envLimitsKey = "FsgoGraceLimits" // master 将资源限制配置传给辅助进程
+
+var errLimitsNotSupported = errors.New("WorkerLimits only supported on linux")
+
+// 支持的 rlimit 类型，在辅助进程中转换为对应系统的 resource
+const (
+	rlimitNoFile = iota + 1
+	rlimitCore
+	rlimitAS
+)
+
+// limitsSpec 传递给辅助进程的配置，在辅助进程中设置完成后，exec 为 Path
+type limitsSpec struct {
+	Path string
+
+	// Rlimits key 为 rlimitNoFile 等
+	Rlimits map[int]int64 `json:",omitempty"`
+
+	// CgroupProcs 辅助进程需要将自己加入此 cgroup.procs 文件
+	CgroupProcs string `json:",omitempty"`
+
+	Groups []int `json:",omitempty"`
+
+	Uid int
+	Gid int
+
+	Nice  int
+	Umask int
+}
+
+// buildLimitsSpec 将配置转换为辅助进程可直接使用的参数，用户名等在 master 中解析，以便尽早发现错误
+func (l *WorkerLimits) buildLimitsSpec() (*limitsSpec, error) {
+	spec := &limitsSpec{
+		Uid:     -1,
+		Gid:     -1,
+		Nice:    l.Nice,
+		Rlimits: map[int]int64{},
+	}
+	var err error
+	if spec.Umask, err = parseUmask(l.Umask); err != nil {
+		return nil, err
+	}
+	for res, val := range map[int]string{rlimitNoFile: l.NoFile, rlimitCore: l.Core, rlimitAS: l.AddressSpace} {
+		num, err := parseLimitValue(val)
+		if err != nil {
+			return nil, err
+		}
+		if num >= 0 {
+			spec.Rlimits[res] = num
+		}
+	}
+
+	if len(l.User) > 0 {
+		u, err := lookupUser(l.User)
+		if err != nil {
+			return nil, err
+		}
+		spec.Uid, _ = strconv.Atoi(u.Uid)
+		spec.Gid, _ = strconv.Atoi(u.Gid)
+		gids, _ := u.GroupIds()
+		for _, id := range gids {
+			if gid, err := strconv.Atoi(id); err == nil {
+				spec.Groups = append(spec.Groups, gid)
+			}
+		}
+	}
+	if len(l.Group) > 0 {
+		g, err := lookupGroup(l.Group)
+		if err != nil {
+			return nil, err
+		}
+		spec.Gid, _ = strconv.Atoi(g.Gid)
+	}
+	return spec, nil
+}
+
+func lookupUser(name string) (*user.User, error) {
+	if _, err := strconv.Atoi(name); err == nil {
+		return user.LookupId(name)
+	}
+	return user.Lookup(name)
+}
+
+func lookupGroup(name string) (*user.Group, error) {
+	if _, err := strconv.Atoi(name); err == nil {
+		return user.LookupGroupId(name)
+	}
+	return user.LookupGroup(name)
+}
+
+// applyLimits 若有配置资源限制，将 cmd 修改为先启动辅助进程
+func (w *Worker) applyLimits(cmd *exec.Cmd) error {
+	limits := w.option.Limits
+	if limits == nil {
+		return nil
+	}
+	if runtime.GOOS != "linux" {
+		return errLimitsNotSupported
+	}
+	if cmd.Err != nil {
+		return cmd.Err
+	}
+	spec, err := limits.buildLimitsSpec()
+	if err != nil {
+		return err
+	}
+	if len(limits.CgroupParent) > 0 {
+		procs, err := setupCgroup(limits, w.name)
+		w.logit("setup cgroup, cgroup.procs=", procs, ", err=", err)
+		if err == nil {
+			spec.CgroupProcs = procs
+		}
+	}
+	self, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	spec.Path = cmd.Path
+	bf, err := json.Marshal(spec)
+	if err != nil {
+		return err
+	}
+
+	// cmd.Args 保持不变，在辅助进程中，os.Args 即为真正 Cmd 的参数
+	cmd.Path = self
+	cmd.Env = append(cmd.Env, envLimitsKey+"="+string(bf))
+	return nil
+}
+
+// 辅助进程：在所有 init 之前完成设置并 exec 为真正的 Cmd，
+// 以避免 env.go 里的 init 将 master 传递给子进程的环境变量删除
+var _ = execWithLimits()
+
+func execWithLimits() bool {
+	val := os.Getenv(envLimitsKey)
+	if len(val) == 0 {
+		return false
+	}
+	_ = os.Unsetenv(envLimitsKey)
+	var spec *limitsSpec
+	err := json.Unmarshal([]byte(val), &spec)
+	if err == nil {
+		err = spec.exec()
+	}
+	// exec 成功时不会返回
+	fmt.Fprintf(os.Stderr, "[grace][limits] exec %q failed: %v\n", os.Args[0], err)
+	os.Exit(1)
+	return true
+}
@@ -0,0 +1,104 @@
+// Copyright(C) 2026 github.com/fsgo  All Rights Reserved.
+// Author: hidu <duv123@gmail.com>
+// Date: 2026/10/19
+
+//go:build linux
+
+package grace
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"syscall"
+)
+
+var rlimitResources = map[int]int{
+	rlimitNoFile: syscall.RLIMIT_NOFILE,
+	rlimitCore:   syscall.RLIMIT_CORE,
+	rlimitAS:     syscall.RLIMIT_AS,
+}
+
+// exec 在辅助进程中执行，设置完成后 exec 为真正的 Cmd
+func (s *limitsSpec) exec() error {
+	// 需要在切换用户之前完成，切换用户后可能就没有权限了
+	if len(s.CgroupProcs) > 0 {
+		if err := os.WriteFile(s.CgroupProcs, []byte(strconv.Itoa(os.Getpid())), 0); err != nil {
+			return err
+		}
+	}
+	for res, num := range s.Rlimits {
+		lim := &syscall.Rlimit{Cur: uint64(num), Max: uint64(num)}
+		if num == math.MaxInt64 {
+			lim.Cur = math.MaxUint64 // RLIM_INFINITY
+			lim.Max = math.MaxUint64
+		}
+		if err := syscall.Setrlimit(rlimitResources[res], lim); err != nil {
+			return fmt.Errorf("setrlimit(%d,%d): %w", res, num, err)
+		}
+	}
+	if s.Nice != 0 {
+		if err := syscall.Setpriority(syscall.PRIO_PROCESS, 0, s.Nice); err != nil {
+			return fmt.Errorf("setpriority(%d): %w", s.Nice, err)
+		}
+	}
+	if s.Umask >= 0 {
+		syscall.Umask(s.Umask)
+	}
+	if s.Gid >= 0 {
+		if s.Uid >= 0 {
+			if err := syscall.Setgroups(s.Groups); err != nil {
+				return fmt.Errorf("setgroups(%v): %w", s.Groups, err)
+			}
+		}
+		if err := syscall.Setgid(s.Gid); err != nil {
+			return fmt.Errorf("setgid(%d): %w", s.Gid, err)
+		}
+	}
+	if s.Uid >= 0 {
+		if err := syscall.Setuid(s.Uid); err != nil {
+			return fmt.Errorf("setuid(%d): %w", s.Uid, err)
+		}
+	}
+	return syscall.Exec(s.Path, os.Args, os.Environ())
+}
+
+// setupCgroup 创建 worker 的 cgroup 并设置 memory.max、cpu.max，返回 cgroup.procs 文件的路径
+func setupCgroup(l *WorkerLimits, name string) (string, error) {
+	if len(name) == 0 {
+		return "", fmt.Errorf("worker not registered, cannot create cgroup")
+	}
+	if err := os.MkdirAll(l.CgroupParent, 0755); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(filepath.Join(l.CgroupParent, "cgroup.subtree_control"), []byte("+memory +cpu"), 0); err != nil {
+		return "", err
+	}
+	dir := filepath.Join(l.CgroupParent, name)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	if len(l.MemoryMax) > 0 {
+		val := l.MemoryMax
+		if val != "max" {
+			num, err := parseLimitValue(val)
+			if err != nil {
+				return "", err
+			}
+			val = strconv.FormatInt(num, 10)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "memory.max"), []byte(val), 0); err != nil {
+			return "", err
+		}
+	}
+	if l.CPUs > 0 {
+		const period = 100000
+		val := fmt.Sprintf("%d %d", int64(l.CPUs*period), period)
+		if err := os.WriteFile(filepath.Join(dir, "cpu.max"), []byte(val), 0); err != nil {
+			return "", err
+		}
+	}
+	return filepath.Join(dir, "cgroup.procs"), nil
+}
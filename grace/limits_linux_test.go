@@ -0,0 +1,47 @@
+// Copyright(C) 2026 github.com/fsgo  All Rights Reserved.
+// Author: hidu <duv123@gmail.com>
+// Date: 2026/10/19
+
+//go:build linux
+
+package grace
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/fsgo/fst"
+)
+
+func TestWorker_applyLimits(t *testing.T) {
+	w := &Worker{
+		option: &WorkerConfig{
+			Limits: &WorkerLimits{
+				NoFile: "100",
+				Umask:  "077",
+			},
+		},
+	}
+	fst.NoError(t, w.option.Parser())
+
+	cmd := exec.Command("sh", "-c", "ulimit -n; umask")
+	cmd.Env = os.Environ()
+	fst.NoError(t, w.applyLimits(cmd))
+	out, err := cmd.CombinedOutput()
+	fst.NoError(t, err)
+	fst.Equal(t, []string{"100", "0077"}, strings.Fields(string(out)))
+}
+
+func TestWorkerLimits_Parser(t *testing.T) {
+	fst.NoError(t, (&WorkerLimits{Core: "1G", AddressSpace: "unlimited"}).Parser())
+	fst.Error(t, (&WorkerLimits{NoFile: "abc"}).Parser())
+	fst.Error(t, (&WorkerLimits{Nice: 30}).Parser())
+	fst.Error(t, (&WorkerLimits{Umask: "999"}).Parser())
+	fst.Error(t, (&WorkerLimits{MemoryMax: "1G"}).Parser())
+
+	num, err := parseLimitValue("2K")
+	fst.NoError(t, err)
+	fst.Equal(t, int64(2048), num)
+}
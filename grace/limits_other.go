@@ -0,0 +1,15 @@
+// Copyright(C) 2026 github.com/fsgo  All Rights Reserved.
+// Author: hidu <duv123@gmail.com>
+// Date: 2026/10/19
+
+//go:build !linux
+
+package grace
+
+func (s *limitsSpec) exec() error {
+	return errLimitsNotSupported
+}
+
+func setupCgroup(l *WorkerLimits, name string) (string, error) {
+	return "", errLimitsNotSupported
+}
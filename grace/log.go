@@ -0,0 +1,176 @@
+// Copyright(C) 2026 github.com/fsgo  All Rights Reserved.
+// Author: hidu <duv123@gmail.com>
+// Date: 2026/10/19
+
+package grace
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/fsgo/fsgo/fsfs"
+	"github.com/fsgo/fsgo/fsio"
+)
+
+// WorkerLog 子进程 stdout、stderr 日志的配置
+type WorkerLog struct {
+	// Rule 可选，日志文件的切割规则，默认为 "1hour"，支持的规则见 fsfs.RotateRuleNames
+	Rule string
+
+	// MaxFiles 可选，每种日志最多保留的文件数，默认为 24，若为 -1 则保留所有
+	MaxFiles int
+
+	// Merge 可选，是否将 stdout 和 stderr 合并写入 output.log
+	// 默认分别写入 stdout.log 和 stderr.log
+	Merge bool
+
+	// Timestamp 可选，是否在每行前添加时间，如 "2006-01-02 15:04:05.000 "
+	Timestamp bool
+
+	// WorkerName 可选，是否在每行前添加 worker 名称和实例序号，如 "[default#0] "
+	WorkerName bool
+
+	// NoTee 可选，是否不再同时输出到 master 的 stdout、stderr
+	NoTee bool
+}
+
+// Parser 检查配置
+func (wl *WorkerLog) Parser() error {
+	if len(wl.Rule) > 0 && !slices.Contains(fsfs.RotateRuleNames(), wl.Rule) {
+		return fmt.Errorf("not support Rule %q", wl.Rule)
+	}
+	return nil
+}
+
+func (wl *WorkerLog) getRule() string {
+	if wl == nil || len(wl.Rule) == 0 {
+		return "1hour"
+	}
+	return wl.Rule
+}
+
+func (wl *WorkerLog) hasPrefix() bool {
+	return wl != nil && (wl.Timestamp || wl.WorkerName)
+}
+
+// initLogWriters 初始化子进程 stdout、stderr 的 writer
+func (w *Worker) initLogWriters() {
+	cfg := w.option.Log
+	newRotator := func(name string) io.Writer {
+		r := &fsfs.Rotator{
+			Path:    filepath.Join(w.option.LogDir, name),
+			ExtRule: cfg.getRule(),
+		}
+		if cfg != nil {
+			r.MaxFiles = cfg.MaxFiles
+		}
+		_ = r.Init()
+		return r
+	}
+	tee := func(std io.Writer, file io.Writer) io.Writer {
+		if cfg != nil && cfg.NoTee {
+			return file
+		}
+		return io.MultiWriter(std, file)
+	}
+
+	if cfg != nil && cfg.Merge {
+		// stdout 和 stderr 会被并发写入
+		output := fsio.NewMutexWriter(newRotator("output.log"))
+		w.stdout = tee(os.Stdout, output)
+		w.stderr = tee(os.Stderr, output)
+		return
+	}
+	w.stdout = tee(os.Stdout, newRotator("stdout.log"))
+	w.stderr = tee(os.Stderr, newRotator("stderr.log"))
+	if cfg.hasPrefix() {
+		// 有前缀时，多个实例的输出是按行写入的，需要保证一行完整写入
+		w.stdout = fsio.NewMutexWriter(w.stdout)
+		w.stderr = fsio.NewMutexWriter(w.stderr)
+	}
+}
+
+// cmdLogWriter 给一个子进程使用的 writer，若需要添加前缀，返回的 writer 需要在子进程退出后 Close
+func (w *Worker) cmdLogWriter(out io.Writer, ins *instance) io.WriteCloser {
+	cfg := w.option.Log
+	if !cfg.hasPrefix() {
+		return fsio.NopWriteCloser(out)
+	}
+	return &linePrefixWriter{
+		out: out,
+		prefix: func(bf *bytes.Buffer) {
+			if cfg.Timestamp {
+				bf.WriteString(time.Now().Format("2006-01-02 15:04:05.000 "))
+			}
+			if cfg.WorkerName {
+				bf.WriteString("[" + w.name + "#" + strconv.Itoa(ins.index) + "] ")
+			}
+		},
+	}
+}
+
+// linePrefixWriter 给每一行添加前缀，并按照完整的行写入 out
+type linePrefixWriter struct {
+	out    io.Writer
+	prefix func(bf *bytes.Buffer)
+	buf    []byte
+	mux    sync.Mutex
+}
+
+// 未换行内容的最大长度，超过后会直接写入
+const linePrefixMaxBuf = 64 * 1024
+
+func (lw *linePrefixWriter) Write(p []byte) (int, error) {
+	lw.mux.Lock()
+	defer lw.mux.Unlock()
+	lw.buf = append(lw.buf, p...)
+	idx := bytes.LastIndexByte(lw.buf, '\n')
+	if idx < 0 && len(lw.buf) < linePrefixMaxBuf {
+		return len(p), nil
+	}
+	end := idx + 1
+	if idx < 0 {
+		end = len(lw.buf)
+	}
+	if err := lw.writeLines(lw.buf[:end]); err != nil {
+		return 0, err
+	}
+	lw.buf = append(lw.buf[:0], lw.buf[end:]...)
+	return len(p), nil
+}
+
+func (lw *linePrefixWriter) writeLines(lines []byte) error {
+	var bf bytes.Buffer
+	for len(lines) > 0 {
+		lw.prefix(&bf)
+		idx := bytes.IndexByte(lines, '\n')
+		if idx < 0 {
+			bf.Write(lines)
+			bf.WriteByte('\n')
+			break
+		}
+		bf.Write(lines[:idx+1])
+		lines = lines[idx+1:]
+	}
+	_, err := lw.out.Write(bf.Bytes())
+	return err
+}
+
+// Close 将剩余未换行的内容写入
+func (lw *linePrefixWriter) Close() error {
+	lw.mux.Lock()
+	defer lw.mux.Unlock()
+	if len(lw.buf) == 0 {
+		return nil
+	}
+	err := lw.writeLines(lw.buf)
+	lw.buf = nil
+	return err
+}
@@ -0,0 +1,33 @@
+// Copyright(C) 2026 github.com/fsgo  All Rights Reserved.
+// Author: hidu <duv123@gmail.com>
+// Date: 2026/10/19
+
+package grace
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/fsgo/fst"
+)
+
+func TestWorker_cmdLogWriter(t *testing.T) {
+	w := &Worker{
+		name: "demo",
+		option: &WorkerConfig{
+			Log: &WorkerLog{WorkerName: true},
+		},
+	}
+	fst.NoError(t, w.option.Parser())
+
+	var out bytes.Buffer
+	lw := w.cmdLogWriter(&out, &instance{index: 2})
+	_, _ = lw.Write([]byte("hello"))
+	fst.Equal(t, "", out.String())
+	_, _ = lw.Write([]byte(" world\nline2\nline3"))
+	fst.Equal(t, "[demo#2] hello world\n[demo#2] line2\n", out.String())
+	fst.NoError(t, lw.Close())
+	fst.Equal(t, "[demo#2] hello world\n[demo#2] line2\n[demo#2] line3\n", out.String())
+
+	fst.Error(t, (&WorkerConfig{Log: &WorkerLog{Rule: "2day"}}).Parser())
+}
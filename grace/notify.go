@@ -0,0 +1,61 @@
+// Copyright(C) 2026 github.com/fsgo  All Rights Reserved.
+// Author: hidu <duv123@gmail.com>
+// Date: 2026/10/19
+
+package grace
+
+import (
+	"context"
+	"time"
+
+	"github.com/fsgo/fsgo/internal/xinotify"
+)
+
+// notifyDelay 收到文件变化的通知后，到检查版本的等待时间
+const notifyDelay = 200 * time.Millisecond
+
+// notify 使用 inotify 监听版本相关文件所在的目录，当这些文件有变化时，返回的 chan 会收到通知
+//
+// 若未开启 WorkerConfig.Notify 或者 inotify 不可用，返回 nil
+func (w *Worker) notify(ctx context.Context) <-chan struct{} {
+	if !w.option.Notify {
+		return nil
+	}
+	nw, err := xinotify.New()
+	if err != nil {
+		w.logit("[notify] inotify not available, fallback to polling: ", err)
+		return nil
+	}
+	const ops = xinotify.OpCreate | xinotify.OpCloseWrite | xinotify.OpRemove |
+		xinotify.OpMoveFrom | xinotify.OpMoveTo | xinotify.OpChmod
+	for _, dir := range w.option.watchDirs() {
+		_, err = nw.Add(dir, ops)
+		w.logit("[notify] watch dir ", dir, ", err=", err)
+	}
+
+	ch := make(chan struct{}, 1)
+	go func() {
+		defer nw.Close()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case err := <-nw.Errors():
+				w.logit("[notify] read events failed, fallback to polling: ", err)
+				return
+			case event, ok := <-nw.Events():
+				if !ok {
+					return
+				}
+				if !event.Op.Has(xinotify.OpOverflow) && !w.option.isVersionFile(event.Path) {
+					continue
+				}
+				select {
+				case ch <- struct{}{}:
+				default:
+				}
+			}
+		}
+	}()
+	return ch
+}
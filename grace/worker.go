@@ -13,12 +13,12 @@ import (
 	"os"
 	"os/exec"
 	"os/signal"
-	"path/filepath"
 	"sync"
 	"syscall"
 	"time"
 
-	"github.com/fsgo/fsgo/fsfs"
+	"golang.org/x/sync/errgroup"
+
 	"github.com/fsgo/fsgo/grace/internal/envfile"
 )
 
@@ -32,23 +32,17 @@ func NewWorker(cfg *WorkerConfig) *Worker {
 		event:  make(chan string, 1),
 	}
 
-	w.sub = &subProcess{
-		worker: w,
+	num := cfg.getInstances()
+	w.instances = make([]*instance, num)
+	for i := 0; i < num; i++ {
+		w.instances[i] = &instance{index: i}
 	}
 
-	stderr := &fsfs.Rotator{
-		Path:    filepath.Join(cfg.LogDir, "stderr.log"),
-		ExtRule: "1hour",
+	w.sub = &subProcess{
+		worker: w,
 	}
-	_ = stderr.Init()
-	w.stderr = io.MultiWriter(os.Stderr, stderr)
 
-	stdout := &fsfs.Rotator{
-		Path:    filepath.Join(cfg.LogDir, "stdout.log"),
-		ExtRule: "1hour",
-	}
-	_ = stdout.Init()
-	w.stdout = io.MultiWriter(os.Stdout, stdout)
+	w.initLogWriters()
 
 	return w
 }
@@ -58,6 +52,16 @@ type resourceAndConsumer struct {
 	Consumer Consumer
 }
 
+// instance 一个子进程实例，由 WorkerConfig.Instances 决定 worker 有几个实例
+type instance struct {
+	// 创建当前 cmd 是对应的 cancel
+	cmdClose context.CancelFunc
+
+	index int
+
+	pid int // cmd 对应的 pid
+}
+
 // Worker 工作进程的逻辑
 type Worker struct {
 	// 子进程上次退出时间
@@ -70,9 +74,6 @@ type Worker struct {
 
 	stderr io.Writer
 
-	// 创建当前 cmd 是对应的 cancel
-	cmdClose context.CancelFunc
-
 	sub *subProcess
 
 	event chan string
@@ -80,9 +81,16 @@ type Worker struct {
 	main   *Grace
 	option *WorkerConfig
 
+	// name 注册到 Grace 时的名称
+	name string
+
+	// hooks 通过 OnHook 注册的生命周期回调
+	hooks map[HookStage][]HookFunc
+
 	resources []*resourceAndConsumer
 
-	pid int // cmd 对应的 pid
+	// 所有的子进程实例，其字段由 mux 保护
+	instances []*instance
 
 	nextListenDSNIndex int
 
@@ -90,6 +98,9 @@ type Worker struct {
 
 	// 是否正在加载进程
 	isReloading bool
+
+	// HookPreStart 是否已经执行成功过，由 mux 保护
+	preStarted bool
 }
 
 // Register 注册新的消费者
@@ -144,9 +155,10 @@ func (w *Worker) start(ctx context.Context) error {
 	defer watchCancel()
 	go w.watch(ctxWatch)
 
-	// 启动一个子进程，用于处理请求
-	err := w.forkAndStart(w.cmdCtx)
+	// 启动所有子进程实例，用于处理请求
+	err := w.forkAll(w.cmdCtx)
 	w.logit("first forkAndStart sub process: ", err)
+	go w.afterFirstStart(w.cmdCtx)
 	if err != nil {
 		if IsSubProcess() {
 			w.logit("start sub process failed")
@@ -222,6 +234,39 @@ func (w *Worker) watch(ctx context.Context) {
 	defer tk.Stop()
 	st := &watchStats{}
 
+	// 版本变化后，需要保持 settle 时长不变才能 reload
+	settle := w.option.getSettleDelay()
+	var pendingVersion string
+	var pendingSince time.Time
+
+	// 检查版本是否已变化并且已稳定
+	checkChange := func(newVersion string) bool {
+		if oldVersion == newVersion {
+			pendingVersion = ""
+			return false
+		}
+		if settle <= 0 {
+			return true
+		}
+		if pendingVersion != newVersion {
+			pendingVersion = newVersion
+			pendingSince = time.Now()
+		}
+		if cost := time.Since(pendingSince); cost < settle {
+			w.logit("[watch] version changed, waiting to settle, stable=", cost, ", settle=", settle)
+			return false
+		}
+		return true
+	}
+
+	// 下次检查的等待时间，有待稳定的版本时，需要尽快检查
+	nextWait := func() time.Duration {
+		if len(pendingVersion) == 0 {
+			return dur
+		}
+		return min(dur, max(settle-time.Since(pendingSince), 10*time.Millisecond))
+	}
+
 	doCheck := func() bool {
 		defer func() {
 			if re := recover(); re != nil {
@@ -247,17 +292,22 @@ func (w *Worker) watch(ctx context.Context) {
 		var err error
 
 		newVersion := w.option.version()
-		change := oldVersion != newVersion
-		pid := w.getLastPID()
-
-		exists := pidExists(pid)
+		change := checkChange(newVersion)
+		dead := w.deadInstances()
 
-		if !exists || change {
-			w.logit("[watch] reload it start...", "pid=", pid, ", exists=", exists, ", version_change=", change, ", ", st.String())
-			err = w.reload(w.cmdCtx)
+		if len(dead) > 0 || change {
+			w.logit("[watch] reload it start...", "dead_instances=", len(dead), ", version_change=", change, ", ", st.String())
+			if change {
+				err = w.reload(w.cmdCtx)
+			} else {
+				err = w.reloadInstances(w.cmdCtx, dead, len(dead), false)
+			}
 			w.logit("[watch] reload it finish, err=", err)
 			if err == nil {
-				oldVersion = newVersion
+				if change {
+					oldVersion = newVersion
+					pendingVersion = ""
+				}
 				st.SucTimes++
 				st.LastSuc = time.Now()
 			} else {
@@ -265,15 +315,29 @@ func (w *Worker) watch(ctx context.Context) {
 				st.LastFail = time.Now()
 			}
 		} else {
-			w.logit("[watch] not change, pid=", pid, ", version=", newVersion)
+			w.logit("[watch] not change, pids=", w.getPIDs(), ", version=", newVersion)
 		}
 		return true
 	}
-	for range tk.C {
-		if !doCheck() {
-			return
+
+	notifyCh := w.notify(ctx)
+	for {
+		select {
+		case <-tk.C:
+			if !doCheck() {
+				return
+			}
+			tk.Reset(nextWait())
+		case <-notifyCh:
+			// 文件有变化，稍等片刻再检查，以合并短时间内的多次变化
+			if !tk.Stop() {
+				select {
+				case <-tk.C:
+				default:
+				}
+			}
+			tk.Reset(notifyDelay)
 		}
-		tk.Reset(dur)
 	}
 }
 
@@ -290,7 +354,51 @@ func (w *Worker) logitDepth(depth int, msgs ...any) {
 	_ = w.main.Logger.Output(depth, msg)
 }
 
-func (w *Worker) forkAndStart(ctx context.Context) (ret error) {
+// forkAll 启动所有的子进程实例
+func (w *Worker) forkAll(ctx context.Context) error {
+	if err := w.preStart(ctx); err != nil {
+		return err
+	}
+	var errs []error
+	for _, ins := range w.instances {
+		if err := w.forkAndStart(ctx, ins); err != nil {
+			errs = append(errs, fmt.Errorf("instance[%d]: %w", ins.index, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// afterFirstStart 首次启动子进程后，等待 StartWait 时长，对依然存活的子进程执行 HookPostStart
+func (w *Worker) afterFirstStart(ctx context.Context) {
+	select {
+	case <-ctx.Done():
+		return
+	case <-time.After(w.getStartWait()):
+	}
+	for _, ins := range w.instances {
+		var pid int
+		_ = w.withLock(func() error {
+			pid = ins.pid
+			return nil
+		})
+		if pidExists(pid) {
+			_ = w.runHooks(ctx, HookPostStart, ins, pid)
+		}
+	}
+}
+
+// preStart 执行 HookPreStart，在 fork 所有实例之前执行一次
+func (w *Worker) preStart(ctx context.Context) error {
+	if err := w.runHooks(ctx, HookPreStart, nil, 0); err != nil {
+		return err
+	}
+	return w.withLock(func() error {
+		w.preStarted = true
+		return nil
+	})
+}
+
+func (w *Worker) forkAndStart(ctx context.Context, ins *instance) (ret error) {
 	files := make([]*os.File, len(w.resources))
 	// 依次获取 *os.File,之后将通过 进程的 ExtraFiles 属性传递给子进程
 	for idx, s := range w.resources {
@@ -317,7 +425,7 @@ func (w *Worker) forkAndStart(ctx context.Context) (ret error) {
 	}
 
 	envs := append(os.Environ(), userEnv...)
-	envs = append(envs, envsForSubProcess()...)
+	envs = append(envs, envsForSubProcess(ins.index)...)
 
 	ctx, cancel := context.WithCancel(ctx)
 	cmdName, args := w.option.getWorkerCmd()
@@ -325,24 +433,35 @@ func (w *Worker) forkAndStart(ctx context.Context) (ret error) {
 	cmd.Dir = w.option.HomeDir
 	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
 
-	w.logit("fork new sub_process, work_dir=", cmd.Dir, ", cmd=", cmd.String())
+	w.logit("fork new sub_process, instance=", ins.index, ", work_dir=", cmd.Dir, ", cmd=", cmd.String())
 
 	cmd.Env = envs
-	// cmd.Stdout = os.Stdout
-	cmd.Stdout = w.stdout
-	// cmd.Stderr = os.Stderr
-	cmd.Stderr = w.stderr
+	stdout := w.cmdLogWriter(w.stdout, ins)
+	cmd.Stdout = stdout
+	stderr := w.cmdLogWriter(w.stderr, ins)
+	cmd.Stderr = stderr
 	cmd.ExtraFiles = files
+	// 子进程退出或者启动失败后，需要关闭 stdout 和 stderr，以写入剩余未换行的内容
+	closeLogs := func() {
+		_ = stdout.Close()
+		_ = stderr.Close()
+	}
+	if err := w.applyLimits(cmd); err != nil {
+		cancel()
+		closeLogs()
+		return fmt.Errorf("apply Limits failed: %w", err)
+	}
 	err := cmd.Start()
 	w.logit("cmd.Start, err=", err)
 	if err != nil {
 		cancel()
+		closeLogs()
 		return err
 	}
 
 	_ = w.withLock(func() error {
-		w.pid = cmd.Process.Pid
-		w.cmdClose = cancel
+		ins.pid = cmd.Process.Pid
+		ins.cmdClose = cancel
 		return nil
 	})
 
@@ -356,8 +475,11 @@ func (w *Worker) forkAndStart(ctx context.Context) (ret error) {
 			}
 		}()
 
-		logFields := make(map[string]any)
+		logFields := map[string]any{
+			"instance": ins.index,
+		}
 		errWait := cmd.Wait()
+		closeLogs()
 		if cmd.Process != nil {
 			logFields["pid"] = cmd.Process.Pid
 		}
@@ -375,9 +497,28 @@ func (w *Worker) withLock(fn func() error) error {
 	return fn()
 }
 
-func (w *Worker) subProcessExists() bool {
-	pid := w.getLastPID()
-	return pidExists(pid)
+// deadInstances 返回子进程不存在的实例
+func (w *Worker) deadInstances() []*instance {
+	w.mux.Lock()
+	defer w.mux.Unlock()
+	var dead []*instance
+	for _, ins := range w.instances {
+		if !pidExists(ins.pid) {
+			dead = append(dead, ins)
+		}
+	}
+	return dead
+}
+
+// getPIDs 返回所有实例的子进程 pid
+func (w *Worker) getPIDs() []int {
+	w.mux.Lock()
+	defer w.mux.Unlock()
+	pids := make([]int, len(w.instances))
+	for i, ins := range w.instances {
+		pids[i] = ins.pid
+	}
+	return pids
 }
 
 // keepPrecess 检查检查是否存在
@@ -392,13 +533,13 @@ func (w *Worker) keepPrecess(ctx context.Context) (err error) {
 		return nil
 	}
 
-	pid := w.getLastPID()
-	if w.subProcessExists() {
-		w.logit("[keepPrecess] work process exists, pid=", pid)
+	dead := w.deadInstances()
+	if len(dead) == 0 {
+		w.logit("[keepPrecess] work process exists, pids=", w.getPIDs())
 		return nil
 	}
 
-	w.logit("[keepPrecess] work process not exists, will reload it, pid=", pid)
+	w.logit("[keepPrecess] work process not exists, will reload it, dead_instances=", len(dead), ", pids=", w.getPIDs())
 
 	// 避免子进程有异常时， 不停重启服务导致 CPU 消耗特别高
 	if !lastExit.IsZero() && time.Since(lastExit) < time.Second {
@@ -409,22 +550,22 @@ func (w *Worker) keepPrecess(ctx context.Context) (err error) {
 	w.lastExit = time.Now()
 	w.mux.Unlock()
 
-	// 若进程不存在，则执行 reload
-	return w.reload(ctx)
+	// 若进程不存在，则执行 reload，已经不存在的实例不影响可用性，所以同时重启
+	return w.reloadInstances(ctx, dead, len(dead), false)
 }
 
-func (w *Worker) getLastPID() int {
-	w.mux.Lock()
-	defer w.mux.Unlock()
-	return w.pid
+// reload 执行 reload 动作，所有实例依次滚动 reload
+// 这个方法都是由 master 进程来调用的
+func (w *Worker) reload(ctx context.Context) error {
+	return w.reloadInstances(ctx, w.instances, w.option.getMaxUnavailable(), true)
 }
 
-// reload 执行 reload 动作
-// 这个方法都是由 master 进程来调用的
+// reloadInstances 分批 reload 指定的实例，每批最多 batch 个实例
 //
-//  1. fork 新子进程
-//  2. stop 旧的子进程
-func (w *Worker) reload(ctx context.Context) (err error) {
+// 若 preStart 为 true，会在 fork 之前执行一次 HookPreStart；
+// 子进程异常退出后的重启，只有在 HookPreStart 从未执行成功时才会执行。
+// 若某一批 reload 失败，则中止，剩余的实例继续使用老的子进程
+func (w *Worker) reloadInstances(ctx context.Context, list []*instance, batch int, preStart bool) (err error) {
 	// -----------------------------------------------------------------
 	// 添加状态判断，避免多种条件在同时触发 reload
 	w.mux.Lock()
@@ -444,35 +585,80 @@ func (w *Worker) reload(ctx context.Context) (err error) {
 	}()
 	// -----------------------------------------------------------------
 
-	w.logit("start reloading  ...")
+	w.logit("start reloading  ..., instances=", len(list), ", batch=", batch)
 	defer func() {
 		w.logit("reload finish, error=", err)
 	}()
 
-	if err1 := ctx.Err(); err != nil {
-		return err1
+	if !preStart {
+		_ = w.withLock(func() error {
+			preStart = !w.preStarted
+			return nil
+		})
+	}
+	if preStart {
+		if err = w.preStart(ctx); err != nil {
+			return err
+		}
 	}
 
-	lastCmdCancel := w.cmdClose
+	if batch < 1 {
+		batch = 1
+	}
 
-	lastPID := w.getLastPID()
+	for start := 0; start < len(list); start += batch {
+		end := min(start+batch, len(list))
+		var eg errgroup.Group
+		for _, ins := range list[start:end] {
+			ins := ins
+			eg.Go(func() error {
+				return w.reloadInstance(ctx, ins)
+			})
+		}
+		if err = eg.Wait(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reloadInstance reload 一个实例
+//
+//  1. fork 新子进程
+//  2. stop 旧的子进程
+func (w *Worker) reloadInstance(ctx context.Context, ins *instance) (err error) {
+	if err1 := ctx.Err(); err1 != nil {
+		return err1
+	}
+
+	var lastCmdCancel context.CancelFunc
+	var lastPID int
+	_ = w.withLock(func() error {
+		lastCmdCancel = ins.cmdClose
+		lastPID = ins.pid
+		return nil
+	})
 
 	// 启动新进程
-	if errFork := w.forkAndStart(ctx); errFork != nil {
+	if errFork := w.forkAndStart(ctx, ins); errFork != nil {
 		return errFork
 	}
-	newPID := w.getLastPID()
+	var newPID int
+	_ = w.withLock(func() error {
+		newPID = ins.pid
+		return nil
+	})
 
 	checkNewPID := func() error {
-		if w.subProcessExists() {
+		if pidExists(newPID) {
 			return nil
 		}
 		_ = w.withLock(func() error {
-			w.pid = lastPID
-			w.cmdClose = lastCmdCancel
+			ins.pid = lastPID
+			ins.cmdClose = lastCmdCancel
 			return nil
 		})
-		errCheck := fmt.Errorf("new process pid=%d not exists, restore pid=%d", newPID, lastPID)
+		errCheck := fmt.Errorf("instance[%d] new process pid=%d not exists, restore pid=%d", ins.index, newPID, lastPID)
 		w.logit(errCheck.Error())
 		return errCheck
 	}
@@ -500,9 +686,11 @@ doCheck:
 		return errCheck
 	}
 
+	_ = w.runHooks(ctx, HookPostStart, ins, newPID)
+
 	// 优雅关闭老的子进程
-	err = w.stopCmd(ctx, lastPID)
-	w.logit("stop pid=", lastPID, ", err=", err)
+	err = w.stopInstance(ctx, ins, lastPID)
+	w.logit("instance[", ins.index, "] stop pid=", lastPID, ", err=", err)
 
 	if lastCmdCancel != nil {
 		lastCmdCancel()
@@ -520,6 +708,17 @@ func (w *Worker) stopCmd(ctx context.Context, pid int) error {
 	return stopCmd(ctx, pid)
 }
 
+// stopInstance 停止实例的子进程，并执行 HookPreStop、HookPostStop
+func (w *Worker) stopInstance(ctx context.Context, ins *instance, pid int) error {
+	if !pidExists(pid) {
+		return nil
+	}
+	_ = w.runHooks(ctx, HookPreStop, ins, pid)
+	err := w.stopCmd(ctx, pid)
+	_ = w.runHooks(ctx, HookPostStop, ins, pid)
+	return err
+}
+
 func (w *Worker) getStopTimeout() time.Duration {
 	if t := w.option.getStopTimeout(); t > 0 {
 		return t
@@ -535,7 +734,15 @@ func (w *Worker) getStartWait() time.Duration {
 }
 
 func (w *Worker) stop(ctx context.Context) error {
-	return w.stopCmd(ctx, w.getLastPID())
+	var eg errgroup.Group
+	for idx, pid := range w.getPIDs() {
+		ins := w.instances[idx]
+		pid := pid
+		eg.Go(func() error {
+			return w.stopInstance(ctx, ins, pid)
+		})
+	}
+	return eg.Wait()
 }
 
 // Resource 将配置的 Listen 的第 index 个 元素解析为可传递使用的 Resource
@@ -0,0 +1,226 @@
+// Copyright(C) 2026 github.com/fsgo  All Rights Reserved.
+// Author: hidu <duv123@gmail.com>
+// Date: 2026/10/19
+
+package grace
+
+import (
+	"context"
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/fsgo/fst"
+)
+
+// newTestWorker 创建一个运行 sh 的 worker，
+// 若 HomeDir 下存在 fail.{实例序号} 文件，子进程会立即退出，否则将实例序号写入 HomeDir/instances.txt
+func newTestWorker(t *testing.T, instances int, maxUnavailable int) *Worker {
+	dir := t.TempDir()
+	cfg := &WorkerConfig{
+		HomeDir: dir,
+		LogDir:  filepath.Join(dir, "log"),
+		Cmd:     "sh",
+		CmdArgs: []string{"-c",
+			`test -f fail.$FsgoGraceInstance && exit 1; echo $FsgoGraceInstance >> instances.txt; exec sleep 30`,
+		},
+		StartWait:      "100ms",
+		StopTimeout:    "1s",
+		Instances:      instances,
+		MaxUnavailable: maxUnavailable,
+		Log:            &WorkerLog{NoTee: true},
+	}
+	fst.NoError(t, cfg.Parser())
+	w := NewWorker(cfg)
+	w.name = "demo"
+	w.main = &Grace{Logger: log.New(os.Stderr, "", 0)}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	w.cmdCtx = ctx
+	done := make(chan struct{})
+	go func() {
+		// 子进程退出后会发送 event，测试中没有 start 的循环来消费它
+		for {
+			select {
+			case <-w.event:
+			case <-done:
+				return
+			}
+		}
+	}()
+	t.Cleanup(func() {
+		cancel()
+		close(done)
+	})
+	return w
+}
+
+// waitStarted 等待 instances.txt 中有 n 行，并返回其内容
+func waitStarted(t *testing.T, w *Worker, n int) []string {
+	name := filepath.Join(w.option.HomeDir, "instances.txt")
+	for i := 0; i < 100; i++ {
+		bf, _ := os.ReadFile(name)
+		if lines := strings.Fields(string(bf)); len(lines) >= n {
+			return lines
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("%d instances not started", n)
+	return nil
+}
+
+// waitDead 等待子进程退出并被回收
+func waitDead(t *testing.T, pid int) {
+	for i := 0; i < 100; i++ {
+		if !pidExists(pid) {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("pid=%d still exists", pid)
+}
+
+func TestWorker_reloadInstances(t *testing.T) {
+	t.Run("batch", func(t *testing.T) {
+		w := newTestWorker(t, 3, 2)
+
+		var mux sync.Mutex
+		var events []HookEvent
+		record := func(ctx context.Context, e HookEvent) error {
+			mux.Lock()
+			events = append(events, e)
+			mux.Unlock()
+			return nil
+		}
+		w.OnHook(HookPostStart, record)
+		w.OnHook(HookPreStop, record)
+
+		fst.NoError(t, w.forkAll(w.cmdCtx))
+		oldPIDs := w.getPIDs()
+		fst.Len(t, w.deadInstances(), 0)
+
+		fst.NoError(t, w.reload(w.cmdCtx))
+		newPIDs := w.getPIDs()
+		for i := range oldPIDs {
+			fst.NotEqual(t, oldPIDs[i], newPIDs[i])
+			waitDead(t, oldPIDs[i])
+		}
+
+		// 第一批是实例 0 和 1，第二批是实例 2，每个实例都是先 PostStart 再 PreStop
+		fst.Len(t, events, 6)
+		var first []int
+		for _, e := range events[:4] {
+			first = append(first, e.Instance)
+		}
+		slices.Sort(first)
+		fst.Equal(t, []int{0, 0, 1, 1}, first)
+		fst.Equal(t, HookEvent{Stage: HookPostStart, Worker: "demo", Instance: 2, PID: newPIDs[2]}, events[4])
+		fst.Equal(t, HookEvent{Stage: HookPreStop, Worker: "demo", Instance: 2, PID: oldPIDs[2]}, events[5])
+		for _, ins := range []int{0, 1} {
+			var stages []HookStage
+			for _, e := range events[:4] {
+				if e.Instance == ins {
+					stages = append(stages, e.Stage)
+				}
+			}
+			fst.Equal(t, []HookStage{HookPostStart, HookPreStop}, stages)
+		}
+	})
+
+	t.Run("abort", func(t *testing.T) {
+		w := newTestWorker(t, 3, 1)
+		fst.NoError(t, w.forkAll(w.cmdCtx))
+		waitStarted(t, w, 3)
+		oldPIDs := w.getPIDs()
+
+		// 实例 1 的新进程会启动失败，reload 中止，实例 1 和 2 继续使用老的子进程
+		fst.NoError(t, os.WriteFile(filepath.Join(w.option.HomeDir, "fail.1"), nil, 0644))
+		err := w.reload(w.cmdCtx)
+		fst.Error(t, err)
+		fst.True(t, strings.Contains(err.Error(), "instance[1]"))
+
+		newPIDs := w.getPIDs()
+		fst.NotEqual(t, oldPIDs[0], newPIDs[0])
+		fst.Equal(t, oldPIDs[1:], newPIDs[1:])
+		fst.True(t, pidExists(newPIDs[1]))
+		fst.True(t, pidExists(newPIDs[2]))
+		fst.False(t, w.isReloading)
+	})
+
+	t.Run("dead", func(t *testing.T) {
+		w := newTestWorker(t, 2, 1)
+		fst.NoError(t, w.forkAll(w.cmdCtx))
+		oldPIDs := w.getPIDs()
+
+		fst.NoError(t, syscall.Kill(oldPIDs[1], syscall.SIGKILL))
+		waitDead(t, oldPIDs[1])
+		dead := w.deadInstances()
+		fst.Len(t, dead, 1)
+		fst.Equal(t, 1, dead[0].index)
+
+		fst.NoError(t, w.reloadInstances(w.cmdCtx, dead, len(dead), false))
+		newPIDs := w.getPIDs()
+		fst.Equal(t, oldPIDs[0], newPIDs[0])
+		fst.NotEqual(t, oldPIDs[1], newPIDs[1])
+		fst.Len(t, w.deadInstances(), 0)
+	})
+}
+
+func TestWorker_preStart(t *testing.T) {
+	w := newTestWorker(t, 3, 2)
+	var mux sync.Mutex
+	var events []HookEvent
+	fail := true
+	w.OnHook(HookPreStart, func(ctx context.Context, e HookEvent) error {
+		mux.Lock()
+		defer mux.Unlock()
+		events = append(events, e)
+		if fail {
+			return errors.New("pre start failed")
+		}
+		return nil
+	})
+
+	// HookPreStart 失败，不会 fork 任何实例
+	fst.Error(t, w.forkAll(w.cmdCtx))
+	fst.Equal(t, []int{0, 0, 0}, w.getPIDs())
+	fst.Equal(t, []HookEvent{{Stage: HookPreStart, Worker: "demo", Instance: -1}}, events)
+
+	// HookPreStart 从未成功，所以重启不存在的实例时也会执行
+	fail = false
+	fst.NoError(t, w.reloadInstances(w.cmdCtx, w.deadInstances(), 3, false))
+	fst.Len(t, events, 2)
+	fst.Len(t, w.deadInstances(), 0)
+
+	// 异常退出后的重启不会执行
+	pid := w.getPIDs()[0]
+	fst.NoError(t, syscall.Kill(pid, syscall.SIGKILL))
+	waitDead(t, pid)
+	fst.NoError(t, w.reloadInstances(w.cmdCtx, w.deadInstances(), 3, false))
+	fst.Len(t, events, 2)
+
+	// reload 时，对所有实例只执行一次
+	fst.NoError(t, w.reload(w.cmdCtx))
+	fst.Len(t, events, 3)
+}
+
+func TestWorker_instanceEnv(t *testing.T) {
+	w := newTestWorker(t, 3, 1)
+	fst.NoError(t, w.forkAll(w.cmdCtx))
+
+	lines := waitStarted(t, w, 3)
+	slices.Sort(lines)
+	fst.Equal(t, []string{"0", "1", "2"}, lines)
+
+	fst.True(t, slices.Contains(envsForSubProcess(2), "FsgoGraceInstance=2"))
+
+	// 当前进程不是由 master 派生的
+	fst.Equal(t, -1, InstanceIndex())
+}
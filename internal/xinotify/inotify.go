@@ -0,0 +1,98 @@
+// Copyright(C) 2026 github.com/fsgo  All Rights Reserved.
+// Author: hidu <duv123@gmail.com>
+// Date: 2026/10/19
+
+// Package xinotify 对 linux inotify 的简单封装，非 linux 系统上 New 会返回 ErrNotSupported
+package xinotify
+
+import (
+	"errors"
+	"strings"
+)
+
+// ErrNotSupported 当前系统不支持 inotify
+var ErrNotSupported = errors.New("inotify not supported")
+
+// Op 事件类型，一个事件可能同时包含多个类型
+type Op uint32
+
+const (
+	// OpCreate 文件或目录被创建
+	OpCreate Op = 1 << iota
+
+	// OpWrite 文件内容被修改
+	OpWrite
+
+	// OpCloseWrite 以可写方式打开的文件被关闭了，一般意味着写入已完成
+	OpCloseWrite
+
+	// OpRemove 文件或目录被删除
+	OpRemove
+
+	// OpMoveFrom 文件或目录被移出（rename 的源）
+	OpMoveFrom
+
+	// OpMoveTo 文件或目录被移入（rename 的目标）
+	OpMoveTo
+
+	// OpChmod 文件属性发生变化
+	OpChmod
+
+	// OpSelfRemove 被监听的文件或目录自身被删除或移走了
+	OpSelfRemove
+
+	// OpIgnored 监听已被移除（主动移除或者被监听的对象已不存在）
+	OpIgnored
+
+	// OpOverflow 事件队列溢出，有事件丢失，调用方应做一次全量扫描
+	OpOverflow
+)
+
+var opNames = []string{
+	"create",
+	"write",
+	"close_write",
+	"remove",
+	"move_from",
+	"move_to",
+	"chmod",
+	"self_remove",
+	"ignored",
+	"overflow",
+}
+
+// Has 判断是否包含指定的类型
+func (o Op) Has(op Op) bool {
+	return o&op != 0
+}
+
+func (o Op) String() string {
+	var names []string
+	for i, name := range opNames {
+		if o.Has(1 << i) {
+			names = append(names, name)
+		}
+	}
+	return strings.Join(names, "|")
+}
+
+// Event inotify 事件
+type Event struct {
+	// Path 事件对应的完整路径，为 Add 时的路径加上事件中的文件名
+	Path string
+
+	// WD 事件所属的监听
+	WD int
+
+	// Op 事件类型
+	Op Op
+
+	// Cookie 用于关联同一次 rename 的 OpMoveFrom 和 OpMoveTo 事件
+	Cookie uint32
+
+	// IsDir 事件对象是否是目录
+	IsDir bool
+}
+
+// OpAll 除 OpIgnored、OpOverflow 外的所有事件，用于 Add
+const OpAll = OpCreate | OpWrite | OpCloseWrite | OpRemove | OpMoveFrom | OpMoveTo | OpChmod | OpSelfRemove
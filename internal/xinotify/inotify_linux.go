@@ -0,0 +1,179 @@
+// Copyright(C) 2026 github.com/fsgo  All Rights Reserved.
+// Author: hidu <duv123@gmail.com>
+// Date: 2026/10/19
+
+//go:build linux
+
+package xinotify
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"unsafe"
+)
+
+var opMasks = map[Op]uint32{
+	OpCreate:     syscall.IN_CREATE,
+	OpWrite:      syscall.IN_MODIFY,
+	OpCloseWrite: syscall.IN_CLOSE_WRITE,
+	OpRemove:     syscall.IN_DELETE,
+	OpMoveFrom:   syscall.IN_MOVED_FROM,
+	OpMoveTo:     syscall.IN_MOVED_TO,
+	OpChmod:      syscall.IN_ATTRIB,
+	OpSelfRemove: syscall.IN_DELETE_SELF | syscall.IN_MOVE_SELF,
+	OpIgnored:    syscall.IN_IGNORED,
+	OpOverflow:   syscall.IN_Q_OVERFLOW,
+}
+
+func toMask(op Op) uint32 {
+	var mask uint32
+	for o, m := range opMasks {
+		if op.Has(o) {
+			mask |= m
+		}
+	}
+	return mask
+}
+
+func toOp(mask uint32) Op {
+	var op Op
+	for o, m := range opMasks {
+		if mask&m != 0 {
+			op |= o
+		}
+	}
+	return op
+}
+
+// Watcher inotify 实例
+type Watcher struct {
+	file   *os.File
+	fd     int
+	events chan Event
+	errors chan error
+	paths  map[int]string
+	done   chan struct{}
+	mux    sync.RWMutex
+	once   sync.Once
+}
+
+// New 创建新的 inotify 实例，并开始异步读取事件
+func New() (*Watcher, error) {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return nil, os.NewSyscallError("inotify_init1", err)
+	}
+	w := &Watcher{
+		// fd 是非阻塞的，os.File 会使用 runtime 的 poller，Close 时能让 Read 立即返回
+		// 不能调用 file.Fd()，否则 fd 会被设置为阻塞模式
+		file:   os.NewFile(uintptr(fd), "inotify"),
+		fd:     fd,
+		events: make(chan Event, 128),
+		errors: make(chan error, 1),
+		paths:  make(map[int]string),
+		done:   make(chan struct{}),
+	}
+	go w.readEvents()
+	return w, nil
+}
+
+// Add 添加对文件或目录的监听，返回监听的 ID
+//
+// 对目录的监听只包含目录下一级的文件，不会递归
+func (w *Watcher) Add(path string, op Op) (int, error) {
+	path = filepath.Clean(path)
+	wd, err := syscall.InotifyAddWatch(w.fd, path, toMask(op))
+	if err != nil {
+		return 0, &os.PathError{Op: "inotify_add_watch", Path: path, Err: err}
+	}
+	w.mux.Lock()
+	w.paths[wd] = path
+	w.mux.Unlock()
+	return wd, nil
+}
+
+// Remove 移除监听
+func (w *Watcher) Remove(wd int) error {
+	w.mux.Lock()
+	delete(w.paths, wd)
+	w.mux.Unlock()
+	_, err := syscall.InotifyRmWatch(w.fd, uint32(wd))
+	if err != nil {
+		return os.NewSyscallError("inotify_rm_watch", err)
+	}
+	return nil
+}
+
+// Events 事件，在 Close 之后会被关闭
+func (w *Watcher) Events() <-chan Event {
+	return w.events
+}
+
+// Errors 读取事件时发生的错误，发生错误后 Events 会被关闭
+func (w *Watcher) Errors() <-chan error {
+	return w.errors
+}
+
+// Close 关闭
+func (w *Watcher) Close() error {
+	var err error
+	w.once.Do(func() {
+		close(w.done)
+		err = w.file.Close()
+	})
+	return err
+}
+
+func (w *Watcher) readEvents() {
+	defer close(w.events)
+	buf := make([]byte, 64*(syscall.SizeofInotifyEvent+syscall.NAME_MAX+1))
+	for {
+		n, err := w.file.Read(buf)
+		if err != nil {
+			if !errors.Is(err, os.ErrClosed) && !errors.Is(err, io.EOF) {
+				w.errors <- err
+			}
+			return
+		}
+		var offset int
+		for offset+syscall.SizeofInotifyEvent <= n {
+			raw := (*syscall.InotifyEvent)(unsafe.Pointer(&buf[offset]))
+			nameStart := offset + syscall.SizeofInotifyEvent
+			nameEnd := nameStart + int(raw.Len)
+			if nameEnd > n {
+				break
+			}
+			name := string(bytes.TrimRight(buf[nameStart:nameEnd], "\x00"))
+			offset = nameEnd
+
+			w.mux.RLock()
+			path := w.paths[int(raw.Wd)]
+			w.mux.RUnlock()
+			if raw.Mask&syscall.IN_IGNORED != 0 {
+				w.mux.Lock()
+				delete(w.paths, int(raw.Wd))
+				w.mux.Unlock()
+			}
+			if len(name) > 0 {
+				path = filepath.Join(path, name)
+			}
+			event := Event{
+				Path:   path,
+				WD:     int(raw.Wd),
+				Op:     toOp(raw.Mask),
+				Cookie: raw.Cookie,
+				IsDir:  raw.Mask&syscall.IN_ISDIR != 0,
+			}
+			select {
+			case w.events <- event:
+			case <-w.done:
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,53 @@
+// Copyright(C) 2026 github.com/fsgo  All Rights Reserved.
+// Author: hidu <duv123@gmail.com>
+// Date: 2026/10/19
+
+//go:build linux
+
+package xinotify
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/fsgo/fst"
+)
+
+func TestWatcher(t *testing.T) {
+	dir := t.TempDir()
+	w, err := New()
+	fst.NoError(t, err)
+
+	_, err = w.Add(dir, OpAll)
+	fst.NoError(t, err)
+
+	fp := filepath.Join(dir, "a.txt")
+	fst.NoError(t, os.WriteFile(fp, []byte("hello"), 0644))
+	fst.NoError(t, os.Rename(fp, fp+".1"))
+
+	var got Op
+	timeout := time.After(3 * time.Second)
+	for !got.Has(OpMoveTo) {
+		select {
+		case e := <-w.Events():
+			got |= e.Op
+		case <-timeout:
+			t.Fatalf("timeout, got=%s", got)
+		}
+	}
+	fst.True(t, got.Has(OpCreate))
+	fst.True(t, got.Has(OpCloseWrite))
+	fst.True(t, got.Has(OpMoveFrom))
+
+	fst.NoError(t, w.Close())
+	select {
+	case _, ok := <-w.Events():
+		for ok {
+			_, ok = <-w.Events()
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Events not closed after Close")
+	}
+}
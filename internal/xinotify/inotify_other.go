@@ -0,0 +1,40 @@
+// Copyright(C) 2026 github.com/fsgo  All Rights Reserved.
+// Author: hidu <duv123@gmail.com>
+// Date: 2026/10/19
+
+//go:build !linux
+
+package xinotify
+
+// Watcher inotify 实例
+type Watcher struct{}
+
+// New 创建新的 inotify 实例，当前系统不支持，总是返回 ErrNotSupported
+func New() (*Watcher, error) {
+	return nil, ErrNotSupported
+}
+
+// Add 添加对文件或目录的监听
+func (w *Watcher) Add(path string, op Op) (int, error) {
+	return 0, ErrNotSupported
+}
+
+// Remove 移除监听
+func (w *Watcher) Remove(wd int) error {
+	return ErrNotSupported
+}
+
+// Events 事件
+func (w *Watcher) Events() <-chan Event {
+	return nil
+}
+
+// Errors 读取事件时发生的错误
+func (w *Watcher) Errors() <-chan error {
+	return nil
+}
+
+// Close 关闭
+func (w *Watcher) Close() error {
+	return nil
+}